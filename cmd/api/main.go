@@ -1,20 +1,33 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/IslamCHup/coworking-manager-project/internal/config"
+	"github.com/IslamCHup/coworking-manager-project/internal/events"
+	"github.com/IslamCHup/coworking-manager-project/internal/invoice"
 	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/notification"
+	"github.com/IslamCHup/coworking-manager-project/internal/payment"
+	"github.com/IslamCHup/coworking-manager-project/internal/realtime"
 
 	"github.com/IslamCHup/coworking-manager-project/internal/redis"
 	"github.com/IslamCHup/coworking-manager-project/internal/repository"
 	"github.com/IslamCHup/coworking-manager-project/internal/service"
+	"github.com/IslamCHup/coworking-manager-project/internal/storage"
 	"github.com/IslamCHup/coworking-manager-project/internal/transport"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 
 	"net/http"
 	_ "net/http/pprof"
+
+	// База часовых поясов для площадок: в alpine-образе ее нет
+	_ "time/tzdata"
 )
 
 func main() {
@@ -47,30 +60,201 @@ func main() {
 		&models.Booking{},
 		&models.Review{},
 		&models.Place{},
+		&models.PlaceRating{},
+		&models.ReviewVote{},
+		&models.ReviewReport{},
 		&models.RefreshToken{},
+		&models.NotificationPreference{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.OutboxEvent{},
+		&models.PriceRule{},
+		&models.PromoCode{},
+		&models.PromoRedemption{},
+		&models.Plan{},
+		&models.PlanAllowance{},
+		&models.Subscription{},
+		&models.HourBucket{},
+		&models.LedgerEntry{},
+		&models.PaymentIntent{},
+		&models.PaymentWebhookEvent{},
+		&models.Invoice{},
+		&models.InvoiceLine{},
+		&models.Organization{},
+		&models.OrganizationMember{},
+		&models.OrganizationInvitation{},
+		&models.BalanceAdjustment{},
+		&models.Location{},
+		&models.Floor{},
+		&models.Zone{},
+		&models.AdminLocation{},
+		&models.FloorPlan{},
+		&models.PlaceImage{},
+		&models.MaintenanceWindow{},
+		&models.BookingRelocation{},
 	); err != nil {
 		logger.Error("Ошибка при выполнении автомиграции", "error", err)
 		return
 	}
 
+	if err := config.RunSQLMigrations(db, logger); err != nil {
+		return
+	}
+
 	bookingRepo := repository.NewBookingRepository(db, logger)
 	adminRepo := repository.NewAdminRepository(db, logger)
 	userRepo := repository.NewUserRepository(db, logger)
 	placeRepo := repository.NewPlaceRepository(db, logger)
 	refreshRepo := repository.NewRefreshTokenRepository(db, logger)
 	reviewRepo := repository.NewReviewRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db, logger)
+	webhookRepo := repository.NewWebhookRepository(db, logger)
+	outboxRepo := repository.NewOutboxRepository(db, logger)
+	priceRuleRepo := repository.NewPriceRuleRepository(db, logger)
+	promoRepo := repository.NewPromoRepository(db, logger)
+	subscriptionRepo := repository.NewSubscriptionRepository(db, logger)
+	ledgerRepo := repository.NewLedgerRepository(db, logger)
+	paymentRepo := repository.NewPaymentRepository(db, logger)
+	invoiceRepo := repository.NewInvoiceRepository(db, logger)
+	organizationRepo := repository.NewOrganizationRepository(db, logger)
+	adjustmentRepo := repository.NewAdjustmentRepository(db, logger)
+	reportRepo := repository.NewReportRepository(db, logger)
+	locationRepo := repository.NewLocationRepository(db, logger)
+	floorPlanRepo := repository.NewFloorPlanRepository(db, logger)
+	placeImageRepo := repository.NewPlaceImageRepository(db, logger)
+	maintenanceRepo := repository.NewMaintenanceRepository(db, logger)
+	relocationRepo := repository.NewRelocationRepository(db, logger)
+
+	notifiers := []notification.Notifier{notification.NewLogNotifier(logger)}
+	if smtpCfg := notification.SMTPConfigFromEnv(); smtpCfg.Host != "" {
+		notifiers = append(notifiers, notification.NewEmailNotifier(smtpCfg, logger))
+	}
+
+	reminderMinutes, err := strconv.Atoi(os.Getenv("NOTIFY_REMINDER_MINUTES"))
+	if err != nil || reminderMinutes <= 0 {
+		reminderMinutes = 60
+	}
+
+	webhookMaxAttempts, _ := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS"))
+
+	publicURL := os.Getenv("PUBLIC_URL")
+	if publicURL == "" {
+		publicURL = "http://localhost:" + os.Getenv("PORT")
+	}
+
+	// Платежный провайдер подключается только явно. Пока есть лишь тестовый:
+	// PAYMENT_PROVIDER=fake поднимает страницу оплаты на /payments/fake, которая
+	// шлет подписанный вебхук обратно в /payments/webhook. Без провайдера
+	// онлайн-пополнение отключено.
+	var paymentProvider payment.PaymentProvider
+	var fakeProvider *payment.FakeProvider
+	switch provider := os.Getenv("PAYMENT_PROVIDER"); provider {
+	case "":
+		logger.Warn("PAYMENT_PROVIDER не задан, онлайн-пополнение баланса отключено")
+	case "fake":
+		webhookSecret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+		if webhookSecret == "" {
+			logger.Error("PAYMENT_WEBHOOK_SECRET не задан, вебхуки нельзя подписать")
+			return
+		}
+		logger.Warn("подключен тестовый платежный провайдер, не используйте его в продакшене")
+		fakeProvider = payment.NewFakeProvider(webhookSecret, publicURL+"/payments/fake", publicURL+"/payments/webhook", logger)
+		paymentProvider = fakeProvider
+	default:
+		logger.Error("неизвестный платежный провайдер", "provider", provider)
+		return
+	}
+	paymentTTLMinutes, _ := strconv.Atoi(os.Getenv("PAYMENT_INTENT_TTL_MINUTES"))
+
+	vatRate, err := strconv.Atoi(os.Getenv("VAT_RATE"))
+	if err != nil || vatRate < 0 {
+		vatRate = 20
+	}
+	paymentTermsDays, _ := strconv.Atoi(os.Getenv("INVOICE_PAYMENT_TERMS_DAYS"))
+	adjustmentThreshold, err := strconv.Atoi(os.Getenv("ADJUSTMENT_APPROVAL_THRESHOLD"))
+	if err != nil || adjustmentThreshold < 0 {
+		adjustmentThreshold = 500000
+	}
+	reviewReportThreshold, err := strconv.Atoi(os.Getenv("REVIEW_REPORT_THRESHOLD"))
+	if err != nil || reviewReportThreshold <= 0 {
+		reviewReportThreshold = 3
+	}
 
-	bookingService := service.NewBookingService(bookingRepo, placeRepo, db, logger, redisClient)
-	placeService := service.NewPlaceService(placeRepo, db)
+	// Фотографии мест лежат на диске; отдаются по подписанным ссылкам /media/...
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "data/media"
+	}
+	blobStore, err := storage.NewLocalStore(mediaDir)
+	if err != nil {
+		logger.Error("Ошибка инициализации хранилища файлов", "error", err)
+		return
+	}
+	mediaSecret := os.Getenv("MEDIA_URL_SECRET")
+	if mediaSecret == "" {
+		// ссылки на фотографии перестанут работать после перезапуска
+		logger.Warn("MEDIA_URL_SECRET не задан, используется случайный ключ")
+		mediaSecret = rand.Text()
+	}
+	mediaTTLMinutes, err := strconv.Atoi(os.Getenv("MEDIA_URL_TTL_MINUTES"))
+	if err != nil || mediaTTLMinutes <= 0 {
+		mediaTTLMinutes = 60
+	}
+	urlSigner := storage.NewURLSigner(publicURL, mediaSecret, time.Duration(mediaTTLMinutes)*time.Minute)
+
+	notificationService := service.NewNotificationService(notificationRepo, bookingRepo, notifiers, time.Duration(reminderMinutes)*time.Minute, logger)
+	webhookService := service.NewWebhookService(webhookRepo, webhookMaxAttempts, logger)
+	broker := realtime.NewBroker(redisClient, logger)
+	availabilityService := service.NewAvailabilityService(broker, placeRepo, logger)
+	pricingService := service.NewPricingService(priceRuleRepo, placeRepo, logger)
+	promoService := service.NewPromoService(promoRepo, logger)
+	subscriptionService := service.NewSubscriptionService(subscriptionRepo, db, logger)
+	organizationService := service.NewOrganizationService(organizationRepo, userRepo, db, logger)
+	invoiceService := service.NewInvoiceService(invoiceRepo, vatRate, time.Duration(paymentTermsDays)*24*time.Hour, invoice.SellerFromEnv(), logger)
+	bookingService := service.NewBookingService(bookingRepo, placeRepo, db, logger, redisClient, pricingService, promoService, subscriptionService, organizationService, invoiceService, maintenanceRepo)
+	locationService := service.NewLocationService(locationRepo, placeRepo, bookingRepo, logger)
+	maintenanceService := service.NewMaintenanceService(maintenanceRepo, placeRepo, locationService, bookingService, logger)
+	relocationService := service.NewRelocationService(db, relocationRepo, placeRepo, bookingService, maintenanceService, locationService, logger)
+	placeService := service.NewPlaceService(placeRepo, db, bookingService, locationService, relocationService, logger)
+	placeMediaService := service.NewPlaceMediaService(placeImageRepo, placeRepo, blobStore, urlSigner, locationService, logger)
+	floorPlanService := service.NewFloorPlanService(floorPlanRepo, locationRepo, placeRepo, locationService, logger)
 	adminService := service.NewAdminService(adminRepo, logger)
 	userService := service.NewUserService(userRepo, logger)
 	authService := service.NewAuthService(userRepo, logger)
 	refreshService := service.NewRefreshService(refreshRepo, logger)
-	reviewService := service.NewReviewService(db, reviewRepo)
+	reviewService := service.NewReviewService(db, reviewRepo, locationService, reviewReportThreshold, logger)
+	ledgerService := service.NewLedgerService(ledgerRepo, logger)
+	adjustmentService := service.NewAdjustmentService(adjustmentRepo, adjustmentThreshold, logger)
+	reportService := service.NewReportService(reportRepo, logger)
+	paymentService := service.NewPaymentService(paymentRepo, paymentProvider, db, time.Duration(paymentTTLMinutes)*time.Minute, logger)
+
+	// События из outbox раздаются подписчикам внутри процесса и, если есть Redis, в Redis Streams
+	bus := events.NewBus()
+	bus.Subscribe("booking", bookingService.HandleEvent, models.EventBookingCreated, models.EventBookingStatusChanged, models.EventBookingDeleted, models.EventBookingRelocated, models.EventBookingUpdated)
+	bus.Subscribe("notification", notificationService.HandleEvent, models.EventBookingStatusChanged, models.EventBookingRelocated)
+	bus.Subscribe("webhook", webhookService.HandleEvent)
+	bus.Subscribe("availability", availabilityService.HandleEvent, models.EventBookingStatusChanged, models.EventBookingDeleted, models.EventBookingCheckedIn, models.EventBookingRelocated, models.EventBookingUpdated)
+
+	sinks := bus.Sinks()
+	if redisClient != nil {
+		sinks = append(sinks, events.NewRedisStreamSink(redisClient, 10000))
+	}
+	relay := events.NewRelay(outboxRepo, sinks, logger)
+
+	go relay.Run(context.Background(), time.Second)
+	go broker.Run(context.Background())
+	go notificationService.RunReminders(context.Background(), time.Minute)
+	go webhookService.RunWorker(context.Background(), 2*time.Second)
+	go subscriptionService.RunRenewals(context.Background(), time.Minute)
+	go paymentService.RunExpirer(context.Background(), time.Minute)
+	go invoiceService.RunMonthly(context.Background(), time.Hour)
 
 	r := gin.Default()
 
-	transport.RegisterRoutes(r, logger, bookingService, placeService, adminService, userService, authService, refreshService, reviewService)
+	transport.RegisterRoutes(r, logger, bookingService, placeService, adminService, userService, authService, refreshService, reviewService, notificationService, webhookService, availabilityService, pricingService, promoService, subscriptionService, ledgerService, paymentService, invoiceService, organizationService, adjustmentService, reportService, locationService, floorPlanService, placeMediaService, maintenanceService, relocationService)
+	if fakeProvider != nil {
+		r.GET("/payments/fake/:id", gin.WrapH(fakeProvider))
+	}
 
 	logger.Info("Запуск HTTP-сервера", "port", os.Getenv("PORT"))
 	if err := r.Run(":" + os.Getenv("PORT")); err != nil {
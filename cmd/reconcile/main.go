@@ -0,0 +1,71 @@
+// Команда reconcile сверяет User.Balance с журналом операций.
+//
+//	go run ./cmd/reconcile            — только отчет, код выхода 1 при расхождениях
+//	go run ./cmd/reconcile -backfill  — перенести в журнал балансы пользователей без проводок
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/config"
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+)
+
+func main() {
+	backfill := flag.Bool("backfill", false, "создать начальные проводки для балансов, появившихся до журнала")
+	flag.Parse()
+
+	logger := config.InitLogger()
+
+	db := config.SetupDataBase(logger)
+	if err := db.AutoMigrate(&models.LedgerEntry{}, &models.Organization{}); err != nil {
+		logger.Error("Ошибка при выполнении автомиграции", "error", err)
+		os.Exit(2)
+	}
+
+	repo := repository.NewLedgerRepository(db, logger)
+
+	drift, err := repo.FindBalanceDrift()
+	if err != nil {
+		os.Exit(2)
+	}
+
+	problems := 0
+	for _, d := range drift {
+		// Пользователь без проводок — баланс из времен до журнала, его можно перенести.
+		// Организации появились вместе с журналом, у них такого не бывает.
+		if d.Entries == 0 && d.UserID != 0 && *backfill {
+			if _, err := repo.PostOpeningBalance(d.UserID); err != nil {
+				problems++
+				continue
+			}
+			logger.Info("opening balance backfilled", "user_id", d.UserID, "amount", d.Balance)
+			continue
+		}
+
+		problems++
+		logger.Warn("balance drift",
+			"account", d.Account,
+			"balance", d.Balance,
+			"ledger_balance", d.LedgerBalance,
+			"diff", d.Balance-d.LedgerBalance,
+			"entries", d.Entries)
+	}
+
+	unbalanced, err := repo.FindUnbalancedTransactions()
+	if err != nil {
+		os.Exit(2)
+	}
+	for _, id := range unbalanced {
+		problems++
+		logger.Warn("unbalanced ledger transaction", "transaction_id", id)
+	}
+
+	if problems > 0 {
+		logger.Error("reconciliation found problems", "count", problems)
+		os.Exit(1)
+	}
+	logger.Info("ledger reconciled", "drift", 0)
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+// sqlMigrations — то, что AutoMigrate не умеет: последовательности, функции и т.п.
+// Каждый запрос должен быть идемпотентным, они выполняются при каждом старте.
+var sqlMigrations = []string{
+	// Номера счетов: значения уникальны, пропуски после откатов транзакций допустимы
+	`CREATE SEQUENCE IF NOT EXISTS invoice_number_seq`,
+
+	// Поиск мест: полнотекстовый индекс по названию, описанию и удобствам на русском
+	// и английском плюс триграммы для опечаток. Колонка вычисляемая, поэтому gorm
+	// о ней не знает и не пытается записать; при изменении выражения колонку нужно
+	// пересоздать вручную.
+	`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+	`ALTER TABLE places ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+		setweight(to_tsvector('russian', name), 'A') ||
+		setweight(to_tsvector('english', name), 'A') ||
+		setweight(jsonb_to_tsvector('russian', amenities, '["string"]'), 'B') ||
+		setweight(jsonb_to_tsvector('english', amenities, '["string"]'), 'B') ||
+		setweight(to_tsvector('russian', coalesce(description, '')), 'C') ||
+		setweight(to_tsvector('english', coalesce(description, '')), 'C')
+	) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_places_search_vector ON places USING gin (search_vector)`,
+	`CREATE INDEX IF NOT EXISTS idx_places_name_trgm ON places USING gin (lower(name) gin_trgm_ops)`,
+
+	// Флаг доступа ко всем площадкам. Раньше таким считался администратор без
+	// закрепленных площадок, поэтому при появлении колонки флаг получают именно
+	// они; дальше пустой список площадок означает отсутствие доступа.
+	`DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = 'admins' AND column_name = 'unrestricted') THEN
+			ALTER TABLE admins ADD COLUMN unrestricted boolean NOT NULL DEFAULT false;
+			UPDATE admins SET unrestricted = true
+			WHERE NOT EXISTS (SELECT 1 FROM admin_locations al WHERE al.admin_id = admins.id);
+		END IF;
+	END $$`,
+
+	// Один отзыв на пользователя и место; удаленный отзыв можно написать заново.
+	// Отзывы, написанные до ограничения, могут повторяться: перед созданием
+	// индекса у каждой пары остается самый новый, остальные удаляются мягко
+	// (их можно найти по deleted_at), а рейтинги затронутых мест
+	// пересчитываются по оставшимся одобренным.
+	`DO $$
+	DECLARE
+		affected bigint[];
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM pg_indexes
+			WHERE schemaname = current_schema() AND indexname = 'idx_reviews_user_place') THEN
+			WITH removed AS (
+				UPDATE reviews SET deleted_at = NOW()
+				WHERE id IN (
+					SELECT id FROM (
+						SELECT id, row_number() OVER (PARTITION BY user_id, place_id ORDER BY created_at DESC, id DESC) AS rn
+						FROM reviews WHERE deleted_at IS NULL
+					) ranked WHERE rn > 1
+				)
+				RETURNING place_id
+			)
+			SELECT array_agg(DISTINCT place_id) INTO affected FROM removed;
+
+			IF affected IS NOT NULL THEN
+				INSERT INTO place_ratings (place_id, count, sum, average, stars_1, stars_2, stars_3, stars_4, stars_5, updated_at)
+				SELECT p.id, count(r.id), coalesce(sum(r.rating), 0), coalesce(round(avg(r.rating), 2), 0),
+					count(r.id) FILTER (WHERE r.rating = 1), count(r.id) FILTER (WHERE r.rating = 2),
+					count(r.id) FILTER (WHERE r.rating = 3), count(r.id) FILTER (WHERE r.rating = 4),
+					count(r.id) FILTER (WHERE r.rating = 5), NOW()
+				FROM unnest(affected) AS p(id)
+				LEFT JOIN reviews r ON r.place_id = p.id AND r.status = 'approved' AND r.deleted_at IS NULL
+				GROUP BY p.id
+				ON CONFLICT (place_id) DO UPDATE SET
+					count = EXCLUDED.count, sum = EXCLUDED.sum, average = EXCLUDED.average,
+					stars_1 = EXCLUDED.stars_1, stars_2 = EXCLUDED.stars_2, stars_3 = EXCLUDED.stars_3,
+					stars_4 = EXCLUDED.stars_4, stars_5 = EXCLUDED.stars_5, updated_at = NOW();
+			END IF;
+
+			CREATE UNIQUE INDEX idx_reviews_user_place ON reviews (user_id, place_id) WHERE deleted_at IS NULL;
+		END IF;
+	END $$`,
+	// Одна открытая жалоба пользователя на отзыв
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_review_reports_open ON review_reports (review_id, user_id) WHERE resolved_at IS NULL`,
+	// Возвраты пополнений раньше проводились как topup с отрицательной суммой
+	`UPDATE ledger_entries SET type = 'topup_refund' WHERE type = 'topup' AND reason = 'topup_refund'`,
+}
+
+func RunSQLMigrations(db *gorm.DB, logger *slog.Logger) error {
+	for _, stmt := range sqlMigrations {
+		if err := db.Exec(stmt).Error; err != nil {
+			logger.Error("sql migration failed", "statement", stmt, "error", err)
+			return err
+		}
+	}
+	logger.Info("sql migrations applied", "count", len(sqlMigrations))
+	return nil
+}
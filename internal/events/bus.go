@@ -0,0 +1,59 @@
+package events
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+)
+
+type Handler func(ctx context.Context, ev *models.OutboxEvent) error
+
+// subscription — отдельный получатель шины со своим учетом доставки
+type subscription struct {
+	name    string
+	types   []models.EventType
+	handler Handler
+}
+
+func (s *subscription) Name() string {
+	return "bus:" + s.name
+}
+
+func (s *subscription) Publish(ctx context.Context, ev *models.OutboxEvent) error {
+	if len(s.types) > 0 && !slices.Contains(s.types, ev.EventType) {
+		return nil
+	}
+	return s.handler(ctx, ev)
+}
+
+// Bus — внутрипроцессная шина: раздает события подписчикам этого экземпляра API
+type Bus struct {
+	mu   sync.RWMutex
+	subs []*subscription
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe подписывает handler на перечисленные типы событий, без типов — на все.
+// name должен быть постоянным: по нему relay помнит, кому событие уже доставлено.
+func (b *Bus) Subscribe(name string, handler Handler, types ...models.EventType) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, &subscription{name: name, types: types, handler: handler})
+}
+
+// Sinks возвращает подписчиков как отдельные Sink для relay
+func (b *Bus) Sinks() []Sink {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	sinks := make([]Sink, len(b.subs))
+	for i, sub := range b.subs {
+		sinks[i] = sub
+	}
+	return sinks
+}
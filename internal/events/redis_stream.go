@@ -0,0 +1,40 @@
+package events
+
+import (
+	"context"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/redis"
+)
+
+// RedisStreamSink пишет события в Redis Streams, по потоку на тип агрегата:
+// events:booking, events:user и т.д.
+type RedisStreamSink struct {
+	client *redis.Client
+	maxLen int64
+}
+
+func NewRedisStreamSink(client *redis.Client, maxLen int64) *RedisStreamSink {
+	return &RedisStreamSink{client: client, maxLen: maxLen}
+}
+
+func (s *RedisStreamSink) Name() string {
+	return "redis_stream"
+}
+
+func (s *RedisStreamSink) Publish(ctx context.Context, ev *models.OutboxEvent) error {
+	return s.client.XAdd(ctx, &goredis.XAddArgs{
+		Stream: "events:" + ev.AggregateType,
+		MaxLen: s.maxLen,
+		Approx: true,
+		Values: map[string]any{
+			"event_id":     ev.ID,
+			"event_type":   string(ev.EventType),
+			"aggregate_id": ev.AggregateID,
+			"payload":      ev.Payload,
+			"created_at":   ev.CreatedAt.UnixMilli(),
+		},
+	}).Err()
+}
@@ -0,0 +1,141 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+)
+
+const (
+	relayBatchSize = 100
+	// relayLease — на сколько событие пачки скрыто от других экземпляров;
+	// должно с запасом покрывать отправку всей пачки
+	relayLease = 5 * time.Minute
+	// relayMaxAttempts — после стольких неудач событие помечается мертвым
+	relayMaxAttempts = 10
+	relayMaxBackoff  = 30 * time.Minute
+)
+
+// Relay переносит события из outbox во все sinks
+type Relay struct {
+	repo   repository.OutboxRepository
+	sinks  []Sink
+	logger *slog.Logger
+}
+
+func NewRelay(repo repository.OutboxRepository, sinks []Sink, logger *slog.Logger) *Relay {
+	return &Relay{repo: repo, sinks: sinks, logger: logger}
+}
+
+// ProcessOnce забирает и отправляет одну пачку, возвращает ее размер.
+// Отправка идет вне транзакции: пачка заранее закреплена за этим relay.
+// Событие опубликовано, когда его приняли все sinks; при ошибке повтор
+// уходит только не принявшим, с растущей задержкой, а после relayMaxAttempts
+// событие становится мертвым и больше не мешает доставке.
+func (r *Relay) ProcessOnce(ctx context.Context) (int, error) {
+	batch, err := r.repo.ClaimBatch(relayBatchSize, relayLease)
+	if err != nil {
+		return 0, err
+	}
+
+	// после ошибки остальные события того же агрегата в этой пачке
+	// возвращаются в очередь, чтобы подписчики не увидели их раньше неудавшегося
+	blocked := make(map[string]bool)
+	var released []uint
+	var errs []error
+
+	for i := range batch {
+		ev := &batch[i]
+		key := fmt.Sprintf("%s:%d", ev.AggregateType, ev.AggregateID)
+		if blocked[key] || ctx.Err() != nil {
+			released = append(released, ev.ID)
+			continue
+		}
+
+		if err := r.deliver(ctx, ev); err != nil {
+			blocked[key] = true
+			errs = append(errs, r.fail(ev, err))
+			continue
+		}
+		errs = append(errs, r.repo.MarkPublished(ev.ID))
+	}
+
+	errs = append(errs, r.repo.Release(released))
+	return len(batch), errors.Join(errs...)
+}
+
+// deliver отправляет событие sinks, которые его еще не приняли, и дописывает
+// принявших в ev.Delivered
+func (r *Relay) deliver(ctx context.Context, ev *models.OutboxEvent) error {
+	var errs []error
+	for _, sink := range r.sinks {
+		if slices.Contains(ev.Delivered, sink.Name()) {
+			continue
+		}
+		if err := sink.Publish(ctx, ev); err != nil {
+			r.logger.Warn("outbox sink failed", "event_id", ev.ID, "event_type", ev.EventType, "sink", sink.Name(), "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+			continue
+		}
+		ev.Delivered = append(ev.Delivered, sink.Name())
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Relay) fail(ev *models.OutboxEvent, err error) error {
+	now := time.Now()
+	ev.Attempts++
+	ev.LastError = err.Error()
+
+	if ev.Attempts >= relayMaxAttempts {
+		ev.NextAttemptAt = nil
+		ev.DeadAt = &now
+		r.logger.Error("outbox event is dead", "event_id", ev.ID, "event_type", ev.EventType, "attempts", ev.Attempts, "error", err)
+	} else {
+		next := now.Add(relayBackoff(ev.Attempts))
+		ev.NextAttemptAt = &next
+	}
+
+	return r.repo.MarkFailed(ev)
+}
+
+// relayBackoff — задержка перед повтором: 2^attempts секунд, не больше relayMaxBackoff
+func relayBackoff(attempts int) time.Duration {
+	d := time.Second
+	for i := 0; i < attempts && d < relayMaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, relayMaxBackoff)
+}
+
+// Run опрашивает outbox до отмены контекста. Пока есть полные пачки,
+// следующая берется сразу, без ожидания тика.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ctx.Err() == nil {
+		n, err := r.ProcessOnce(ctx)
+		if err != nil {
+			r.logger.Error("outbox relay failed", "error", err)
+		} else if n > 0 {
+			r.logger.Debug("outbox relay processed events", "count", n)
+		}
+
+		if err == nil && n == relayBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
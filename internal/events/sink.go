@@ -0,0 +1,16 @@
+package events
+
+import (
+	"context"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+)
+
+// Sink — получатель событий из outbox. Доставка учитывается по Name
+// отдельно для каждого получателя: при повторе событие уходит только тем,
+// кто его еще не принял. Доставка at-least-once, поэтому обработка на
+// стороне получателя должна быть идемпотентной.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, ev *models.OutboxEvent) error
+}
@@ -0,0 +1,105 @@
+package floorplan
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+)
+
+// Размеры холста, если подложка этажа еще не загружена
+const (
+	defaultWidth  = 1000
+	defaultHeight = 600
+	pointRadius   = 12
+)
+
+var statusColors = map[models.PlaceAvailability]string{
+	models.PlaceFree:        "#4caf50",
+	models.PlaceBusy:        "#e53935",
+	models.PlaceMaintenance: "#fb8c00",
+	models.PlaceInactive:    "#9e9e9e",
+}
+
+var statusTitles = map[models.PlaceAvailability]string{
+	models.PlaceFree:        "свободно",
+	models.PlaceBusy:        "занято",
+	models.PlaceMaintenance: "на обслуживании",
+	models.PlaceInactive:    "недоступно",
+}
+
+var svgTemplate = template.Must(template.New("floor").Funcs(template.FuncMap{
+	"esc":    template.HTMLEscapeString,
+	"color":  func(s models.PlaceAvailability) string { return statusColors[s] },
+	"status": func(s models.PlaceAvailability) string { return statusTitles[s] },
+	"points": points,
+	"label":  label,
+	"num":    num,
+}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" font-family="Arial, sans-serif" font-size="12">
+<title>{{esc .Map.FloorName}}: {{.Map.StartTime.Format "02.01.2006 15:04"}} – {{.Map.EndTime.Format "02.01.2006 15:04"}}</title>
+{{if .Map.Background}}<image href="{{esc .Map.Background}}" x="0" y="0" width="{{.Width}}" height="{{.Height}}" preserveAspectRatio="none"/>
+{{else}}<rect x="0" y="0" width="{{.Width}}" height="{{.Height}}" fill="#fafafa" stroke="#ccc"/>
+{{end}}{{range .Map.Places}}{{if or .Polygon .X}}<g id="place-{{.ID}}" data-place-id="{{.ID}}" data-status="{{.Status}}">
+<title>{{esc .Name}} — {{status .Status}}</title>
+{{if .Polygon}}<polygon points="{{points .Polygon}}" fill="{{color .Status}}" fill-opacity="0.45" stroke="{{color .Status}}" stroke-width="2"/>
+{{else}}<circle cx="{{num .X}}" cy="{{num .Y}}" r="{{$.Radius}}" fill="{{color .Status}}" fill-opacity="0.8" stroke="#fff" stroke-width="2"/>
+{{end}}{{with label .}}<text x="{{num .X}}" y="{{num .Y}}" text-anchor="middle" dominant-baseline="central">{{esc .Text}}</text>
+{{end}}</g>
+{{end}}{{end}}</svg>
+`))
+
+type labelPos struct {
+	X, Y *float64
+	Text string
+}
+
+// RenderSVG рисует план этажа; места без координат на план не попадают
+func RenderSVG(w io.Writer, m *models.FloorMap) error {
+	width, height := m.Width, m.Height
+	if width <= 0 || height <= 0 {
+		width, height = defaultWidth, defaultHeight
+	}
+
+	return svgTemplate.Execute(w, struct {
+		Map           *models.FloorMap
+		Width, Height int
+		Radius        int
+	}{Map: m, Width: width, Height: height, Radius: pointRadius})
+}
+
+func points(polygon []models.MapPoint) string {
+	parts := make([]string, 0, len(polygon))
+	for _, p := range polygon {
+		parts = append(parts, num(&p[0])+","+num(&p[1]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// label ставит подпись в точку места, а для контура — в его центр
+func label(p models.FloorMapPlace) *labelPos {
+	if p.X != nil && p.Y != nil {
+		return &labelPos{X: p.X, Y: p.Y, Text: p.Name}
+	}
+	if len(p.Polygon) == 0 {
+		return nil
+	}
+
+	var x, y float64
+	for _, pt := range p.Polygon {
+		x += pt[0]
+		y += pt[1]
+	}
+	x /= float64(len(p.Polygon))
+	y /= float64(len(p.Polygon))
+	return &labelPos{X: &x, Y: &y, Text: p.Name}
+}
+
+func num(v *float64) string {
+	if v == nil {
+		return "0"
+	}
+	return fmt.Sprintf("%.1f", *v)
+}
@@ -0,0 +1,105 @@
+package floorplan
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+)
+
+func TestRenderSVGEscaping(t *testing.T) {
+	x, y := 100.0, 50.0
+	start := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		floorName string
+		place     string
+		bg        string
+		forbidden string // сырой фрагмент, которого не должно быть в SVG
+	}{
+		{name: "тег в названии места", floorName: "2 этаж", place: `<script>alert(1)</script>`, forbidden: "<script>"},
+		{name: "кавычки и амперсанд", floorName: "2 этаж", place: `Зал "A" & B`, forbidden: `"A" & B`},
+		{name: "тег в названии этажа", floorName: `</title><script>x</script>`, place: "Стол 1", forbidden: "<script>"},
+		{name: "кавычка в URL подложки", floorName: "2 этаж", place: "Стол 1", bg: `https://cdn/x.png" onload="alert(1)`, forbidden: `" onload="`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &models.FloorMap{
+				FloorName:  tt.floorName,
+				Background: tt.bg,
+				StartTime:  start,
+				EndTime:    start.Add(time.Hour),
+				Places: []models.FloorMapPlace{
+					{ID: 1, Name: tt.place, X: &x, Y: &y, Status: models.PlaceFree},
+					{ID: 2, Name: tt.place, Polygon: []models.MapPoint{{0, 0}, {10, 0}, {10, 10}}, Status: models.PlaceBusy},
+				},
+			}
+
+			var buf bytes.Buffer
+			if err := RenderSVG(&buf, m); err != nil {
+				t.Fatalf("RenderSVG: %v", err)
+			}
+			out := buf.String()
+			if strings.Contains(out, tt.forbidden) {
+				t.Fatalf("в SVG попал неэкранированный фрагмент %q:\n%s", tt.forbidden, out)
+			}
+
+			// Экранированный документ остается корректным XML, а текст
+			// подписей после разбора совпадает с исходным названием
+			var texts []string
+			dec := xml.NewDecoder(strings.NewReader(out))
+			inText := false
+			for {
+				tok, err := dec.Token()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("SVG не разбирается как XML: %v\n%s", err, out)
+				}
+				switch tok := tok.(type) {
+				case xml.StartElement:
+					inText = tok.Name.Local == "text"
+				case xml.EndElement:
+					inText = false
+				case xml.CharData:
+					if inText {
+						texts = append(texts, string(tok))
+					}
+				}
+			}
+			if len(texts) != 2 || texts[0] != tt.place || texts[1] != tt.place {
+				t.Fatalf("подписи %q, ожидалось дважды %q", texts, tt.place)
+			}
+		})
+	}
+}
+
+func TestRenderSVGSkipsPlacesWithoutPosition(t *testing.T) {
+	x, y := 10.0, 20.0
+	m := &models.FloorMap{
+		Places: []models.FloorMapPlace{
+			{ID: 1, Name: "на плане", X: &x, Y: &y, Status: models.PlaceFree},
+			{ID: 2, Name: "без координат", Status: models.PlaceFree},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderSVG(&buf, m); err != nil {
+		t.Fatalf("RenderSVG: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `id="place-1"`) || strings.Contains(out, `id="place-2"`) {
+		t.Fatalf("неверный набор мест на плане:\n%s", out)
+	}
+	if !strings.Contains(out, `width="1000" height="600"`) {
+		t.Fatalf("без подложки должен использоваться холст по умолчанию:\n%s", out)
+	}
+}
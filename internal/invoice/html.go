@@ -0,0 +1,58 @@
+package invoice
+
+import (
+	"html/template"
+	"io"
+)
+
+var htmlTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"rub": FormatRub,
+	"inc": func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: Arial, sans-serif; font-size: 14px; margin: 40px; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #999; padding: 6px 8px; }
+td.num, th.num { text-align: right; white-space: nowrap; }
+.parties td { border: none; vertical-align: top; width: 50%; }
+.totals td { border: none; }
+.status { color: #666; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="status">Статус: {{.Status}}{{with .Invoice.IssuedAt}} · выставлен {{.Format "02.01.2006"}}{{end}}{{with .Invoice.DueAt}} · оплатить до {{.Format "02.01.2006"}}{{end}}</p>
+<p>Период: {{.Period}}</p>
+
+<table class="parties">
+<tr>
+<td><strong>Продавец</strong><br>{{.Seller.Name}}{{with .Seller.TaxID}}<br>ИНН {{.}}{{end}}{{with .Seller.Address}}<br>{{.}}{{end}}{{with .Seller.Email}}<br>{{.}}{{end}}</td>
+<td><strong>Покупатель</strong><br>{{.Buyer.Name}}{{with .Buyer.TaxID}}<br>ИНН {{.}}{{end}}{{with .Buyer.Address}}<br>{{.}}{{end}}{{with .Buyer.Email}}<br>{{.}}{{end}}</td>
+</tr>
+</table>
+
+<table>
+<tr><th>№</th><th>Дата</th><th>Наименование</th><th class="num">Кол-во</th><th class="num">Цена</th><th class="num">Сумма</th></tr>
+{{range $i, $l := .Invoice.Lines}}<tr>
+<td>{{inc $i}}</td><td>{{$l.Date.Format "02.01.2006"}}</td><td>{{$l.Description}}</td>
+<td class="num">{{$l.Quantity}}</td><td class="num">{{rub $l.UnitPrice}}</td><td class="num">{{rub $l.Amount}}</td>
+</tr>
+{{else}}<tr><td colspan="6">Нет операций за период</td></tr>
+{{end}}</table>
+
+<table class="totals">
+<tr><td class="num">Без НДС:</td><td class="num">{{rub .Invoice.Subtotal}}</td></tr>
+<tr><td class="num">НДС {{.Invoice.VATRate}}%:</td><td class="num">{{rub .Invoice.VATAmount}}</td></tr>
+<tr><td class="num"><strong>Итого:</strong></td><td class="num"><strong>{{rub .Invoice.Total}}</strong></td></tr>
+</table>
+</body>
+</html>
+`))
+
+func RenderHTML(w io.Writer, doc Document) error {
+	return htmlTemplate.Execute(w, doc)
+}
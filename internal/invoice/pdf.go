@@ -0,0 +1,214 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PDF собирается вручную: стандартные шрифты PDF не содержат кириллицы,
+// а встраивать шрифт ради счетов избыточно, поэтому текст транслитерируется.
+// Для документа с кириллицей есть HTML-версия.
+
+const (
+	pageWidth    = 595 // A4 в пунктах
+	pageHeight   = 842
+	marginLeft   = 40
+	marginTop    = 800
+	marginBottom = 60
+	lineStep     = 14
+)
+
+type pdfText struct {
+	x, y float64
+	size float64
+	bold bool
+	text string
+}
+
+type pdfLayout struct {
+	pages [][]pdfText
+	y     float64
+}
+
+func (l *pdfLayout) newPage() {
+	l.pages = append(l.pages, nil)
+	l.y = marginTop
+}
+
+func (l *pdfLayout) add(x float64, size float64, bold bool, text string) {
+	last := len(l.pages) - 1
+	l.pages[last] = append(l.pages[last], pdfText{x: x, y: l.y, size: size, bold: bold, text: text})
+}
+
+func (l *pdfLayout) nextLine(step float64) {
+	l.y -= step
+	if l.y < marginBottom {
+		l.newPage()
+	}
+}
+
+// RenderPDF возвращает счет в виде PDF 1.4
+func RenderPDF(doc Document) ([]byte, error) {
+	inv := doc.Invoice
+	l := &pdfLayout{}
+	l.newPage()
+
+	l.add(marginLeft, 16, true, doc.Title())
+	l.nextLine(22)
+	status := "Статус: " + doc.Status() + "   Период: " + doc.Period()
+	if inv.DueAt != nil {
+		status += "   Оплатить до: " + inv.DueAt.Format("02.01.2006")
+	}
+	l.add(marginLeft, 10, false, status)
+	l.nextLine(lineStep * 2)
+
+	for _, p := range []struct {
+		title string
+		party Party
+	}{{"Продавец", doc.Seller}, {"Покупатель", doc.Buyer}} {
+		l.add(marginLeft, 10, true, p.title+": "+p.party.Name)
+		l.nextLine(lineStep)
+		if p.party.TaxID != "" {
+			l.add(marginLeft, 10, false, "ИНН "+p.party.TaxID)
+			l.nextLine(lineStep)
+		}
+		if p.party.Address != "" {
+			l.add(marginLeft, 10, false, p.party.Address)
+			l.nextLine(lineStep)
+		}
+		if p.party.Email != "" {
+			l.add(marginLeft, 10, false, p.party.Email)
+			l.nextLine(lineStep)
+		}
+		l.nextLine(lineStep / 2)
+	}
+
+	l.nextLine(lineStep)
+	header := func() {
+		l.add(marginLeft, 9, true, "№")
+		l.add(65, 9, true, "Дата")
+		l.add(125, 9, true, "Наименование")
+		l.add(380, 9, true, "Кол-во")
+		l.add(420, 9, true, "Цена")
+		l.add(495, 9, true, "Сумма")
+		l.nextLine(lineStep)
+	}
+	header()
+
+	for i, line := range inv.Lines {
+		if l.y == marginTop {
+			header()
+		}
+		l.add(marginLeft, 9, false, strconv.Itoa(i+1))
+		l.add(65, 9, false, line.Date.Format("02.01.2006"))
+		l.add(125, 9, false, truncate(line.Description, 48))
+		l.add(380, 9, false, strconv.Itoa(line.Quantity))
+		l.add(420, 9, false, FormatRub(line.UnitPrice))
+		l.add(495, 9, false, FormatRub(line.Amount))
+		l.nextLine(lineStep)
+	}
+
+	l.nextLine(lineStep)
+	l.add(380, 10, false, "Без НДС:")
+	l.add(470, 10, false, FormatRub(inv.Subtotal))
+	l.nextLine(lineStep)
+	l.add(380, 10, false, fmt.Sprintf("НДС %d%%:", inv.VATRate))
+	l.add(470, 10, false, FormatRub(inv.VATAmount))
+	l.nextLine(lineStep)
+	l.add(380, 11, true, "Итого:")
+	l.add(470, 11, true, FormatRub(inv.Total))
+
+	return writePDF(l.pages), nil
+}
+
+func writePDF(pages [][]pdfText) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	// 1 — каталог, 2 — дерево страниц, 3 и 4 — шрифты, дальше пары «страница + содержимое»
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, texts := range pages {
+		var content bytes.Buffer
+		for _, t := range texts {
+			font := "F1"
+			if t.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "BT /%s %.0f Tf %.0f %.0f Td (%s) Tj ET\n", font, t.size, t.x, t.y, pdfEscape(transliterate(t.text)))
+		}
+
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+i*2))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
+
+func pdfEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s)
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+var translitTable = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+	'№': "No.", '₽': "RUB", '—': "-", '–': "-", '«': `"`, '»': `"`, '…': "...",
+}
+
+// transliterate переводит текст в ASCII для стандартных шрифтов PDF
+func transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < 128 {
+			b.WriteRune(r)
+			continue
+		}
+		lower := []rune(strings.ToLower(string(r)))[0]
+		t, ok := translitTable[lower]
+		if !ok {
+			b.WriteByte('?')
+			continue
+		}
+		if lower != r && t != "" {
+			t = strings.ToUpper(t[:1]) + t[1:]
+		}
+		b.WriteString(t)
+	}
+	return b.String()
+}
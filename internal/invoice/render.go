@@ -0,0 +1,85 @@
+// Package invoice отрисовывает счета в HTML и PDF.
+package invoice
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+)
+
+// Party — реквизиты продавца или покупателя
+type Party struct {
+	Name    string
+	TaxID   string // ИНН
+	Address string
+	Email   string
+}
+
+// SellerFromEnv читает реквизиты коворкинга: COMPANY_NAME, COMPANY_INN, COMPANY_ADDRESS, COMPANY_EMAIL
+func SellerFromEnv() Party {
+	name := os.Getenv("COMPANY_NAME")
+	if name == "" {
+		name = "Coworking"
+	}
+	return Party{
+		Name:    name,
+		TaxID:   os.Getenv("COMPANY_INN"),
+		Address: os.Getenv("COMPANY_ADDRESS"),
+		Email:   os.Getenv("COMPANY_EMAIL"),
+	}
+}
+
+// Document — все, что нужно для отрисовки счета
+type Document struct {
+	Invoice *models.Invoice
+	Seller  Party
+	Buyer   Party
+}
+
+func (d Document) Title() string {
+	number := "черновик"
+	if d.Invoice.Number != nil {
+		number = *d.Invoice.Number
+	}
+	return fmt.Sprintf("Счет № %s", number)
+}
+
+func (d Document) Period() string {
+	return fmt.Sprintf("%s — %s",
+		d.Invoice.PeriodStart.Format("02.01.2006"),
+		d.Invoice.PeriodEnd.AddDate(0, 0, -1).Format("02.01.2006"))
+}
+
+var statusTitles = map[models.InvoiceStatus]string{
+	models.InvoiceDraft:   "черновик",
+	models.InvoiceIssued:  "выставлен",
+	models.InvoicePaid:    "оплачен",
+	models.InvoiceVoid:    "аннулирован",
+	models.InvoiceOverdue: "просрочен",
+}
+
+func (d Document) Status() string {
+	return statusTitles[d.Invoice.Status]
+}
+
+// FormatRub форматирует копейки как «1 234,50 ₽»
+func FormatRub(kopecks int) string {
+	sign := ""
+	if kopecks < 0 {
+		sign = "-"
+		kopecks = -kopecks
+	}
+
+	rub := strconv.Itoa(kopecks / 100)
+	grouped := make([]byte, 0, len(rub)+len(rub)/3)
+	for i := range len(rub) {
+		if i > 0 && (len(rub)-i)%3 == 0 {
+			grouped = append(grouped, ' ')
+		}
+		grouped = append(grouped, rub[i])
+	}
+
+	return fmt.Sprintf("%s%s,%02d ₽", sign, grouped, kopecks%100)
+}
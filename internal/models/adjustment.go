@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+type AdjustmentReason string
+
+const (
+	AdjustmentCompensation  AdjustmentReason = "compensation"   // компенсация клиенту за сбой или неудобство
+	AdjustmentBillingError  AdjustmentReason = "billing_error"  // исправление ошибочного списания
+	AdjustmentManualPayment AdjustmentReason = "manual_payment" // оплата наличными или переводом мимо платежного провайдера
+	AdjustmentChargeback    AdjustmentReason = "chargeback"     // возврат платежа по инициативе банка
+	AdjustmentOther         AdjustmentReason = "other"
+)
+
+type AdjustmentStatus string
+
+const (
+	AdjustmentPending  AdjustmentStatus = "pending" // ждет подтверждения вторым администратором
+	AdjustmentApplied  AdjustmentStatus = "applied"
+	AdjustmentRejected AdjustmentStatus = "rejected"
+)
+
+// BalanceAdjustment — ручная корректировка баланса пользователя или организации.
+// Крупные корректировки применяются только после подтверждения другим администратором.
+type BalanceAdjustment struct {
+	Base
+
+	UserID         *uint `json:"user_id,omitempty" gorm:"index"`
+	OrganizationID *uint `json:"organization_id,omitempty" gorm:"index"`
+
+	Amount     int              `json:"amount" gorm:"not null"` // в копейках, отрицательная — списание
+	ReasonCode AdjustmentReason `json:"reason_code" gorm:"not null"`
+	Comment    string           `json:"comment" gorm:"not null"`
+	Status     AdjustmentStatus `json:"status" gorm:"not null;index"`
+
+	RequestedBy   uint       `json:"requested_by" gorm:"not null;index"`
+	ReviewedBy    *uint      `json:"reviewed_by,omitempty" gorm:"index"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	ReviewComment string     `json:"review_comment,omitempty"`
+
+	LedgerEntryID *uint      `json:"ledger_entry_id,omitempty"`
+	AppliedAt     *time.Time `json:"applied_at,omitempty"`
+}
+
+type BalanceAdjustmentDTO struct {
+	Amount     int              `json:"amount" binding:"required"`
+	ReasonCode AdjustmentReason `json:"reason_code" binding:"required,oneof=compensation billing_error manual_payment chargeback other"`
+	Comment    string           `json:"comment" binding:"required,min=3"`
+}
+
+type ReviewAdjustmentDTO struct {
+	Comment string `json:"comment"`
+}
+
+type FilterAdjustments struct {
+	AdminID        *uint             `form:"admin_id"` // кто запросил
+	UserID         *uint             `form:"user_id"`
+	OrganizationID *uint             `form:"organization_id"`
+	Status         *AdjustmentStatus `form:"status" binding:"omitempty,oneof=pending applied rejected"`
+	From           *time.Time        `form:"from" time_format:"2006-01-02"`
+	To             *time.Time        `form:"to" time_format:"2006-01-02"` // не включая
+	Limit          int               `form:"limit"`
+	Offset         int               `form:"offset"`
+}
+
+// AdjustmentReportRow — итоги корректировок одного администратора за период
+type AdjustmentReportRow struct {
+	AdminID    uint   `json:"admin_id"`
+	AdminLogin string `json:"admin_login"`
+	Requested  int64  `json:"requested"`
+	Applied    int64  `json:"applied"`
+	Pending    int64  `json:"pending"`
+	Rejected   int64  `json:"rejected"`
+	Credited   int    `json:"credited"` // сумма примененных начислений
+	Debited    int    `json:"debited"`  // сумма примененных списаний, по модулю
+	Approved   int64  `json:"approved"` // подтверждено чужих корректировок
+}
@@ -5,6 +5,10 @@ type Admin struct {
 
 	Login        string `json:"login" gorm:"not null;uniqueIndex"`
 	PasswordHash string `json:"-" gorm:"not null"`
+	// Unrestricted — доступ ко всем площадкам. Остальные администраторы управляют
+	// только закрепленными за ними площадками, а без них — ничем. Колонку
+	// создает SQL-миграция, чтобы перенести доступ уже заведенных администраторов.
+	Unrestricted bool `json:"unrestricted" gorm:"-:migration;not null;default:false"`
 
 	Reviews []Review `json:"-"`
 }
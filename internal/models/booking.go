@@ -23,8 +23,22 @@ type Booking struct {
 
 	TotalPrice int `json:"total_price" gorm:"not null"`
 
+	PriceBreakdown JSONText `json:"price_breakdown,omitempty" gorm:"type:jsonb"`
+	PromoCodeID    *uint    `json:"promo_code_id,omitempty" gorm:"index"`
+	Discount       int      `json:"discount" gorm:"not null;default:0"` // скидка по промокоду, в копейках
+
+	// Сколько часов покрыто абонементом и сколько реально списано с баланса
+	HourBucketID  *uint `json:"hour_bucket_id,omitempty"`
+	IncludedHours int   `json:"included_hours" gorm:"not null;default:0"`
+	ChargedAmount int   `json:"charged_amount" gorm:"not null;default:0"`
+	// Кошелек организации, с которого оплачена бронь; nil — личный баланс
+	OrganizationID *uint `json:"organization_id,omitempty" gorm:"index"`
+
 	Status BookingStatus `json:"status" gorm:"not null;default:'non_active';index:idx_booking_status_place_time,priority:1"`
 
+	CheckedInAt    *time.Time `json:"checked_in_at,omitempty"`
+	ReminderSentAt *time.Time `json:"-"`
+
 	User  *User  `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	Place *Place `json:"place,omitempty" gorm:"foreignKey:PlaceID"`
 }
@@ -34,6 +48,7 @@ type BookingReqDTO struct {
 	PlaceID   uint   `json:"place_id"`
 	StartTime string `json:"start_time"`
 	EndTime   string `json:"end_time"`
+	PromoCode string `json:"promo_code,omitempty"`
 }
 
 type BookingReqUpdateDTO struct {
@@ -59,15 +74,18 @@ type BookingResDTO struct {
 }
 
 type FilterBooking struct {
-	PlaceID   *uint      `form:"place_id"`
-	Status    *string    `form:"status"`
-	Preload   bool       `form:"preload" default:"false"`
-	PriceMin  *int       `form:"price_min"`
-	PriceMax  *int       `form:"price_max"`
-	StartTime *time.Time `form:"start_time"`
-	EndTime   *time.Time `form:"end_time"`
-	Limit     int        `form:"limit"`
-	Offset    int        `form:"offset"`
-	SortBy    string     `form:"sort_by"`
-	Order     string     `form:"order"`
+	PlaceID    *uint      `form:"place_id"`
+	LocationID *uint      `form:"location_id"`
+	FloorID    *uint      `form:"floor_id"`
+	ZoneID     *uint      `form:"zone_id"`
+	Status     *string    `form:"status"`
+	Preload    bool       `form:"preload" default:"false"`
+	PriceMin   *int       `form:"price_min"`
+	PriceMax   *int       `form:"price_max"`
+	StartTime  *time.Time `form:"start_time"`
+	EndTime    *time.Time `form:"end_time"`
+	Limit      int        `form:"limit"`
+	Offset     int        `form:"offset"`
+	SortBy     string     `form:"sort_by"`
+	Order      string     `form:"order"`
 }
@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+type EventType string
+
+const (
+	EventBookingCreated       EventType = "booking.created"
+	EventBookingStatusChanged EventType = "booking.status_changed"
+	EventBookingCheckedIn     EventType = "booking.checked_in"
+	EventBookingDeleted       EventType = "booking.deleted"
+	EventBookingRelocated     EventType = "booking.relocated"
+	EventBookingUpdated       EventType = "booking.updated"
+	EventBalanceChanged       EventType = "balance.changed"
+	EventReviewCreated        EventType = "review.created"
+	EventUserUpdated          EventType = "user.updated"
+	EventUserDeleted          EventType = "user.deleted"
+)
+
+// EventTypes — типы событий, на которые можно подписаться вебхуком
+var EventTypes = []EventType{
+	EventBookingCreated,
+	EventBookingStatusChanged,
+	EventBookingRelocated,
+	EventBookingUpdated,
+	EventBalanceChanged,
+	EventReviewCreated,
+}
+
+type BookingEvent struct {
+	BookingID     uint          `json:"booking_id"`
+	UserID        uint          `json:"user_id"`
+	PlaceID       uint          `json:"place_id"`
+	StartTime     time.Time     `json:"start_time"`
+	EndTime       time.Time     `json:"end_time"`
+	TotalPrice    int           `json:"total_price"`
+	ChargedAmount int           `json:"charged_amount"` // списано с баланса за вычетом часов абонемента, в копейках
+	Status        BookingStatus `json:"status"`
+	OldStatus     BookingStatus `json:"old_status,omitempty"`
+	Refunded      int           `json:"refunded,omitempty"` // возврат на баланс при отмене, в копейках
+}
+
+// BookingUpdatedEvent — бронь изменена администратором; From* — место и время до изменения
+type BookingUpdatedEvent struct {
+	BookingEvent
+	FromPlaceID   uint      `json:"from_place_id"`
+	FromStartTime time.Time `json:"from_start_time"`
+	FromEndTime   time.Time `json:"from_end_time"`
+}
+
+type BalanceEvent struct {
+	UserID    uint   `json:"user_id"`
+	Amount    int    `json:"amount"` // в копейках, отрицательное значение — списание
+	Reason    string `json:"reason"`
+	BookingID *uint  `json:"booking_id,omitempty"`
+	// OrganizationID задан, если изменился кошелек организации, а не личный баланс
+	OrganizationID *uint `json:"organization_id,omitempty"`
+}
+
+type UserEvent struct {
+	UserID uint `json:"user_id"`
+}
+
+type ReviewEvent struct {
+	ReviewID uint `json:"review_id"`
+	UserID   uint `json:"user_id"`
+	PlaceID  uint `json:"place_id"`
+	Rating   int  `json:"rating"`
+}
+
+func NewBookingEvent(b *Booking, oldStatus BookingStatus) BookingEvent {
+	return BookingEvent{
+		BookingID:     b.ID,
+		UserID:        b.UserID,
+		PlaceID:       b.PlaceID,
+		StartTime:     b.StartTime,
+		EndTime:       b.EndTime,
+		TotalPrice:    b.TotalPrice,
+		ChargedAmount: b.ChargedAmount,
+		Status:        b.Status,
+		OldStatus:     oldStatus,
+	}
+}
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// FloorPlan — подложка плана этажа. Width и Height задают систему координат,
+// в которой размечены места этажа.
+type FloorPlan struct {
+	Base
+
+	FloorID     uint   `json:"floor_id" gorm:"not null;uniqueIndex"`
+	Width       int    `json:"width" gorm:"not null"`
+	Height      int    `json:"height" gorm:"not null"`
+	ContentType string `json:"content_type" gorm:"not null"`
+	Image       []byte `json:"-" gorm:"not null"`
+}
+
+// MapPoint — точка в координатах плана: [x, y]
+type MapPoint [2]float64
+
+type PlacePositionDTO struct {
+	X       *float64   `json:"x"`
+	Y       *float64   `json:"y"`
+	Polygon []MapPoint `json:"polygon" binding:"omitempty,min=3"`
+}
+
+type PlaceAvailability string
+
+const (
+	PlaceFree        PlaceAvailability = "free"
+	PlaceBusy        PlaceAvailability = "busy"
+	PlaceMaintenance PlaceAvailability = "maintenance"
+	PlaceInactive    PlaceAvailability = "inactive"
+)
+
+type FilterFloorMap struct {
+	StartTime *time.Time `form:"start_time"`
+	EndTime   *time.Time `form:"end_time"`
+	Format    string     `form:"format" binding:"omitempty,oneof=json svg"`
+}
+
+// FloorMap — план этажа с занятостью мест за интервал [StartTime, EndTime)
+type FloorMap struct {
+	FloorID    uint            `json:"floor_id"`
+	FloorName  string          `json:"floor_name"`
+	Level      int             `json:"level"`
+	LocationID uint            `json:"location_id"`
+	Width      int             `json:"width"`
+	Height     int             `json:"height"`
+	Background string          `json:"background,omitempty"` // URL подложки
+	StartTime  time.Time       `json:"start_time"`
+	EndTime    time.Time       `json:"end_time"`
+	Places     []FloorMapPlace `json:"places"`
+}
+
+type FloorMapPlace struct {
+	ID           uint              `json:"id"`
+	Name         string            `json:"name"`
+	Type         PlaceType         `json:"type"`
+	PricePerHour int               `json:"price_per_hour"`
+	X            *float64          `json:"x,omitempty"`
+	Y            *float64          `json:"y,omitempty"`
+	Polygon      []MapPoint        `json:"polygon,omitempty"`
+	Status       PlaceAvailability `json:"status"`
+}
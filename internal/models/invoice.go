@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+type InvoiceStatus string
+
+const (
+	InvoiceDraft  InvoiceStatus = "draft"
+	InvoiceIssued InvoiceStatus = "issued"
+	InvoicePaid   InvoiceStatus = "paid"
+	InvoiceVoid   InvoiceStatus = "void"
+	// InvoiceOverdue — счет постоплаты не оплачен к сроку, новые брони клиента заблокированы
+	InvoiceOverdue InvoiceStatus = "overdue"
+)
+
+type InvoiceLineKind string
+
+const (
+	InvoiceLineBooking      InvoiceLineKind = "booking"
+	InvoiceLineSubscription InvoiceLineKind = "subscription"
+	InvoiceLineFee          InvoiceLineKind = "fee"
+	InvoiceLineRefund       InvoiceLineKind = "refund"
+)
+
+// Invoice — счет за расчетный период. Суммы в копейках, цены включают НДС.
+// Номер присваивается при выставлении, у черновика его нет.
+// Счет выставляется либо пользователю, либо организации.
+type Invoice struct {
+	Base
+
+	Number         *string       `json:"number,omitempty" gorm:"uniqueIndex"`
+	UserID         *uint         `json:"user_id,omitempty" gorm:"index;uniqueIndex:idx_invoice_user_period,where:status <> 'void'"`
+	OrganizationID *uint         `json:"organization_id,omitempty" gorm:"index;uniqueIndex:idx_invoice_org_period,where:status <> 'void'"`
+	PeriodStart    time.Time     `json:"period_start" gorm:"not null;uniqueIndex:idx_invoice_user_period,where:status <> 'void';uniqueIndex:idx_invoice_org_period,where:status <> 'void'"`
+	PeriodEnd      time.Time     `json:"period_end" gorm:"not null"`
+	Status         InvoiceStatus `json:"status" gorm:"not null;index"`
+
+	// Счет постоплаты: его оплата гасит долг на балансе, просрочка блокирует брони
+	Postpaid bool       `json:"postpaid" gorm:"not null;default:false"`
+	DueAt    *time.Time `json:"due_at,omitempty"`
+
+	Subtotal  int `json:"subtotal"` // без НДС
+	VATRate   int `json:"vat_rate"` // в процентах
+	VATAmount int `json:"vat_amount"`
+	Total     int `json:"total"`
+
+	IssuedAt   *time.Time `json:"issued_at,omitempty"`
+	PaidAt     *time.Time `json:"paid_at,omitempty"`
+	VoidedAt   *time.Time `json:"voided_at,omitempty"`
+	VoidReason string     `json:"void_reason,omitempty"`
+
+	User         *User         `json:"-" gorm:"foreignKey:UserID"`
+	Organization *Organization `json:"-" gorm:"foreignKey:OrganizationID"`
+	Lines        []InvoiceLine `json:"lines,omitempty" gorm:"foreignKey:InvoiceID"`
+}
+
+type InvoiceLine struct {
+	Base
+
+	InvoiceID     uint            `json:"invoice_id" gorm:"not null;index"`
+	Kind          InvoiceLineKind `json:"kind" gorm:"not null"`
+	Description   string          `json:"description" gorm:"not null"`
+	Quantity      int             `json:"quantity"`   // часы для броней, 1 для остальных строк
+	UnitPrice     int             `json:"unit_price"` // с НДС
+	Amount        int             `json:"amount"`     // с НДС, у возвратов отрицательная
+	BookingID     *uint           `json:"booking_id,omitempty"`
+	LedgerEntryID *uint           `json:"ledger_entry_id,omitempty"`
+	Date          time.Time       `json:"date"`
+}
+
+type FilterInvoice struct {
+	UserID         *uint          `form:"user_id"`
+	OrganizationID *uint          `form:"organization_id"`
+	Status         *InvoiceStatus `form:"status" binding:"omitempty,oneof=draft issued paid void overdue"`
+	Year           int            `form:"year"`
+	Month          int            `form:"month" binding:"omitempty,min=1,max=12"`
+	Limit          int            `form:"limit"`
+	Offset         int            `form:"offset"`
+	// ExcludeDrafts выставляется сервисом для пользовательского API
+	ExcludeDrafts bool `form:"-"`
+}
+
+type GenerateInvoicesDTO struct {
+	Year           int   `json:"year" binding:"required,min=2000"`
+	Month          int   `json:"month" binding:"required,min=1,max=12"`
+	UserID         *uint `json:"user_id"`
+	OrganizationID *uint `json:"organization_id"`
+}
+
+type VoidInvoiceDTO struct {
+	Reason string `json:"reason" binding:"required,min=3"`
+}
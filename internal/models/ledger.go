@@ -0,0 +1,92 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+type LedgerEntryType string
+
+const (
+	LedgerTopup         LedgerEntryType = "topup"
+	LedgerBookingCharge LedgerEntryType = "booking_charge"
+	LedgerRefund        LedgerEntryType = "refund"
+	LedgerAdjustment    LedgerEntryType = "adjustment"
+	LedgerFee           LedgerEntryType = "fee"
+	// LedgerSettlement — оплата счета постоплаты, гасит долг на балансе
+	LedgerSettlement LedgerEntryType = "settlement"
+	// LedgerTopupRefund — возврат пополнения через провайдера, списывает его сумму с баланса
+	LedgerTopupRefund LedgerEntryType = "topup_refund"
+)
+
+// Системные счета — вторая сторона проводки для счета пользователя
+const (
+	AccountRevenue     = "revenue"     // выручка от броней и абонементов
+	AccountCash        = "cash"        // деньги, пришедшие извне (пополнения, оплата счетов)
+	AccountAdjustments = "adjustments" // ручные корректировки администраторов
+)
+
+func UserAccount(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+func OrganizationAccount(orgID uint) string {
+	return fmt.Sprintf("org:%d", orgID)
+}
+
+// LedgerEntry — неизменяемая строка двойной записи. Каждая операция пишет
+// пару строк с общим TransactionID, сумма Amount по операции равна нулю.
+// User.Balance — проекция суммы строк по счету пользователя.
+type LedgerEntry struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+
+	TransactionID string `json:"transaction_id" gorm:"not null;index"`
+	Account       string `json:"account" gorm:"not null;index"`
+	UserID        *uint  `json:"user_id,omitempty" gorm:"index"`
+	// OrganizationID — проводка по кошельку организации, UserID тогда указывает, кто потратил
+	OrganizationID *uint           `json:"organization_id,omitempty" gorm:"index"`
+	Type           LedgerEntryType `json:"type" gorm:"not null"`
+	Amount         int             `json:"amount" gorm:"not null"`  // в копейках, со знаком
+	BalanceAfter   *int            `json:"balance_after,omitempty"` // только для счета пользователя
+	Reason         string          `json:"reason,omitempty"`
+	BookingID      *uint           `json:"booking_id,omitempty" gorm:"index"`
+	AdminID        *uint           `json:"admin_id,omitempty"`
+	PaymentID      *uint           `json:"payment_id,omitempty" gorm:"index"`
+}
+
+// LedgerPosting описывает изменение баланса пользователя или, если задан
+// OrganizationID, кошелька его организации. Сумма со знаком: отрицательная — списание.
+type LedgerPosting struct {
+	UserID         uint
+	OrganizationID *uint
+	Type           LedgerEntryType
+	Amount         int
+	Reason         string
+	BookingID      *uint
+	AdminID        *uint
+	PaymentID      *uint
+	// AllowNegative разрешает уходить в минус, иначе списание больше баланса отклоняется
+	AllowNegative bool
+}
+
+type TransactionsPage struct {
+	Items  []LedgerEntry `json:"items"`
+	Total  int64         `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+type FilterTransactions struct {
+	Limit  int `form:"limit"`
+	Offset int `form:"offset"`
+}
+
+// BalanceDrift — расхождение сохраненного баланса с суммой по журналу
+type BalanceDrift struct {
+	Account       string `json:"account"`
+	UserID        uint   `json:"user_id,omitempty"`
+	Balance       int    `json:"balance"`
+	LedgerBalance int    `json:"ledger_balance"`
+	Entries       int64  `json:"entries"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// Location — отдельная площадка коворкинга со своим адресом и часовым поясом
+type Location struct {
+	Base
+
+	Name      string  `json:"name" gorm:"not null"`
+	Address   string  `json:"address" gorm:"not null"`
+	Timezone  string  `json:"timezone" gorm:"not null;default:'Europe/Moscow'"` // IANA, например Europe/Moscow
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+
+	Floors []Floor `json:"floors,omitempty"`
+}
+
+type Floor struct {
+	Base
+
+	LocationID uint   `json:"location_id" gorm:"not null;index"`
+	Name       string `json:"name" gorm:"not null"`
+	Level      int    `json:"level" gorm:"not null;default:0"`
+
+	Zones []Zone `json:"zones,omitempty"`
+}
+
+type Zone struct {
+	Base
+
+	FloorID     uint   `json:"floor_id" gorm:"not null;index"`
+	Name        string `json:"name" gorm:"not null"`
+	Description string `json:"description"`
+}
+
+// AdminLocation закрепляет площадку за администратором. Доступ ко всем
+// площадкам дает только флаг Admin.Unrestricted.
+type AdminLocation struct {
+	AdminID    uint      `json:"admin_id" gorm:"primaryKey"`
+	LocationID uint      `json:"location_id" gorm:"primaryKey;index"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type LocationDTO struct {
+	Name      string  `json:"name" binding:"required,min=2"`
+	Address   string  `json:"address" binding:"required"`
+	Timezone  string  `json:"timezone" binding:"required"`
+	Latitude  float64 `json:"latitude" binding:"min=-90,max=90"`
+	Longitude float64 `json:"longitude" binding:"min=-180,max=180"`
+}
+
+type LocationUpdateDTO struct {
+	Name      *string  `json:"name" binding:"omitempty,min=2"`
+	Address   *string  `json:"address"`
+	Timezone  *string  `json:"timezone"`
+	Latitude  *float64 `json:"latitude" binding:"omitempty,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" binding:"omitempty,min=-180,max=180"`
+}
+
+type FloorDTO struct {
+	Name  string `json:"name" binding:"required"`
+	Level int    `json:"level"`
+}
+
+type FloorUpdateDTO struct {
+	Name  *string `json:"name" binding:"omitempty,min=1"`
+	Level *int    `json:"level"`
+}
+
+type ZoneDTO struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type ZoneUpdateDTO struct {
+	Name        *string `json:"name" binding:"omitempty,min=1"`
+	Description *string `json:"description"`
+}
+
+type AdminLocationsDTO struct {
+	LocationIDs []uint `json:"location_ids"`
+	// Unrestricted дает доступ ко всем площадкам; список тогда не нужен
+	Unrestricted bool `json:"unrestricted"`
+}
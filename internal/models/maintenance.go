@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// MaintenanceWindow закрывает место или целую зону на интервал [StartTime, EndTime):
+// в это время нельзя создать бронь, а место не попадает в список свободных
+type MaintenanceWindow struct {
+	Base
+
+	PlaceID   *uint     `json:"place_id,omitempty" gorm:"index"`
+	ZoneID    *uint     `json:"zone_id,omitempty" gorm:"index"`
+	StartTime time.Time `json:"start_time" gorm:"not null;index"`
+	EndTime   time.Time `json:"end_time" gorm:"not null;index"`
+	Reason    string    `json:"reason" gorm:"not null"`
+	CreatedBy uint      `json:"created_by" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MaintenanceWindowDTO struct {
+	PlaceID   *uint     `json:"place_id"`
+	ZoneID    *uint     `json:"zone_id"`
+	StartTime time.Time `json:"start_time" binding:"required"`
+	EndTime   time.Time `json:"end_time" binding:"required"`
+	Reason    string    `json:"reason" binding:"required,min=3"`
+}
+
+type MaintenanceWindowUpdateDTO struct {
+	StartTime *time.Time `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+	Reason    *string    `json:"reason" binding:"omitempty,min=3"`
+}
+
+type FilterMaintenance struct {
+	PlaceID *uint      `form:"place_id"`
+	ZoneID  *uint      `form:"zone_id"`
+	From    *time.Time `form:"from"` // окна, заканчивающиеся позже From
+	To      *time.Time `form:"to"`   // окна, начинающиеся раньше To
+	Limit   int        `form:"limit"`
+	Offset  int        `form:"offset"`
+
+	// LocationIDs — площадки администратора, заполняется сервисом
+	LocationIDs []uint `form:"-"`
+}
+
+// MaintenanceBookingsDTO — брони для массовой отмены; пустой список — все затронутые
+type MaintenanceBookingsDTO struct {
+	BookingIDs []uint `json:"booking_ids"`
+}
+
+type MaintenanceCancelResult struct {
+	WindowID          uint             `json:"window_id"`
+	CancelledBookings []uint           `json:"cancelled_bookings"`
+	FailedBookings    []BookingFailure `json:"failed_bookings,omitempty"`
+	Refunded          int              `json:"refunded"` // в копейках
+}
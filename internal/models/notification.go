@@ -0,0 +1,23 @@
+package models
+
+type NotificationChannel string
+
+const (
+	NotificationEmail NotificationChannel = "email"
+	NotificationLog   NotificationChannel = "log"
+)
+
+// NotificationPreference хранит выбор пользователя по каналу уведомлений.
+// Если записи для канала нет, канал считается включенным.
+type NotificationPreference struct {
+	Base
+
+	UserID  uint                `json:"user_id" gorm:"not null;uniqueIndex:idx_notification_pref_user_channel,priority:1"`
+	Channel NotificationChannel `json:"channel" gorm:"not null;uniqueIndex:idx_notification_pref_user_channel,priority:2"`
+	Enabled bool                `json:"enabled" gorm:"not null;default:true"`
+}
+
+type NotificationPreferenceDTO struct {
+	Channel NotificationChannel `json:"channel" binding:"required,oneof=email log"`
+	Enabled *bool               `json:"enabled" binding:"required"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+type OccupancyKind string
+
+const (
+	OccupancyBooked    OccupancyKind = "booked"
+	OccupancyReleased  OccupancyKind = "released"
+	OccupancyCheckedIn OccupancyKind = "checked_in"
+)
+
+// OccupancyEvent — изменение занятости места для SSE-потока
+type OccupancyEvent struct {
+	ID        string        `json:"id"`
+	Kind      OccupancyKind `json:"kind"`
+	PlaceID   uint          `json:"place_id"`
+	PlaceType PlaceType     `json:"place_type"`
+	BookingID uint          `json:"booking_id"`
+	StartTime time.Time     `json:"start_time"`
+	EndTime   time.Time     `json:"end_time"`
+	At        time.Time     `json:"at"`
+}
+
+type FilterOccupancy struct {
+	PlaceID *uint   `form:"place_id"`
+	Type    *string `form:"type" binding:"omitempty,oneof=workspace meeting_room"`
+}
+
+// Match проверяет, подходит ли событие под фильтр подписчика
+func (f FilterOccupancy) Match(ev OccupancyEvent) bool {
+	if f.PlaceID != nil && *f.PlaceID != ev.PlaceID {
+		return false
+	}
+	if f.Type != nil && PlaceType(*f.Type) != ev.PlaceType {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package models
+
+import "time"
+
+type BillingPolicy string
+
+const (
+	// BillingOrganization — брони участников оплачиваются с общего кошелька организации
+	BillingOrganization BillingPolicy = "organization"
+	// BillingPersonal — каждый участник платит со своего баланса
+	BillingPersonal BillingPolicy = "personal"
+)
+
+type OrgRole string
+
+const (
+	OrgOwner  OrgRole = "owner"  // управляет участниками, лимитами и политикой оплаты
+	OrgBooker OrgRole = "booker" // бронирует за других участников и видит их брони
+	OrgMember OrgRole = "member"
+)
+
+type Organization struct {
+	Base
+
+	Name          string        `json:"name" gorm:"not null"`
+	TaxID         string        `json:"tax_id"` // ИНН
+	Address       string        `json:"address"`
+	Balance       int           `json:"balance" gorm:"not null;default:0"` // общий кошелек, в копейках
+	BillingPolicy BillingPolicy `json:"billing_policy" gorm:"not null;default:'organization'"`
+	Postpaid      bool          `json:"postpaid" gorm:"not null;default:false"`
+	CreditLimit   int           `json:"credit_limit" gorm:"not null;default:0"`
+
+	Members []OrganizationMember `json:"members,omitempty" gorm:"foreignKey:OrganizationID"`
+}
+
+// OrganizationMember — участие пользователя в организации. Пользователь
+// может состоять только в одной организации, так кошелек для оплаты однозначен.
+type OrganizationMember struct {
+	Base
+
+	OrganizationID uint    `json:"organization_id" gorm:"not null;index"`
+	UserID         uint    `json:"user_id" gorm:"not null;uniqueIndex"`
+	Role           OrgRole `json:"role" gorm:"not null"`
+	// MonthlyLimit — сколько участник может потратить с кошелька организации за месяц, 0 — без лимита
+	MonthlyLimit int `json:"monthly_limit" gorm:"not null;default:0"`
+
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+type OrganizationInvitation struct {
+	Base
+
+	OrganizationID uint       `json:"organization_id" gorm:"not null;index"`
+	Email          string     `json:"email" gorm:"not null;index"`
+	Role           OrgRole    `json:"role" gorm:"not null"`
+	Token          string     `json:"token,omitempty" gorm:"not null;uniqueIndex"`
+	InvitedBy      uint       `json:"invited_by" gorm:"not null"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"not null"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+}
+
+type OrganizationDTO struct {
+	Name          string        `json:"name" binding:"required,min=2"`
+	TaxID         string        `json:"tax_id" binding:"omitempty,numeric,min=10,max=12"`
+	Address       string        `json:"address"`
+	BillingPolicy BillingPolicy `json:"billing_policy" binding:"omitempty,oneof=organization personal"`
+}
+
+type OrganizationUpdateDTO struct {
+	Name          *string        `json:"name" binding:"omitempty,min=2"`
+	TaxID         *string        `json:"tax_id" binding:"omitempty,numeric,min=10,max=12"`
+	Address       *string        `json:"address"`
+	BillingPolicy *BillingPolicy `json:"billing_policy" binding:"omitempty,oneof=organization personal"`
+}
+
+type InvitationDTO struct {
+	Email string  `json:"email" binding:"required,email"`
+	Role  OrgRole `json:"role" binding:"required,oneof=booker member"`
+}
+
+type MemberUpdateDTO struct {
+	Role         *OrgRole `json:"role" binding:"omitempty,oneof=owner booker member"`
+	MonthlyLimit *int     `json:"monthly_limit" binding:"omitempty,min=0"`
+}
+
+// OrganizationResDTO — организация глазами текущего участника
+type OrganizationResDTO struct {
+	Organization
+	Role         OrgRole `json:"role"`
+	MonthlyLimit int     `json:"monthly_limit"`
+	SpentMonth   int     `json:"spent_month"`
+}
+
+type FilterOrgBookings struct {
+	UserID *uint   `form:"user_id"`
+	Status *string `form:"status"`
+	Limit  int     `form:"limit"`
+	Offset int     `form:"offset"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+const (
+	AggregateBooking = "booking"
+	AggregateUser    = "user"
+	AggregateReview  = "review"
+
+	AggregateOrganization = "organization"
+)
+
+// OutboxEvent пишется в той же транзакции, что и изменение данных,
+// и публикуется relay-воркером после коммита. Delivered — получатели, уже
+// принявшие событие: при повторе им оно не отправляется. NextAttemptAt —
+// время, раньше которого relay событие не берет (повтор или обработка другим
+// экземпляром). После исчерпания попыток событие помечается DeadAt и больше
+// не отправляется.
+type OutboxEvent struct {
+	ID            uint       `json:"id" gorm:"primarykey"`
+	AggregateType string     `json:"aggregate_type" gorm:"not null;index:idx_outbox_aggregate,priority:1"`
+	AggregateID   uint       `json:"aggregate_id" gorm:"not null;index:idx_outbox_aggregate,priority:2"`
+	EventType     EventType  `json:"event_type" gorm:"not null"`
+	Payload       string     `json:"payload" gorm:"type:jsonb;not null"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	LastError     string     `json:"last_error"`
+	Delivered     []string   `json:"delivered" gorm:"type:jsonb;serializer:json"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	DeadAt        *time.Time `json:"dead_at" gorm:"index"`
+	PublishedAt   *time.Time `json:"published_at" gorm:"index:idx_outbox_unpublished,where:published_at IS NULL"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
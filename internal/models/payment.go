@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+type PaymentIntentStatus string
+
+const (
+	PaymentPending   PaymentIntentStatus = "pending"
+	PaymentSucceeded PaymentIntentStatus = "succeeded"
+	PaymentFailed    PaymentIntentStatus = "failed"
+	PaymentExpired   PaymentIntentStatus = "expired"
+	PaymentRefunded  PaymentIntentStatus = "refunded"
+	// PaymentRefunding — сумма уже списана с баланса, провайдер еще не подтвердил возврат
+	PaymentRefunding PaymentIntentStatus = "refunding"
+)
+
+// PaymentIntent — попытка пополнить баланс через платежного провайдера
+type PaymentIntent struct {
+	Base
+
+	UserID            uint                `json:"user_id" gorm:"not null;index;uniqueIndex:idx_payment_intent_idempotency,priority:1"`
+	Amount            int                 `json:"amount" gorm:"not null"` // в копейках
+	Currency          string              `json:"currency" gorm:"not null;default:'RUB'"`
+	Provider          string              `json:"provider" gorm:"not null;uniqueIndex:idx_payment_intent_provider,priority:1"`
+	ProviderPaymentID *string             `json:"provider_payment_id,omitempty" gorm:"uniqueIndex:idx_payment_intent_provider,priority:2"`
+	ConfirmationURL   string              `json:"confirmation_url,omitempty"`
+	Status            PaymentIntentStatus `json:"status" gorm:"not null;index"`
+	IdempotencyKey    *string             `json:"-" gorm:"uniqueIndex:idx_payment_intent_idempotency,priority:2"`
+	FailureReason     string              `json:"failure_reason,omitempty"`
+	ExpiresAt         time.Time           `json:"expires_at" gorm:"not null;index"`
+	PaidAt            *time.Time          `json:"paid_at,omitempty"`
+	RefundedAt        *time.Time          `json:"refunded_at,omitempty"`
+}
+
+// PaymentWebhookEvent хранит id обработанных уведомлений провайдера,
+// чтобы повторная доставка не зачислила деньги дважды
+type PaymentWebhookEvent struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt       time.Time `json:"created_at"`
+	Provider        string    `json:"provider" gorm:"not null;uniqueIndex:idx_payment_webhook_event,priority:1"`
+	EventID         string    `json:"event_id" gorm:"not null;uniqueIndex:idx_payment_webhook_event,priority:2"`
+	PaymentIntentID *uint     `json:"payment_intent_id,omitempty" gorm:"index"`
+	Status          string    `json:"status"`
+	Payload         string    `json:"payload" gorm:"type:jsonb"`
+}
+
+type TopupDTO struct {
+	Amount    int    `json:"amount" binding:"required,min=100"` // минимум 1 рубль
+	ReturnURL string `json:"return_url" binding:"omitempty,url"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// PlaceImage — фотография места. Сами файлы лежат в BlobStore, в базе только
+// ключи оригинала и миниатюры.
+type PlaceImage struct {
+	Base
+
+	PlaceID     uint   `json:"place_id" gorm:"not null;index"`
+	Position    int    `json:"position" gorm:"not null"`
+	Caption     string `json:"caption"`
+	ContentType string `json:"content_type" gorm:"not null"`
+	Size        int64  `json:"size" gorm:"not null"`
+	Width       int    `json:"width" gorm:"not null"`
+	Height      int    `json:"height" gorm:"not null"`
+	Key         string `json:"-" gorm:"not null"`
+	ThumbKey    string `json:"-" gorm:"not null"`
+}
+
+// PlaceImageView — элемент галереи со ссылками, действующими до ExpiresAt
+type PlaceImageView struct {
+	ID           uint      `json:"id"`
+	Position     int       `json:"position"`
+	Caption      string    `json:"caption,omitempty"`
+	ContentType  string    `json:"content_type"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type PlaceImageUpdateDTO struct {
+	Caption *string `json:"caption" binding:"omitempty,max=200"`
+}
+
+type PlaceImageOrderDTO struct {
+	ImageIDs []uint `json:"image_ids" binding:"required,min=1"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// PlaceRating — агрегаты по одобренным отзывам места. Пересчитываются
+// приращениями при одобрении, изменении и удалении отзыва.
+type PlaceRating struct {
+	PlaceID uint    `gorm:"primaryKey;autoIncrement:false"`
+	Count   int     `gorm:"not null;default:0"`
+	Sum     int     `gorm:"not null;default:0"`
+	Average float64 `gorm:"not null;default:0;index"`
+	Stars1  int     `gorm:"column:stars_1;not null;default:0"`
+	Stars2  int     `gorm:"column:stars_2;not null;default:0"`
+	Stars3  int     `gorm:"column:stars_3;not null;default:0"`
+	Stars4  int     `gorm:"column:stars_4;not null;default:0"`
+	Stars5  int     `gorm:"column:stars_5;not null;default:0"`
+
+	UpdatedAt time.Time
+}
+
+// Distribution — число отзывов по оценкам, индекс 0 соответствует одной звезде
+func (r PlaceRating) Distribution() [5]int {
+	return [5]int{r.Stars1, r.Stars2, r.Stars3, r.Stars4, r.Stars5}
+}
+
+func (r PlaceRating) MarshalJSON() ([]byte, error) {
+	distribution := make(map[string]int, 5)
+	for i, n := range r.Distribution() {
+		distribution[strconv.Itoa(i+1)] = n
+	}
+	return json.Marshal(struct {
+		Average      float64        `json:"average"`
+		Count        int            `json:"count"`
+		Distribution map[string]int `json:"distribution"`
+	}{r.Average, r.Count, distribution})
+}
+
+// FilterPlaceReviews — пагинация и сортировка отзывов на странице места
+type FilterPlaceReviews struct {
+	Limit  int    `form:"limit"`
+	Offset int    `form:"offset"`
+	SortBy string `form:"sort_by" binding:"omitempty,oneof=created_at rating"`
+	Order  string `form:"order" binding:"omitempty,oneof=asc desc"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// FilterPlaceSearch — полнотекстовый поиск по местам. Если заданы StartTime и
+// EndTime, в выдачу попадают только места, свободные весь этот интервал.
+type FilterPlaceSearch struct {
+	Query      string  `form:"q" binding:"required,min=2,max=200"`
+	Type       *string `form:"type" binding:"omitempty,oneof=workspace meeting_room"`
+	LocationID *uint   `form:"location_id"`
+	FloorID    *uint   `form:"floor_id"`
+	ZoneID     *uint   `form:"zone_id"`
+	// цена за час в копейках
+	MinPrice  *int       `form:"min_price" binding:"omitempty,gte=0"`
+	MaxPrice  *int       `form:"max_price" binding:"omitempty,gte=0"`
+	StartTime *time.Time `form:"start_time"`
+	EndTime   *time.Time `form:"end_time"`
+	Limit     int        `form:"limit"`
+	Offset    int        `form:"offset"`
+}
+
+// PlaceSearchHit — найденное место с релевантностью и подсветкой совпадений:
+// текст экранирован для HTML, совпадения обрамлены тегами <mark>
+type PlaceSearchHit struct {
+	Place
+
+	Rank                 float64 `json:"rank"`
+	NameHighlight        string  `json:"name_highlight"`
+	DescriptionHighlight string  `json:"description_highlight,omitempty"`
+}
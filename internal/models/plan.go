@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// Plan — абонемент с включенными часами, например «40 часов в open space + 4 часа переговорных»
+type Plan struct {
+	Base
+
+	Name          string `json:"name" gorm:"not null"`
+	Description   string `json:"description"`
+	Price         int    `json:"price" gorm:"not null"` // в копейках за период
+	PeriodDays    int    `json:"period_days" gorm:"not null;default:30"`
+	AllowRollover bool   `json:"allow_rollover"` // неиспользованные часы переносятся на следующий период
+	IsActive      bool   `json:"is_active" gorm:"not null;default:true"`
+
+	Allowances []PlanAllowance `json:"allowances" gorm:"foreignKey:PlanID"`
+}
+
+type PlanAllowance struct {
+	Base
+
+	PlanID    uint      `json:"plan_id" gorm:"not null;uniqueIndex:idx_plan_allowance_type,priority:1"`
+	PlaceType PlaceType `json:"place_type" gorm:"not null;uniqueIndex:idx_plan_allowance_type,priority:2"`
+	Hours     int       `json:"hours" gorm:"not null"`
+}
+
+type SubscriptionStatus string
+
+const (
+	SubscriptionActive  SubscriptionStatus = "active"
+	SubscriptionExpired SubscriptionStatus = "expired"
+)
+
+type Subscription struct {
+	Base
+
+	UserID      uint               `json:"user_id" gorm:"not null;index"`
+	PlanID      uint               `json:"plan_id" gorm:"not null"`
+	Status      SubscriptionStatus `json:"status" gorm:"not null;index"`
+	PeriodStart time.Time          `json:"period_start" gorm:"not null"`
+	PeriodEnd   time.Time          `json:"period_end" gorm:"not null;index"`
+	// AutoRenew выключается при отмене: абонемент доживает до конца периода
+	AutoRenew bool `json:"auto_renew" gorm:"not null;default:true"`
+
+	Plan    *Plan        `json:"plan,omitempty" gorm:"foreignKey:PlanID"`
+	Buckets []HourBucket `json:"buckets,omitempty" gorm:"foreignKey:SubscriptionID"`
+}
+
+// HourBucket — остаток включенных часов одного типа мест за один период абонемента
+type HourBucket struct {
+	Base
+
+	SubscriptionID uint      `json:"subscription_id" gorm:"not null;index"`
+	PlaceType      PlaceType `json:"place_type" gorm:"not null"`
+	PeriodStart    time.Time `json:"period_start" gorm:"not null"`
+	PeriodEnd      time.Time `json:"period_end" gorm:"not null"`
+	Total          int       `json:"total"` // часы плана плюс перенесенные
+	RolledOver     int       `json:"rolled_over"`
+	Used           int       `json:"used"`
+}
+
+func (b *HourBucket) Remaining() int {
+	return max(b.Total-b.Used, 0)
+}
+
+type PlanAllowanceDTO struct {
+	PlaceType PlaceType `json:"place_type" binding:"required,oneof=workspace meeting_room"`
+	Hours     int       `json:"hours" binding:"required,gt=0"`
+}
+
+type PlanDTO struct {
+	Name          string             `json:"name" binding:"required,min=2"`
+	Description   string             `json:"description"`
+	Price         int                `json:"price" binding:"min=0"`
+	PeriodDays    int                `json:"period_days" binding:"omitempty,gt=0"`
+	AllowRollover bool               `json:"allow_rollover"`
+	IsActive      *bool              `json:"is_active"`
+	Allowances    []PlanAllowanceDTO `json:"allowances" binding:"required,min=1,dive"`
+}
+
+type SubscribeDTO struct {
+	PlanID uint `json:"plan_id" binding:"required"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+type PriceRuleKind string
+
+const (
+	// PriceRuleHourly умножает цену часов, попавших в диапазон StartHour–EndHour
+	PriceRuleHourly PriceRuleKind = "hourly"
+	// PriceRuleDuration дает скидку на всю бронь от MinHours часов
+	PriceRuleDuration PriceRuleKind = "duration"
+)
+
+type PriceRule struct {
+	Base
+
+	Name      string        `json:"name" gorm:"not null"`
+	Kind      PriceRuleKind `json:"kind" gorm:"not null"`
+	PlaceID   *uint         `json:"place_id,omitempty" gorm:"index"`
+	PlaceType *PlaceType    `json:"place_type,omitempty"`
+	Weekdays  string        `json:"weekdays"` // дни недели через запятую, 0 — воскресенье; пусто — все дни
+	StartHour int           `json:"start_hour"`
+	EndHour   int           `json:"end_hour"` // не включительно
+	// Multiplier для hourly: 1.5 — дороже в полтора раза, 0.8 — скидка 20%
+	Multiplier      float64 `json:"multiplier"`
+	MinHours        int     `json:"min_hours"`
+	DiscountPercent int     `json:"discount_percent"`
+	Priority        int     `json:"priority" gorm:"not null;default:0"`
+	IsActive        bool    `json:"is_active" gorm:"not null;default:true"`
+}
+
+type PriceRuleDTO struct {
+	Name            string        `json:"name" binding:"required,min=2"`
+	Kind            PriceRuleKind `json:"kind" binding:"required,oneof=hourly duration"`
+	PlaceID         *uint         `json:"place_id"`
+	PlaceType       *PlaceType    `json:"place_type" binding:"omitempty,oneof=workspace meeting_room"`
+	Weekdays        []int         `json:"weekdays" binding:"omitempty,dive,min=0,max=6"`
+	StartHour       int           `json:"start_hour" binding:"min=0,max=23"`
+	EndHour         int           `json:"end_hour" binding:"min=0,max=24"`
+	Multiplier      float64       `json:"multiplier" binding:"omitempty,gt=0"`
+	MinHours        int           `json:"min_hours" binding:"min=0"`
+	DiscountPercent int           `json:"discount_percent" binding:"min=0,max=100"`
+	Priority        int           `json:"priority"`
+	IsActive        *bool         `json:"is_active"`
+}
+
+// PriceSegment — цена одного часа брони
+type PriceSegment struct {
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	BasePrice  int       `json:"base_price"`
+	Multiplier float64   `json:"multiplier"`
+	Price      int       `json:"price"`
+	Rule       string    `json:"rule,omitempty"`
+}
+
+// PriceQuote — расчет стоимости брони с разбивкой по часам, все суммы в копейках
+type PriceQuote struct {
+	PlaceID          uint           `json:"place_id"`
+	StartTime        time.Time      `json:"start_time"`
+	EndTime          time.Time      `json:"end_time"`
+	Hours            int            `json:"hours"`
+	Segments         []PriceSegment `json:"segments"`
+	Subtotal         int            `json:"subtotal"`
+	DurationDiscount int            `json:"duration_discount"`
+	DiscountRule     string         `json:"discount_rule,omitempty"`
+	PromoCode        string         `json:"promo_code,omitempty"`
+	PromoDiscount    int            `json:"promo_discount,omitempty"`
+	Total            int            `json:"total"`
+}
+
+type QuoteRequestDTO struct {
+	StartTime string `form:"start_time" binding:"required"`
+	EndTime   string `form:"end_time" binding:"required"`
+}
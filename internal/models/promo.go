@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+type PromoDiscountType string
+
+const (
+	PromoPercent PromoDiscountType = "percent"
+	PromoFixed   PromoDiscountType = "fixed"
+)
+
+type PromoRedemptionStatus string
+
+const (
+	PromoRedemptionApplied  PromoRedemptionStatus = "applied"
+	PromoRedemptionReleased PromoRedemptionStatus = "released"
+)
+
+type PromoCode struct {
+	Base
+
+	Code         string            `json:"code" gorm:"not null;uniqueIndex"`
+	Description  string            `json:"description"`
+	DiscountType PromoDiscountType `json:"discount_type" gorm:"not null"`
+	Value        int               `json:"value" gorm:"not null"` // процент или копейки
+	MaxHours     int               `json:"max_hours"`             // скидка только на первые N часов брони, 0 — на всю бронь
+	PlaceType    *PlaceType        `json:"place_type,omitempty"`
+	// окно действия проверяется по времени начала брони
+	ValidFrom        *time.Time `json:"valid_from,omitempty"`
+	ValidTo          *time.Time `json:"valid_to,omitempty"`
+	MaxRedemptions   int        `json:"max_redemptions"` // 0 — без лимита
+	PerUserLimit     int        `json:"per_user_limit"`  // 0 — без лимита
+	RedemptionsCount int        `json:"redemptions_count" gorm:"not null;default:0"`
+	IsActive         bool       `json:"is_active" gorm:"not null;default:true"`
+}
+
+// PromoRedemption фиксирует использование кода при оплате брони.
+// При отмене брони запись переходит в released и перестает считаться в лимитах.
+type PromoRedemption struct {
+	Base
+
+	PromoCodeID uint                  `json:"promo_code_id" gorm:"not null;index:idx_promo_redemption_user,priority:1"`
+	UserID      uint                  `json:"user_id" gorm:"not null;index:idx_promo_redemption_user,priority:2"`
+	BookingID   uint                  `json:"booking_id" gorm:"not null;uniqueIndex"`
+	Discount    int                   `json:"discount" gorm:"not null"`
+	Status      PromoRedemptionStatus `json:"status" gorm:"not null"`
+}
+
+type PromoCodeDTO struct {
+	Code           string            `json:"code" binding:"required,min=3,max=32"`
+	Description    string            `json:"description"`
+	DiscountType   PromoDiscountType `json:"discount_type" binding:"required,oneof=percent fixed"`
+	Value          int               `json:"value" binding:"required,gt=0"`
+	MaxHours       int               `json:"max_hours" binding:"min=0"`
+	PlaceType      *PlaceType        `json:"place_type" binding:"omitempty,oneof=workspace meeting_room"`
+	ValidFrom      *time.Time        `json:"valid_from"`
+	ValidTo        *time.Time        `json:"valid_to"`
+	MaxRedemptions int               `json:"max_redemptions" binding:"min=0"`
+	PerUserLimit   int               `json:"per_user_limit" binding:"min=0"`
+	IsActive       *bool             `json:"is_active"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+type RelocationStatus string
+
+const (
+	RelocationPending   RelocationStatus = "pending"   // не удалось перенести, ждет администратора
+	RelocationDone      RelocationStatus = "relocated" // бронь перенесена
+	RelocationCancelled RelocationStatus = "cancelled" // бронь отменена с возвратом
+)
+
+type RelocationSource string
+
+const (
+	RelocationDeactivation RelocationSource = "deactivation"
+	RelocationMaintenance  RelocationSource = "maintenance"
+)
+
+// BookingRelocation — запись о переносе брони с места, ставшего недоступным.
+// Записи в статусе pending образуют очередь для администраторов.
+type BookingRelocation struct {
+	Base
+
+	BookingID           uint             `json:"booking_id" gorm:"not null;index"`
+	UserID              uint             `json:"user_id" gorm:"not null;index"`
+	FromPlaceID         uint             `json:"from_place_id" gorm:"not null;index"`
+	ToPlaceID           *uint            `json:"to_place_id,omitempty"`
+	Source              RelocationSource `json:"source" gorm:"not null"`
+	MaintenanceWindowID *uint            `json:"maintenance_window_id,omitempty"`
+	Status              RelocationStatus `json:"status" gorm:"not null;index"`
+
+	OldPrice   int `json:"old_price"`
+	NewPrice   int `json:"new_price"`
+	Difference int `json:"difference"` // > 0 — доплата с баланса, < 0 — возврат, в копейках
+
+	// Error — почему бронь не удалось перенести автоматически
+	Error       string     `json:"error,omitempty"`
+	RequestedBy uint       `json:"requested_by"`
+	ResolvedBy  *uint      `json:"resolved_by,omitempty"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	Booking *Booking `json:"booking,omitempty" gorm:"foreignKey:BookingID"`
+}
+
+// RelocationOutcome — итог переноса одной брони на новое место
+type RelocationOutcome struct {
+	BookingID   uint `json:"booking_id"`
+	FromPlaceID uint `json:"from_place_id"`
+	ToPlaceID   uint `json:"to_place_id"`
+	OldPrice    int  `json:"old_price"`
+	NewPrice    int  `json:"new_price"`
+	Difference  int  `json:"difference"`
+}
+
+type RelocationBatchResult struct {
+	Relocated []BookingRelocation `json:"relocated"`
+	Queued    []BookingRelocation `json:"queued"`
+}
+
+type FilterRelocation struct {
+	Status      *RelocationStatus `form:"status" binding:"omitempty,oneof=pending relocated cancelled"`
+	FromPlaceID *uint             `form:"from_place_id"`
+	Limit       int               `form:"limit"`
+	Offset      int               `form:"offset"`
+
+	// LocationIDs — площадки администратора, заполняется сервисом
+	LocationIDs []uint `form:"-"`
+}
+
+type RelocationAssignDTO struct {
+	PlaceID uint `json:"place_id" binding:"required"`
+}
+
+type RelocationEvent struct {
+	BookingEvent
+	FromPlaceID   uint   `json:"from_place_id"`
+	FromPlaceName string `json:"from_place_name"`
+	PlaceName     string `json:"place_name"`
+	Difference    int    `json:"difference"`
+}
@@ -0,0 +1,93 @@
+package models
+
+import "time"
+
+type ReportPeriod string
+
+const (
+	ReportDay   ReportPeriod = "day"
+	ReportWeek  ReportPeriod = "week" // неделя с понедельника, как date_trunc в Postgres
+	ReportMonth ReportPeriod = "month"
+)
+
+type ReportGroupBy string
+
+const (
+	ReportByPlace ReportGroupBy = "place"
+	ReportByType  ReportGroupBy = "type"
+)
+
+type FilterReport struct {
+	From       time.Time     `form:"from" time_format:"2006-01-02" binding:"required"`
+	To         time.Time     `form:"to" time_format:"2006-01-02" binding:"required"` // не включая
+	Period     ReportPeriod  `form:"period" binding:"omitempty,oneof=day week month"`
+	GroupBy    ReportGroupBy `form:"group_by" binding:"omitempty,oneof=place type"`
+	PlaceID    *uint         `form:"place_id"`
+	LocationID *uint         `form:"location_id"`
+	Type       *string       `form:"type" binding:"omitempty,oneof=workspace meeting_room"`
+	// LocationIDs — площадки администратора, заполняется хендлером
+	LocationIDs []uint `form:"-"`
+	// Compare добавляет к строкам значения за предыдущий период той же длины
+	Compare bool   `form:"compare"`
+	Format  string `form:"format" binding:"omitempty,oneof=json csv"`
+}
+
+// RevenueRow — выручка по броням, начавшимся в интервале Period.
+// Суммы в копейках по счету выручки журнала: Net = Gross - Refunds.
+type RevenueRow struct {
+	Period    time.Time `json:"period"`
+	PlaceID   *uint     `json:"place_id,omitempty"`
+	PlaceName string    `json:"place_name,omitempty"`
+	PlaceType PlaceType `json:"place_type,omitempty"`
+	Bookings  int64     `json:"bookings"`
+	Gross     int       `json:"gross"`
+	Refunds   int       `json:"refunds"`
+	Net       int       `json:"net"`
+
+	PreviousNet *int     `json:"previous_net,omitempty"`
+	NetChange   *float64 `json:"net_change,omitempty"` // в процентах к предыдущему периоду
+}
+
+type RevenueTotals struct {
+	Bookings int64 `json:"bookings"`
+	Gross    int   `json:"gross"`
+	Refunds  int   `json:"refunds"`
+	Net      int   `json:"net"`
+}
+
+type RevenueReport struct {
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	Period   ReportPeriod   `json:"period"`
+	GroupBy  ReportGroupBy  `json:"group_by,omitempty"`
+	Items    []RevenueRow   `json:"items"`
+	Total    RevenueTotals  `json:"total"`
+	Previous *RevenueTotals `json:"previous,omitempty"`
+}
+
+// OccupancyRow — загрузка: часы активных броней к часам работы мест за интервал
+type OccupancyRow struct {
+	Period      time.Time `json:"period"`
+	PlaceID     *uint     `json:"place_id,omitempty"`
+	PlaceName   string    `json:"place_name,omitempty"`
+	PlaceType   PlaceType `json:"place_type,omitempty"`
+	Bookings    int64     `json:"bookings"`
+	BookedHours float64   `json:"booked_hours"`
+	OpenHours   float64   `json:"open_hours"`
+	Rate        float64   `json:"rate"` // в процентах
+
+	PreviousRate *float64 `json:"previous_rate,omitempty"`
+	RateChange   *float64 `json:"rate_change,omitempty"` // в процентных пунктах
+}
+
+type OccupancyReport struct {
+	From         time.Time      `json:"from"`
+	To           time.Time      `json:"to"`
+	Period       ReportPeriod   `json:"period"`
+	GroupBy      ReportGroupBy  `json:"group_by,omitempty"`
+	Items        []OccupancyRow `json:"items"`
+	BookedHours  float64        `json:"booked_hours"`
+	OpenHours    float64        `json:"open_hours"`
+	Rate         float64        `json:"rate"`
+	PreviousRate *float64       `json:"previous_rate,omitempty"`
+}
@@ -2,6 +2,15 @@ package models
 
 import "time"
 
+// ReviewStatus — состояние модерации отзыва; в публичных списках только approved
+type ReviewStatus string
+
+const (
+	ReviewPending  ReviewStatus = "pending"
+	ReviewApproved ReviewStatus = "approved"
+	ReviewRejected ReviewStatus = "rejected"
+)
+
 type Review struct {
 	Base
 	UserID     uint      `json:"user_id" gorm:"not null;index"`
@@ -12,6 +21,26 @@ type Review struct {
 	IsApproved bool      `json:"is_approved" gorm:"not null;default:false"`
 	CreatedAt  time.Time `json:"created_at"`
 
+	// Status и IsApproved меняются вместе; причина отказа видна только автору
+	Status          ReviewStatus `json:"status" gorm:"not null;default:pending;index"`
+	RejectionReason string       `json:"rejection_reason,omitempty"`
+	ModeratedAt     *time.Time   `json:"moderated_at,omitempty"`
+
+	// Отзыв привязан к завершенной брони автора; у отзывов, оставленных до
+	// проверки проживания, BookingID пуст и значка нет
+	BookingID    *uint `json:"booking_id,omitempty" gorm:"index"`
+	VerifiedStay bool  `json:"verified_stay" gorm:"not null;default:false"`
+
+	// Публичный ответ администрации
+	Reply        string     `json:"reply,omitempty"`
+	ReplyAdminID *uint      `json:"-"`
+	RepliedAt    *time.Time `json:"replied_at,omitempty"`
+
+	// Счетчики денормализованы, чтобы списки не считали голоса и жалобы;
+	// ReportCount — жалобы с последней модерации
+	HelpfulCount int `json:"helpful_count" gorm:"not null;default:0"`
+	ReportCount  int `json:"report_count" gorm:"not null;default:0"`
+
 	User  User   `json:"-"`
 	Place Place  `json:"-"`
 	Admin *Admin `json:"-"`
@@ -26,4 +55,18 @@ type PlaceRequestRatingIdDto struct {
 type UpdateReviewDTO struct {
 	Rating int    `json:"rating,omitempty"`
 	Text   string `json:"text,omitempty"`
+}
+
+type ReviewRejectDTO struct {
+	Reason string `json:"reason" binding:"required,min=3,max=500"`
+}
+
+// FilterReviews — очередь модерации; по умолчанию только ожидающие отзывы
+type FilterReviews struct {
+	Status  ReviewStatus `form:"status" binding:"omitempty,oneof=pending approved rejected"`
+	PlaceID *uint        `form:"place_id"`
+	Limit   int          `form:"limit"`
+	Offset  int          `form:"offset"`
+	// LocationIDs ограничивает выборку площадками администратора, не из запроса
+	LocationIDs []uint `form:"-"`
 }
\ No newline at end of file
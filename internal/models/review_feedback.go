@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// ReviewVote — отметка «полезно»; один голос пользователя на отзыв
+type ReviewVote struct {
+	ReviewID  uint      `json:"review_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID    uint      `json:"user_id" gorm:"primaryKey;autoIncrement:false;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReviewReport — жалоба на отзыв. Открытые жалобы закрываются при следующей
+// модерации отзыва; повторно пожаловаться можно только после этого.
+type ReviewReport struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	ReviewID   uint       `json:"review_id" gorm:"not null;index"`
+	UserID     uint       `json:"user_id" gorm:"not null"`
+	Reason     string     `json:"reason" gorm:"not null"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+type ReviewReplyDTO struct {
+	Text string `json:"text" binding:"required,min=2,max=1000"`
+}
+
+type ReviewReportDTO struct {
+	Reason string `json:"reason" binding:"required,min=3,max=500"`
+}
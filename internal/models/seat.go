@@ -19,18 +19,97 @@ type Place struct {
 	IsActive     bool      `json:"is_active" gorm:"not null;default:true"`
 	CreatedAt    time.Time `json:"created_at"`
 
+	// Вместимость и удобства нужны, чтобы подобрать равноценную замену месту
+	Capacity  int        `json:"capacity" gorm:"not null;default:1"`
+	Amenities StringList `json:"amenities" gorm:"type:jsonb;not null;default:'[]'"` // projector, whiteboard, ...
+
+	// Размещение: площадка, этаж и зона; у мест, заведенных до площадок, пусто
+	LocationID *uint `json:"location_id,omitempty" gorm:"index"`
+	FloorID    *uint `json:"floor_id,omitempty" gorm:"index"`
+	ZoneID     *uint `json:"zone_id,omitempty" gorm:"index"`
+
+	// Положение на плане этажа в координатах плана: точка и/или контур
+	MapX       *float64 `json:"map_x,omitempty"`
+	MapY       *float64 `json:"map_y,omitempty"`
+	MapPolygon JSONText `json:"map_polygon,omitempty" gorm:"type:jsonb"` // [[x, y], ...]
+
+	// Gallery заполняется только в карточке места
+	Gallery []PlaceImageView `json:"gallery,omitempty" gorm:"-"`
+
+	// Rating — агрегаты одобренных отзывов; у мест без отзывов пусто
+	Rating *PlaceRating `json:"rating,omitempty" gorm:"foreignKey:PlaceID"`
+
 	Bookings []Booking `json:"-"`
 	Reviews  []Review  `json:"-"`
 }
 
+type PlaceCreateDTO struct {
+	Name         string    `json:"name" binding:"required,min=2"`
+	Type         PlaceType `json:"type" binding:"required,oneof=workspace meeting_room"`
+	Description  string    `json:"description"`
+	PricePerHour int       `json:"price_per_hour" binding:"required,gt=0"` // в копейках
+	IsActive     *bool     `json:"is_active"`
+	Capacity     int       `json:"capacity" binding:"omitempty,gt=0"` // по умолчанию 1
+	Amenities    []string  `json:"amenities" binding:"omitempty,dive,min=1"`
+	// Достаточно указать самый нижний уровень: зона определяет этаж и площадку
+	LocationID *uint `json:"location_id"`
+	FloorID    *uint `json:"floor_id"`
+	ZoneID     *uint `json:"zone_id"`
+}
+
+type PlaceUpdateDTO struct {
+	Name         *string    `json:"name" binding:"omitempty,min=2"`
+	Type         *PlaceType `json:"type" binding:"omitempty,oneof=workspace meeting_room"`
+	Description  *string    `json:"description"`
+	PricePerHour *int       `json:"price_per_hour" binding:"omitempty,gt=0"`
+	IsActive     *bool      `json:"is_active"`
+	Capacity     *int       `json:"capacity" binding:"omitempty,gt=0"`
+	Amenities    *[]string  `json:"amenities" binding:"omitempty,dive,min=1"`
+	LocationID   *uint      `json:"location_id"`
+	FloorID      *uint      `json:"floor_id"`
+	ZoneID       *uint      `json:"zone_id"`
+}
+
+// PlaceRemovalResult — итог отключения или удаления места с отменой будущих броней
+type PlaceRemovalResult struct {
+	PlaceID           uint   `json:"place_id"`
+	CancelledBookings []uint `json:"cancelled_bookings"`
+	Refunded          int    `json:"refunded"` // в копейках
+	// При relocate брони переносятся на другие места, а неудачные попадают в очередь
+	RelocatedBookings []uint `json:"relocated_bookings,omitempty"`
+	QueuedBookings    []uint `json:"queued_bookings,omitempty"`
+	// Брони, которые не удалось отменить; место при этом уже отключено
+	FailedBookings []BookingFailure `json:"failed_bookings,omitempty"`
+}
+
+// BookingFailure — бронь, которую не удалось обработать, и причина
+type BookingFailure struct {
+	BookingID uint   `json:"booking_id"`
+	Error     string `json:"error"`
+}
+
+// PlaceRemovalMode — что делать с будущими бронями отключаемого места
+type PlaceRemovalMode string
+
+const (
+	RemovalRefuse   PlaceRemovalMode = ""         // отказать, если брони есть
+	RemovalCascade  PlaceRemovalMode = "cascade"  // отменить с возвратом денег
+	RemovalRelocate PlaceRemovalMode = "relocate" // перенести на равноценные места
+)
+
 // FilterPlace используется для листинга мест и поиска свободных мест
 type FilterPlace struct {
-	Type      *string    `form:"type" binding:"omitempty,oneof=workspace meeting_room"`
-	IsActive  *bool      `form:"is_active"`
-	StartTime *time.Time `form:"start_time"`
-	EndTime   *time.Time `form:"end_time"`
-	Limit     int        `form:"limit"`
-	Offset    int        `form:"offset"`
-	SortBy    string     `form:"sort_by"`
-	Order     string     `form:"order"`
+	Type       *string `form:"type" binding:"omitempty,oneof=workspace meeting_room"`
+	IsActive   *bool   `form:"is_active"`
+	LocationID *uint   `form:"location_id"`
+	FloorID    *uint   `form:"floor_id"`
+	ZoneID     *uint   `form:"zone_id"`
+	// LocationIDs ограничивает выборку площадками администратора, не из запроса
+	LocationIDs []uint     `form:"-"`
+	StartTime   *time.Time `form:"start_time"`
+	EndTime     *time.Time `form:"end_time"`
+	Limit       int        `form:"limit"`
+	Offset      int        `form:"offset"`
+	SortBy      string     `form:"sort_by"`
+	Order       string     `form:"order"`
 }
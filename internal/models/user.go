@@ -10,16 +10,22 @@ type User struct {
 	IsBlocked    bool   `json:"is_blocked" gorm:"default:false"`
 	Balance      int    `json:"balance"`
 
+	// В постоплате баланс может уйти в минус до CreditLimit, долг гасится ежемесячным счетом
+	Postpaid    bool `json:"postpaid" gorm:"not null;default:false"`
+	CreditLimit int  `json:"credit_limit" gorm:"not null;default:0"`
+
 	Bookings []Booking `json:"bookings" gorm:"foreignKey:UserID"`
 	Reviews  []Review  `json:"reviews" gorm:"foreignKey:UserID"`
 }
 type UserResponseDTO struct {
-	ID        uint            `json:"id"`
-	Email     string          `json:"email"`
-	FirstName string          `json:"first_name"`
-	LastName  string          `json:"last_name"`
-	Balance   int             `json:"balance"`
-	Bookings  []BookingResDTO `json:"bookings,omitempty"`
+	ID          uint            `json:"id"`
+	Email       string          `json:"email"`
+	FirstName   string          `json:"first_name"`
+	LastName    string          `json:"last_name"`
+	Balance     int             `json:"balance"`
+	Postpaid    bool            `json:"postpaid"`
+	CreditLimit int             `json:"credit_limit,omitempty"`
+	Bookings    []BookingResDTO `json:"bookings,omitempty"`
 }
 
 type UserUpdateDTO struct {
@@ -27,8 +33,10 @@ type UserUpdateDTO struct {
 	LastName  *string `json:"last_name"`
 }
 
-type UpdateBalanceDTO struct {
-	Amount int `json:"amount" binding:"required"`
+// BillingSettingsDTO — режим оплаты пользователя или организации, меняет администратор
+type BillingSettingsDTO struct {
+	Postpaid    *bool `json:"postpaid"`
+	CreditLimit *int  `json:"credit_limit" binding:"omitempty,min=0"`
 }
 
 type RegisterRequest struct {
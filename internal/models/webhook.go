@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryDead      WebhookDeliveryStatus = "dead"
+)
+
+type WebhookSubscription struct {
+	Base
+
+	URL         string `json:"url" gorm:"not null"`
+	Secret      string `json:"-" gorm:"not null"`
+	Events      string `json:"events" gorm:"not null"` // типы событий через запятую
+	Description string `json:"description"`
+	IsActive    bool   `json:"is_active" gorm:"not null;default:true"`
+}
+
+type WebhookDelivery struct {
+	Base
+
+	SubscriptionID uint                  `json:"subscription_id" gorm:"not null;uniqueIndex:idx_webhook_delivery_event,priority:1"`
+	EventID        string                `json:"event_id" gorm:"not null;uniqueIndex:idx_webhook_delivery_event,priority:2"`
+	EventType      EventType             `json:"event_type" gorm:"not null"`
+	Payload        string                `json:"payload" gorm:"type:jsonb;not null"`
+	Status         WebhookDeliveryStatus `json:"status" gorm:"not null;default:'pending';index:idx_webhook_delivery_due,priority:1"`
+	Attempts       int                   `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at" gorm:"not null;index:idx_webhook_delivery_due,priority:2"`
+	ResponseStatus int                   `json:"response_status"`
+	LastError      string                `json:"last_error"`
+	DeliveredAt    *time.Time            `json:"delivered_at"`
+	CreatedAt      time.Time             `json:"created_at"`
+}
+
+type WebhookSubscriptionDTO struct {
+	URL         string   `json:"url" binding:"required,url"`
+	Events      []string `json:"events" binding:"required,min=1"` // проверяются по EventTypes в сервисе
+	Secret      string   `json:"secret" binding:"omitempty,min=16"`
+	Description string   `json:"description"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+type FilterWebhookDelivery struct {
+	Status *string `form:"status" binding:"omitempty,oneof=pending delivered dead"`
+	Limit  int     `form:"limit"`
+	Offset int     `form:"offset"`
+}
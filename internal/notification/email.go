@@ -0,0 +1,127 @@
+package notification
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPConfigFromEnv читает настройки SMTP из окружения.
+// Пустой SMTP_HOST означает, что email-канал выключен.
+func SMTPConfigFromEnv() SMTPConfig {
+	cfg := SMTPConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USER"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	if cfg.Port == "" {
+		cfg.Port = "25"
+	}
+	if cfg.From == "" {
+		cfg.From = "noreply@coworking.local"
+	}
+	return cfg
+}
+
+type EmailNotifier struct {
+	cfg    SMTPConfig
+	logger *slog.Logger
+}
+
+func NewEmailNotifier(cfg SMTPConfig, logger *slog.Logger) *EmailNotifier {
+	return &EmailNotifier{cfg: cfg, logger: logger}
+}
+
+func (n *EmailNotifier) Channel() models.NotificationChannel {
+	return models.NotificationEmail
+}
+
+func (n *EmailNotifier) Send(ctx context.Context, msg Message) error {
+	if msg.To == "" {
+		return errors.New("не указан получатель")
+	}
+
+	addr := net.JoinHostPort(n.cfg.Host, n.cfg.Port)
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		n.logger.Error("smtp dial failed", "addr", addr, "error", err)
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	// STARTTLS только если сервер его поддерживает: локальные SMTP-заглушки обычно без TLS
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.cfg.Host}); err != nil {
+			return err
+		}
+	}
+
+	if n.cfg.Username != "" {
+		auth := smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(n.cfg.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(n.buildMessage(msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	n.logger.Info("email sent", "to", msg.To, "subject", msg.Subject)
+	return client.Quit()
+}
+
+func (n *EmailNotifier) buildMessage(msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", n.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,26 @@
+package notification
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+)
+
+// LogNotifier пишет уведомления в лог, удобно для локальной разработки
+type LogNotifier struct {
+	logger *slog.Logger
+}
+
+func NewLogNotifier(logger *slog.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Channel() models.NotificationChannel {
+	return models.NotificationLog
+}
+
+func (n *LogNotifier) Send(ctx context.Context, msg Message) error {
+	n.logger.Info("notification", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
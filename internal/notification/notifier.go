@@ -0,0 +1,20 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+)
+
+// Message — готовое к отправке уведомление
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier — канал доставки уведомлений (email, лог и т.д.)
+type Notifier interface {
+	Channel() models.NotificationChannel
+	Send(ctx context.Context, msg Message) error
+}
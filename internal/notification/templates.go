@@ -0,0 +1,118 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+)
+
+type Kind string
+
+const (
+	KindBookingConfirmed Kind = "booking_confirmed"
+	KindBookingReminder  Kind = "booking_reminder"
+	KindBookingCancelled Kind = "booking_cancelled"
+	KindBookingRelocated Kind = "booking_relocated"
+)
+
+// TemplateData — данные, доступные в шаблонах уведомлений
+type TemplateData struct {
+	FirstName  string
+	PlaceName  string
+	StartTime  time.Time
+	EndTime    time.Time
+	TotalPrice int // в копейках
+	Refund     int // в копейках
+	Minutes    int
+
+	FromPlaceName string // прежнее место при переносе брони
+	Charged       int    // списано с баланса за бронь, при переносе — доплата; в копейках
+	IncludedHours int    // часы, покрытые абонементом
+}
+
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+var funcs = template.FuncMap{
+	"rub": formatRub,
+	"dt": func(t time.Time) string {
+		return t.Format("02.01.2006 15:04")
+	},
+}
+
+var templates = map[Kind]messageTemplate{
+	KindBookingConfirmed: parse(
+		"Бронирование подтверждено: {{.PlaceName}}",
+		`Здравствуйте{{if .FirstName}}, {{.FirstName}}{{end}}!
+
+Ваше бронирование подтверждено.
+Место: {{.PlaceName}}
+Время: {{dt .StartTime}} — {{dt .EndTime}}
+{{if .IncludedHours}}Из абонемента: {{.IncludedHours}} ч.
+{{end}}Списано с баланса: {{rub .Charged}}`,
+	),
+	KindBookingReminder: parse(
+		"Напоминание о бронировании: {{.PlaceName}}",
+		`Здравствуйте{{if .FirstName}}, {{.FirstName}}{{end}}!
+
+Через {{.Minutes}} мин. начинается ваше бронирование.
+Место: {{.PlaceName}}
+Время: {{dt .StartTime}} — {{dt .EndTime}}`,
+	),
+	KindBookingCancelled: parse(
+		"Бронирование отменено: {{.PlaceName}}",
+		`Здравствуйте{{if .FirstName}}, {{.FirstName}}{{end}}!
+
+Ваше бронирование отменено.
+Место: {{.PlaceName}}
+Время: {{dt .StartTime}} — {{dt .EndTime}}
+{{if .Refund}}На баланс возвращено: {{rub .Refund}}{{else}}Списаний по брони не было.{{end}}`,
+	),
+	KindBookingRelocated: parse(
+		"Бронирование перенесено: {{.PlaceName}}",
+		`Здравствуйте{{if .FirstName}}, {{.FirstName}}{{end}}!
+
+Место {{.FromPlaceName}} недоступно в выбранное время, поэтому мы перенесли ваше бронирование.
+Новое место: {{.PlaceName}}
+Время: {{dt .StartTime}} — {{dt .EndTime}}
+Стоимость: {{rub .TotalPrice}}
+{{if .Charged}}С баланса списана доплата: {{rub .Charged}}{{else if .Refund}}На баланс возвращена разница: {{rub .Refund}}{{end}}`,
+	),
+}
+
+func parse(subject, body string) messageTemplate {
+	return messageTemplate{
+		subject: template.Must(template.New("subject").Funcs(funcs).Parse(subject)),
+		body:    template.Must(template.New("body").Funcs(funcs).Parse(body)),
+	}
+}
+
+// Render собирает тему и текст уведомления по шаблону
+func Render(kind Kind, to string, data TemplateData) (Message, error) {
+	tpl, ok := templates[kind]
+	if !ok {
+		return Message{}, fmt.Errorf("неизвестный тип уведомления: %s", kind)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tpl.subject.Execute(&subject, data); err != nil {
+		return Message{}, err
+	}
+	if err := tpl.body.Execute(&body, data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{To: to, Subject: subject.String(), Body: body.String()}, nil
+}
+
+func formatRub(kopecks int) string {
+	sign := ""
+	if kopecks < 0 {
+		sign = "-"
+		kopecks = -kopecks
+	}
+	return fmt.Sprintf("%s%d.%02d ₽", sign, kopecks/100, kopecks%100)
+}
@@ -0,0 +1,219 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	headerTimestamp = "X-Payment-Timestamp"
+	headerSignature = "X-Payment-Signature"
+
+	// signatureTolerance защищает от повторной отправки старых перехваченных вебхуков
+	signatureTolerance = 5 * time.Minute
+)
+
+type fakePayment struct {
+	amount    int
+	returnURL string
+	status    Status
+}
+
+// FakeProvider — провайдер для локальной разработки. Страница оплаты
+// (ServeHTTP) отправляет подписанный вебхук на WebhookURL так же,
+// как это сделал бы настоящий провайдер.
+type FakeProvider struct {
+	secret     string
+	publicURL  string
+	webhookURL string
+	client     *http.Client
+	logger     *slog.Logger
+
+	mu       sync.Mutex
+	payments map[string]*fakePayment
+}
+
+// NewFakeProvider: publicURL — адрес, по которому смонтирована страница
+// оплаты (например, http://localhost:8080/payments/fake), webhookURL — куда слать уведомления.
+func NewFakeProvider(secret, publicURL, webhookURL string, logger *slog.Logger) *FakeProvider {
+	return &FakeProvider{
+		secret:     secret,
+		publicURL:  strings.TrimRight(publicURL, "/"),
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		logger:     logger,
+		payments:   make(map[string]*fakePayment),
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) CreatePayment(ctx context.Context, req CreatePaymentRequest) (*Payment, error) {
+	id, err := randomID("fake_")
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.payments[id] = &fakePayment{amount: req.Amount, returnURL: req.ReturnURL}
+	p.mu.Unlock()
+
+	return &Payment{ID: id, ConfirmationURL: p.publicURL + "/" + id}, nil
+}
+
+func (p *FakeProvider) Refund(ctx context.Context, paymentID string, amount int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fp, ok := p.payments[paymentID]
+	if !ok {
+		// После рестарта память провайдера пуста — для локальной разработки это допустимо
+		return nil
+	}
+	if fp.status == StatusRefunded {
+		return nil
+	}
+	if fp.status != StatusSucceeded {
+		return errors.New("вернуть можно только успешный платеж")
+	}
+	fp.status = StatusRefunded
+	return nil
+}
+
+func (p *FakeProvider) ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error) {
+	if err := verifySignature(p.secret, header, body, time.Now()); err != nil {
+		return nil, err
+	}
+
+	var ev WebhookEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return nil, err
+	}
+	if ev.EventID == "" || ev.PaymentID == "" {
+		return nil, errors.New("в вебхуке нет event_id или payment_id")
+	}
+	return &ev, nil
+}
+
+// ServeHTTP — страница оплаты: GET /{payment_id}?outcome=succeeded|failed|canceled
+func (p *FakeProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := path.Base(r.URL.Path)
+
+	status := Status(r.URL.Query().Get("outcome"))
+	if status == "" {
+		status = StatusSucceeded
+	}
+	if status != StatusSucceeded && status != StatusFailed && status != StatusCanceled {
+		http.Error(w, "outcome должен быть succeeded, failed или canceled", http.StatusBadRequest)
+		return
+	}
+
+	p.mu.Lock()
+	fp, ok := p.payments[id]
+	if ok && fp.status == "" {
+		fp.status = status
+	}
+	p.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "платеж не найден", http.StatusNotFound)
+		return
+	}
+
+	if err := p.sendWebhook(r.Context(), WebhookEvent{PaymentID: id, Status: status, Amount: fp.amount}); err != nil {
+		p.logger.Error("fake provider webhook failed", "payment_id", id, "error", err)
+		http.Error(w, "не удалось отправить уведомление об оплате", http.StatusBadGateway)
+		return
+	}
+
+	if fp.returnURL != "" {
+		http.Redirect(w, r, fp.returnURL, http.StatusFound)
+		return
+	}
+	fmt.Fprintf(w, "платеж %s: %s\n", id, status)
+}
+
+func (p *FakeProvider) sendWebhook(ctx context.Context, ev WebhookEvent) error {
+	eventID, err := randomID("evt_")
+	if err != nil {
+		return err
+	}
+	ev.EventID = eventID
+
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(headerTimestamp, timestamp)
+	req.Header.Set(headerSignature, "sha256="+sign(p.secret, timestamp, body))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook endpoint ответил %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func verifySignature(secret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get(headerTimestamp)
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > signatureTolerance || d < -signatureTolerance {
+		return ErrInvalidSignature
+	}
+
+	got, ok := strings.CutPrefix(header.Get(headerSignature), "sha256=")
+	if !ok {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(got), []byte(sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func randomID(prefix string) (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
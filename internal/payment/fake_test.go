@@ -0,0 +1,58 @@
+package payment
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	const secret = "payment_secret"
+	now := time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"id":"pay_1","status":"succeeded"}`)
+
+	header := func(ts time.Time, signature string) http.Header {
+		h := http.Header{}
+		timestamp := strconv.FormatInt(ts.Unix(), 10)
+		h.Set(headerTimestamp, timestamp)
+		if signature == "" {
+			signature = "sha256=" + sign(secret, timestamp, body)
+		}
+		h.Set(headerSignature, signature)
+		return h
+	}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   []byte
+		ok     bool
+	}{
+		{name: "верная подпись", header: header(now, ""), body: body, ok: true},
+		{name: "на границе допуска", header: header(now.Add(-signatureTolerance), ""), body: body, ok: true},
+		{name: "устаревший timestamp", header: header(now.Add(-signatureTolerance-time.Second), ""), body: body},
+		{name: "timestamp из будущего", header: header(now.Add(signatureTolerance+time.Second), ""), body: body},
+		{name: "измененное тело", header: header(now, ""), body: []byte(`{"id":"pay_1","status":"failed"}`)},
+		{name: "чужой секрет", header: header(now, "sha256="+sign("other", strconv.FormatInt(now.Unix(), 10), body)), body: body},
+		{name: "без префикса sha256=", header: header(now, sign(secret, strconv.FormatInt(now.Unix(), 10), body)), body: body},
+		{name: "без timestamp", header: http.Header{headerSignature: []string{"sha256=00"}}, body: body},
+		{name: "timestamp не число", header: http.Header{headerTimestamp: []string{"вчера"}, headerSignature: []string{"sha256=00"}}, body: body},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySignature(secret, tt.header, tt.body, now)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("неожиданная ошибка: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidSignature) {
+				t.Fatalf("ошибка %v, ожидалась ErrInvalidSignature", err)
+			}
+		})
+	}
+}
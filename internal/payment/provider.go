@@ -0,0 +1,49 @@
+// Package payment описывает интеграцию с платежными провайдерами для пополнения баланса.
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+type Status string
+
+const (
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCanceled  Status = "canceled"
+	StatusRefunded  Status = "refunded"
+)
+
+var ErrInvalidSignature = errors.New("неверная подпись вебхука провайдера")
+
+type CreatePaymentRequest struct {
+	Amount         int // в копейках
+	Currency       string
+	Description    string
+	IdempotencyKey string
+	ReturnURL      string
+}
+
+type Payment struct {
+	ID              string
+	ConfirmationURL string // куда отправить пользователя для оплаты
+}
+
+// WebhookEvent — уведомление провайдера об изменении статуса платежа
+type WebhookEvent struct {
+	EventID   string `json:"event_id"`
+	PaymentID string `json:"payment_id"`
+	Status    Status `json:"status"`
+	Amount    int    `json:"amount"`
+}
+
+type PaymentProvider interface {
+	Name() string
+	CreatePayment(ctx context.Context, req CreatePaymentRequest) (*Payment, error)
+	// Refund должен быть идемпотентным: после сбоя возврат запрашивается повторно
+	Refund(ctx context.Context, paymentID string, amount int) error
+	// ParseWebhook проверяет подпись и разбирает тело уведомления
+	ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error)
+}
@@ -0,0 +1,215 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/redis"
+)
+
+const (
+	occupancyStream  = "places:occupancy:stream"
+	occupancyChannel = "places:occupancy"
+	streamMaxLen     = 5000
+	localHistorySize = 1000
+	subscriberBuffer = 64
+)
+
+// Broker раздает события занятости SSE-подписчикам.
+// С Redis событие пишется в stream (для Last-Event-ID) и рассылается через
+// pub/sub, так что его получают подписчики всех экземпляров API.
+// Без Redis работает в пределах одного процесса.
+type Broker struct {
+	client *redis.Client
+	logger *slog.Logger
+
+	mu      sync.RWMutex
+	subs    map[chan models.OccupancyEvent]models.FilterOccupancy
+	history []models.OccupancyEvent
+	seq     uint64
+}
+
+func NewBroker(client *redis.Client, logger *slog.Logger) *Broker {
+	return &Broker{
+		client: client,
+		logger: logger,
+		subs:   make(map[chan models.OccupancyEvent]models.FilterOccupancy),
+	}
+}
+
+func (b *Broker) Publish(ctx context.Context, ev models.OccupancyEvent) error {
+	if b.client == nil {
+		b.mu.Lock()
+		b.seq++
+		ev.ID = strconv.FormatUint(b.seq, 10)
+		b.history = append(b.history, ev)
+		if len(b.history) > localHistorySize {
+			b.history = b.history[len(b.history)-localHistorySize:]
+		}
+		b.mu.Unlock()
+
+		b.fanOut(ev)
+		return nil
+	}
+
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	id, err := b.client.XAdd(ctx, &goredis.XAddArgs{
+		Stream: occupancyStream,
+		MaxLen: streamMaxLen,
+		Approx: true,
+		Values: map[string]any{"data": data},
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	ev.ID = id
+	if data, err = json.Marshal(ev); err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, occupancyChannel, data).Err()
+}
+
+// Since возвращает события после lastID — для возобновления по Last-Event-ID
+func (b *Broker) Since(ctx context.Context, lastID string, filter models.FilterOccupancy) ([]models.OccupancyEvent, error) {
+	var result []models.OccupancyEvent
+
+	if b.client == nil {
+		last, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			return nil, nil
+		}
+		b.mu.RLock()
+		defer b.mu.RUnlock()
+		for _, ev := range b.history {
+			seq, _ := strconv.ParseUint(ev.ID, 10, 64)
+			if seq > last && filter.Match(ev) {
+				result = append(result, ev)
+			}
+		}
+		return result, nil
+	}
+
+	msgs, err := b.client.XRangeN(ctx, occupancyStream, "("+lastID, "+", streamMaxLen).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range msgs {
+		raw, _ := msg.Values["data"].(string)
+		var ev models.OccupancyEvent
+		if err := json.Unmarshal([]byte(raw), &ev); err != nil {
+			continue
+		}
+		ev.ID = msg.ID
+		if filter.Match(ev) {
+			result = append(result, ev)
+		}
+	}
+	return result, nil
+}
+
+// IDAfter сообщает, что событие id идет после last. Понимает и id Redis
+// Streams ("мс-номер"), и локальные номера. Непонятный id считается новым:
+// лучше отправить дубль, чем потерять событие.
+func IDAfter(id, last string) bool {
+	a, okA := parseID(id)
+	b, okB := parseID(last)
+	if !okA || !okB {
+		return true
+	}
+	if a[0] != b[0] {
+		return a[0] > b[0]
+	}
+	return a[1] > b[1]
+}
+
+func parseID(id string) ([2]uint64, bool) {
+	ms, seq, hasSeq := strings.Cut(id, "-")
+	var parts [2]uint64
+	var err error
+	if parts[0], err = strconv.ParseUint(ms, 10, 64); err != nil {
+		return parts, false
+	}
+	if hasSeq {
+		if parts[1], err = strconv.ParseUint(seq, 10, 64); err != nil {
+			return parts, false
+		}
+	}
+	return parts, true
+}
+
+// Subscribe регистрирует локального подписчика. cancel нужно вызвать при отключении клиента.
+func (b *Broker) Subscribe(filter models.FilterOccupancy) (<-chan models.OccupancyEvent, func()) {
+	ch := make(chan models.OccupancyEvent, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subs[ch] = filter
+	b.mu.Unlock()
+
+	cancel := func() {
+		b.mu.Lock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+		b.mu.Unlock()
+	}
+	return ch, cancel
+}
+
+// Run слушает Redis pub/sub и раздает события локальным подписчикам.
+// Переподключение go-redis делает сам. Без Redis ничего не делает.
+func (b *Broker) Run(ctx context.Context) {
+	if b.client == nil {
+		return
+	}
+
+	pubsub := b.client.Subscribe(ctx, occupancyChannel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var ev models.OccupancyEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil {
+				b.logger.Warn("invalid occupancy message", "error", err)
+				continue
+			}
+			b.fanOut(ev)
+		}
+	}
+}
+
+func (b *Broker) fanOut(ev models.OccupancyEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch, filter := range b.subs {
+		if !filter.Match(ev) {
+			continue
+		}
+		select {
+		case ch <- ev:
+		default:
+			// медленный клиент не должен тормозить остальных
+			b.logger.Warn("occupancy subscriber is slow, event dropped", "event_id", ev.ID)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrAdjustmentProcessed = errors.New("корректировка уже обработана")
+
+type AdjustmentRepository interface {
+	// CreateAdjustment сохраняет корректировку; если она создана сразу в статусе
+	// applied, в той же транзакции проводится по журналу
+	CreateAdjustment(adj *models.BalanceAdjustment) error
+	GetAdjustment(id uint) (*models.BalanceAdjustment, error)
+	ListAdjustments(filter *models.FilterAdjustments) ([]models.BalanceAdjustment, int64, error)
+	ApproveAdjustment(id, reviewerID uint, comment string) (*models.BalanceAdjustment, error)
+	RejectAdjustment(id, reviewerID uint, comment string) (*models.BalanceAdjustment, error)
+	TargetExists(userID, orgID *uint) (bool, error)
+
+	Report(from, to time.Time) ([]models.AdjustmentReportRow, error)
+}
+
+type adjustmentRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewAdjustmentRepository(db *gorm.DB, logger *slog.Logger) AdjustmentRepository {
+	return &adjustmentRepository{db: db, logger: logger}
+}
+
+func (r *adjustmentRepository) CreateAdjustment(adj *models.BalanceAdjustment) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(adj).Error; err != nil {
+			return err
+		}
+		if adj.Status == models.AdjustmentApplied {
+			return applyAdjustment(tx, adj)
+		}
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("CreateAdjustment failed", "user_id", adj.UserID, "organization_id", adj.OrganizationID, "amount", adj.Amount, "error", err)
+		return err
+	}
+	r.logger.Info("balance adjustment created", "adjustment_id", adj.ID, "status", adj.Status, "amount", adj.Amount, "admin_id", adj.RequestedBy)
+	return nil
+}
+
+func (r *adjustmentRepository) GetAdjustment(id uint) (*models.BalanceAdjustment, error) {
+	var adj models.BalanceAdjustment
+	if err := r.db.First(&adj, id).Error; err != nil {
+		return nil, err
+	}
+	return &adj, nil
+}
+
+func (r *adjustmentRepository) ListAdjustments(filter *models.FilterAdjustments) ([]models.BalanceAdjustment, int64, error) {
+	q := r.db.Model(&models.BalanceAdjustment{})
+
+	if filter.AdminID != nil {
+		q = q.Where("requested_by = ?", *filter.AdminID)
+	}
+	if filter.UserID != nil {
+		q = q.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.OrganizationID != nil {
+		q = q.Where("organization_id = ?", *filter.OrganizationID)
+	}
+	if filter.Status != nil {
+		q = q.Where("status = ?", *filter.Status)
+	}
+	if filter.From != nil {
+		q = q.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		q = q.Where("created_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		r.logger.Error("ListAdjustments count failed", "error", err)
+		return nil, 0, err
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	var items []models.BalanceAdjustment
+	if err := q.Order("id desc").Limit(limit).Offset(max(filter.Offset, 0)).Find(&items).Error; err != nil {
+		r.logger.Error("ListAdjustments failed", "error", err)
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (r *adjustmentRepository) ApproveAdjustment(id, reviewerID uint, comment string) (*models.BalanceAdjustment, error) {
+	var adj models.BalanceAdjustment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Блокировка не дает двум администраторам провести одну корректировку дважды
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&adj, id).Error; err != nil {
+			return err
+		}
+		if adj.Status != models.AdjustmentPending {
+			return ErrAdjustmentProcessed
+		}
+
+		now := time.Now()
+		adj.ReviewedBy = &reviewerID
+		adj.ReviewedAt = &now
+		adj.ReviewComment = comment
+		return applyAdjustment(tx, &adj)
+	})
+	if err != nil {
+		r.logger.Warn("ApproveAdjustment failed", "adjustment_id", id, "reviewer_id", reviewerID, "error", err)
+		return nil, err
+	}
+	r.logger.Info("balance adjustment approved", "adjustment_id", id, "reviewer_id", reviewerID, "amount", adj.Amount)
+	return &adj, nil
+}
+
+func (r *adjustmentRepository) RejectAdjustment(id, reviewerID uint, comment string) (*models.BalanceAdjustment, error) {
+	res := r.db.Model(&models.BalanceAdjustment{}).
+		Where("id = ? AND status = ?", id, models.AdjustmentPending).
+		Updates(map[string]any{
+			"status":         models.AdjustmentRejected,
+			"reviewed_by":    reviewerID,
+			"reviewed_at":    time.Now(),
+			"review_comment": comment,
+		})
+	if res.Error != nil {
+		r.logger.Error("RejectAdjustment failed", "adjustment_id", id, "error", res.Error)
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		if _, err := r.GetAdjustment(id); err != nil {
+			return nil, err
+		}
+		return nil, ErrAdjustmentProcessed
+	}
+
+	r.logger.Info("balance adjustment rejected", "adjustment_id", id, "reviewer_id", reviewerID)
+	return r.GetAdjustment(id)
+}
+
+func (r *adjustmentRepository) TargetExists(userID, orgID *uint) (bool, error) {
+	q := r.db.Model(&models.User{})
+	id := userID
+	if orgID != nil {
+		q = r.db.Model(&models.Organization{})
+		id = orgID
+	}
+	if id == nil {
+		return false, nil
+	}
+
+	var count int64
+	if err := q.Where("id = ?", *id).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Report сводит корректировки за [from, to) по администраторам: сколько запрошено,
+// в каком они статусе, на какие суммы, и сколько чужих корректировок подтверждено
+func (r *adjustmentRepository) Report(from, to time.Time) ([]models.AdjustmentReportRow, error) {
+	var rows []models.AdjustmentReportRow
+	err := r.db.Raw(`
+		SELECT a.requested_by AS admin_id,
+		       COALESCE(ad.login, '') AS admin_login,
+		       COUNT(*) AS requested,
+		       COUNT(*) FILTER (WHERE a.status = ?) AS applied,
+		       COUNT(*) FILTER (WHERE a.status = ?) AS pending,
+		       COUNT(*) FILTER (WHERE a.status = ?) AS rejected,
+		       COALESCE(SUM(a.amount) FILTER (WHERE a.status = ? AND a.amount > 0), 0) AS credited,
+		       COALESCE(-SUM(a.amount) FILTER (WHERE a.status = ? AND a.amount < 0), 0) AS debited
+		FROM balance_adjustments a
+		LEFT JOIN admins ad ON ad.id = a.requested_by
+		WHERE a.deleted_at IS NULL AND a.created_at >= ? AND a.created_at < ?
+		GROUP BY a.requested_by, ad.login
+		ORDER BY a.requested_by`,
+		models.AdjustmentApplied, models.AdjustmentPending, models.AdjustmentRejected,
+		models.AdjustmentApplied, models.AdjustmentApplied,
+		from, to,
+	).Scan(&rows).Error
+	if err != nil {
+		r.logger.Error("adjustment Report failed", "error", err)
+		return nil, err
+	}
+
+	var approvals []struct {
+		AdminID uint
+		Login   string
+		Count   int64
+	}
+	err = r.db.Raw(`
+		SELECT a.reviewed_by AS admin_id, COALESCE(ad.login, '') AS login, COUNT(*) AS count
+		FROM balance_adjustments a
+		LEFT JOIN admins ad ON ad.id = a.reviewed_by
+		WHERE a.deleted_at IS NULL AND a.status = ? AND a.reviewed_by IS NOT NULL
+		  AND a.reviewed_at >= ? AND a.reviewed_at < ?
+		GROUP BY a.reviewed_by, ad.login`,
+		models.AdjustmentApplied, from, to,
+	).Scan(&approvals).Error
+	if err != nil {
+		r.logger.Error("adjustment Report approvals failed", "error", err)
+		return nil, err
+	}
+
+	// Администратор мог только подтверждать, ничего не запрашивая сам
+	index := make(map[uint]int, len(rows))
+	for i, row := range rows {
+		index[row.AdminID] = i
+	}
+	for _, a := range approvals {
+		if i, ok := index[a.AdminID]; ok {
+			rows[i].Approved = a.Count
+			continue
+		}
+		rows = append(rows, models.AdjustmentReportRow{AdminID: a.AdminID, AdminLogin: a.Login, Approved: a.Count})
+	}
+
+	return rows, nil
+}
+
+func applyAdjustment(tx *gorm.DB, adj *models.BalanceAdjustment) error {
+	posting := models.LedgerPosting{
+		OrganizationID: adj.OrganizationID,
+		Type:           models.LedgerAdjustment,
+		Amount:         adj.Amount,
+		Reason:         "admin_adjustment:" + string(adj.ReasonCode),
+		AdminID:        &adj.RequestedBy,
+		AllowNegative:  true,
+	}
+	if adj.UserID != nil {
+		posting.UserID = *adj.UserID
+	}
+
+	entry, err := PostLedger(tx, posting)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	adj.Status = models.AdjustmentApplied
+	adj.AppliedAt = &now
+	if entry != nil {
+		adj.LedgerEntryID = &entry.ID
+	}
+	return tx.Model(adj).
+		Select("status", "applied_at", "ledger_entry_id", "reviewed_by", "reviewed_at", "review_comment").
+		Updates(adj).Error
+}
@@ -1,14 +1,19 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
 
 	"github.com/IslamCHup/coworking-manager-project/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrPlaceUnavailable — место отключили, пока создавалась бронь
+var ErrPlaceUnavailable = errors.New("место недоступно для бронирования")
+
 type BookingRepository interface {
 	CreateBooking(req *models.Booking) error
 	ListBooking(filter *models.FilterBooking) ([]models.Booking, error)
@@ -28,7 +33,23 @@ func NewBookingRepository(db *gorm.DB, logger *slog.Logger) BookingRepository {
 
 func (r *bookingRepository) CreateBooking(req *models.Booking) error {
 	r.logger.Debug("creating booking", "user_id", req.UserID, "place_id", req.PlaceID, "start", req.StartTime, "end", req.EndTime)
-	if err := r.db.Create(req).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// FOR SHARE не дает отключить место, пока бронь не записана (см. DeactivatePlace)
+		var place models.Place
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Select("id", "is_active").
+			Where("id = ?", req.PlaceID).Take(&place).Error; err != nil {
+			return err
+		}
+		if !place.IsActive {
+			return ErrPlaceUnavailable
+		}
+
+		if err := tx.Create(req).Error; err != nil {
+			return err
+		}
+		return AddOutboxEvent(tx, models.AggregateBooking, req.ID, models.EventBookingCreated, models.NewBookingEvent(req, ""))
+	})
+	if err != nil {
 		r.logger.Debug("failed to create booking", "error", err)
 		r.logger.Error("CreateBooking failed", "error", err, "user_id", req.UserID, "place_id", req.PlaceID)
 		return err
@@ -78,19 +99,28 @@ func (r *bookingRepository) UpdateBooking(req *models.Booking) error {
 func (r *bookingRepository) Delete(id uint) error {
 	r.logger.Debug("deleting booking", "id", id)
 
-	res := r.db.Delete(&models.Booking{}, id)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var booking models.Booking
+		if err := tx.First(&booking, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&booking).Error; err != nil {
+			return err
+		}
+		return AddOutboxEvent(tx, models.AggregateBooking, id, models.EventBookingDeleted, models.NewBookingEvent(&booking, ""))
+	})
 
-	if res.RowsAffected == 0 {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		r.logger.Info("no booking deleted", "id", id)
-		return gorm.ErrRecordNotFound
+		return err
 	}
 
-	if res.Error != nil {
-		r.logger.Debug("delete failed", "error", res.Error)
-		r.logger.Error("failed to delete record", "id", id, "error", res.Error)
-		return res.Error
+	if err != nil {
+		r.logger.Debug("delete failed", "error", err)
+		r.logger.Error("failed to delete record", "id", id, "error", err)
+		return err
 	}
-	r.logger.Info("booking deleted", "id", id, "rows_affected", res.RowsAffected)
+	r.logger.Info("booking deleted", "id", id)
 	return nil
 }
 
@@ -129,6 +159,19 @@ func (r *bookingRepository) ListBooking(filter *models.FilterBooking) ([]models.
 		query = query.Where("status = ?", *filter.Status)
 	}
 
+	if filter.PlaceID != nil {
+		query = query.Where("bookings.place_id = ?", *filter.PlaceID)
+	}
+	if filter.LocationID != nil || filter.FloorID != nil || filter.ZoneID != nil {
+		places := r.db.Model(&models.Place{}).Select("places.id")
+		places = applyPlaceLocation(places, &models.FilterPlace{
+			LocationID: filter.LocationID,
+			FloorID:    filter.FloorID,
+			ZoneID:     filter.ZoneID,
+		})
+		query = query.Where("bookings.place_id IN (?)", places)
+	}
+
 	if filter.PriceMin != nil {
 		query = query.Where("total_price >= ?", *filter.PriceMin)
 	}
@@ -181,16 +224,36 @@ func (r *bookingRepository) ListBooking(filter *models.FilterBooking) ([]models.
 	return bookings, nil
 }
 
+// UpdateBook сохраняет изменения брони и в той же транзакции пишет событие
+// с прежними местом и временем, чтобы подписчики освободили старый слот
 func (r *bookingRepository) UpdateBook(id uint, req *models.Booking) error {
-	result := r.db.Model(models.Booking{}).Where("id = ?", id).Updates(req)
-	if result.Error != nil {
-		r.logger.Error("failed to update booking", "error", result.Error)
-		return result.Error
-	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var old models.Booking
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&old, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(models.Booking{}).Where("id = ?", id).Omit(clause.Associations).Updates(req).Error; err != nil {
+			return err
+		}
 
-	if result.RowsAffected == 0 {
-		r.logger.Info("booking not found", "id", req.ID)
-		return gorm.ErrRecordNotFound
+		var updated models.Booking
+		if err := tx.First(&updated, id).Error; err != nil {
+			return err
+		}
+		return AddOutboxEvent(tx, models.AggregateBooking, id, models.EventBookingUpdated, models.BookingUpdatedEvent{
+			BookingEvent:  models.NewBookingEvent(&updated, ""),
+			FromPlaceID:   old.PlaceID,
+			FromStartTime: old.StartTime,
+			FromEndTime:   old.EndTime,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.logger.Info("booking not found", "id", id)
+		} else {
+			r.logger.Error("failed to update booking", "error", err)
+		}
+		return err
 	}
 	return nil
 }
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"gorm.io/gorm"
+)
+
+type FloorPlanRepository interface {
+	// SavePlan заменяет подложку этажа, если она уже загружена
+	SavePlan(plan *models.FloorPlan) error
+	GetPlan(floorID uint) (*models.FloorPlan, error)
+	// GetPlanMeta возвращает план без самого изображения
+	GetPlanMeta(floorID uint) (*models.FloorPlan, error)
+
+	ListFloorPlaces(floorID uint) ([]models.Place, error)
+	BusyPlaceIDs(placeIDs []uint, start, end time.Time) ([]uint, error)
+	MaintenancePlaceIDs(floorID uint, start, end time.Time) ([]uint, error)
+	UpdatePlacePosition(placeID uint, x, y *float64, polygon models.JSONText) error
+}
+
+type floorPlanRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewFloorPlanRepository(db *gorm.DB, logger *slog.Logger) FloorPlanRepository {
+	return &floorPlanRepository{db: db, logger: logger}
+}
+
+func (r *floorPlanRepository) SavePlan(plan *models.FloorPlan) error {
+	existing, err := r.GetPlanMeta(plan.FloorID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		err = r.db.Create(plan).Error
+	case err == nil:
+		plan.ID = existing.ID
+		err = r.db.Model(plan).Select("width", "height", "content_type", "image").Updates(plan).Error
+	}
+	if err != nil {
+		r.logger.Error("SavePlan failed", "floor_id", plan.FloorID, "error", err)
+		return err
+	}
+
+	r.logger.Info("floor plan saved", "floor_id", plan.FloorID, "size", len(plan.Image))
+	return nil
+}
+
+func (r *floorPlanRepository) GetPlan(floorID uint) (*models.FloorPlan, error) {
+	var plan models.FloorPlan
+	if err := r.db.Where("floor_id = ?", floorID).First(&plan).Error; err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (r *floorPlanRepository) GetPlanMeta(floorID uint) (*models.FloorPlan, error) {
+	var plan models.FloorPlan
+	if err := r.db.Omit("image").Where("floor_id = ?", floorID).First(&plan).Error; err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (r *floorPlanRepository) ListFloorPlaces(floorID uint) ([]models.Place, error) {
+	var places []models.Place
+	if err := r.db.Where("floor_id = ?", floorID).Order("id").Find(&places).Error; err != nil {
+		r.logger.Error("ListFloorPlaces failed", "floor_id", floorID, "error", err)
+		return nil, err
+	}
+	return places, nil
+}
+
+// BusyPlaceIDs возвращает места, у которых есть активная бронь, пересекающая интервал
+func (r *floorPlanRepository) BusyPlaceIDs(placeIDs []uint, start, end time.Time) ([]uint, error) {
+	if len(placeIDs) == 0 {
+		return nil, nil
+	}
+
+	var ids []uint
+	err := r.db.Model(&models.Booking{}).
+		Distinct("place_id").
+		Where("place_id IN ? AND status = ?", placeIDs, models.BookingActive).
+		Where("start_time < ? AND end_time > ?", end, start).
+		Pluck("place_id", &ids).Error
+	if err != nil {
+		r.logger.Error("BusyPlaceIDs failed", "error", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// MaintenancePlaceIDs возвращает места этажа, закрытые на обслуживание в интервале
+func (r *floorPlanRepository) MaintenancePlaceIDs(floorID uint, start, end time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.Place{}).
+		Where("floor_id = ?", floorID).
+		Where("EXISTS (?)", maintenanceBlocks(r.db, start, end)).
+		Pluck("id", &ids).Error
+	if err != nil {
+		r.logger.Error("MaintenancePlaceIDs failed", "floor_id", floorID, "error", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *floorPlanRepository) UpdatePlacePosition(placeID uint, x, y *float64, polygon models.JSONText) error {
+	err := r.db.Model(&models.Place{}).Where("id = ?", placeID).Updates(map[string]any{
+		"map_x":       x,
+		"map_y":       y,
+		"map_polygon": polygon,
+	}).Error
+	if err != nil {
+		r.logger.Error("UpdatePlacePosition failed", "place_id", placeID, "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"gorm.io/gorm"
+)
+
+type InvoiceRepository interface {
+	CreateInvoice(inv *models.Invoice) error
+	GetInvoice(id uint) (*models.Invoice, error)
+	ListInvoices(filter *models.FilterInvoice) ([]models.Invoice, int64, error)
+	UpdateInvoice(inv *models.Invoice) error
+	// HasInvoice проверяет, есть ли у пользователя счет за период; includeVoid учитывает и аннулированные
+	HasInvoice(userID uint, periodStart time.Time, includeVoid bool) (bool, error)
+	HasOrganizationInvoice(orgID uint, periodStart time.Time, includeVoid bool) (bool, error)
+	// IssueInvoice присваивает черновику номер и выставляет его
+	IssueInvoice(inv *models.Invoice) error
+
+	// Постоплата
+	IsPostpaid(inv *models.Invoice) (bool, error)
+	SettleInvoice(inv *models.Invoice) error
+	MarkOverdue(now time.Time) (int64, error)
+	CountOverdue(userID uint, orgID *uint) (int64, error)
+
+	// Источники строк счета
+	ListBillableUsers(start, end time.Time) ([]uint, error)
+	ListBillableOrganizations(start, end time.Time) ([]uint, error)
+	ListBillableEntries(account string, start, end time.Time) ([]models.LedgerEntry, error)
+	ListCoveredBookings(userID uint, start, end time.Time) ([]models.Booking, error)
+	GetBookingsByIDs(ids []uint) (map[uint]models.Booking, error)
+}
+
+var billableEntryTypes = []models.LedgerEntryType{models.LedgerBookingCharge, models.LedgerFee, models.LedgerRefund}
+
+type invoiceRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewInvoiceRepository(db *gorm.DB, logger *slog.Logger) InvoiceRepository {
+	return &invoiceRepository{db: db, logger: logger}
+}
+
+func (r *invoiceRepository) CreateInvoice(inv *models.Invoice) error {
+	if err := r.db.Create(inv).Error; err != nil {
+		r.logger.Error("CreateInvoice failed", "user_id", inv.UserID, "organization_id", inv.OrganizationID, "error", err)
+		return err
+	}
+	r.logger.Info("invoice created", "invoice_id", inv.ID, "user_id", inv.UserID, "organization_id", inv.OrganizationID, "total", inv.Total)
+	return nil
+}
+
+func (r *invoiceRepository) GetInvoice(id uint) (*models.Invoice, error) {
+	var inv models.Invoice
+	err := r.db.
+		Preload("User").
+		Preload("Organization").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("date, id") }).
+		First(&inv, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func (r *invoiceRepository) ListInvoices(filter *models.FilterInvoice) ([]models.Invoice, int64, error) {
+	q := r.db.Model(&models.Invoice{})
+
+	if filter.UserID != nil {
+		q = q.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.OrganizationID != nil {
+		q = q.Where("organization_id = ?", *filter.OrganizationID)
+	}
+	if filter.Status != nil {
+		q = q.Where("status = ?", *filter.Status)
+	}
+	if filter.ExcludeDrafts {
+		q = q.Where("status <> ?", models.InvoiceDraft)
+	}
+	if filter.Year > 0 {
+		from := time.Date(filter.Year, time.January, 1, 0, 0, 0, 0, time.Local)
+		to := from.AddDate(1, 0, 0)
+		if filter.Month > 0 {
+			from = time.Date(filter.Year, time.Month(filter.Month), 1, 0, 0, 0, 0, time.Local)
+			to = from.AddDate(0, 1, 0)
+		}
+		q = q.Where("period_start >= ? AND period_start < ?", from, to)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		r.logger.Error("ListInvoices count failed", "error", err)
+		return nil, 0, err
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	var invoices []models.Invoice
+	if err := q.Order("period_start desc, id desc").Limit(limit).Offset(max(filter.Offset, 0)).Find(&invoices).Error; err != nil {
+		r.logger.Error("ListInvoices failed", "error", err)
+		return nil, 0, err
+	}
+	return invoices, total, nil
+}
+
+func (r *invoiceRepository) UpdateInvoice(inv *models.Invoice) error {
+	err := r.db.Model(inv).Select("number", "status", "due_at", "issued_at", "paid_at", "voided_at", "void_reason").Updates(inv).Error
+	if err != nil {
+		r.logger.Error("UpdateInvoice failed", "invoice_id", inv.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *invoiceRepository) HasInvoice(userID uint, periodStart time.Time, includeVoid bool) (bool, error) {
+	q := r.db.Model(&models.Invoice{}).Where("user_id = ? AND period_start = ?", userID, periodStart)
+	if !includeVoid {
+		q = q.Where("status <> ?", models.InvoiceVoid)
+	}
+
+	var count int64
+	if err := q.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *invoiceRepository) HasOrganizationInvoice(orgID uint, periodStart time.Time, includeVoid bool) (bool, error) {
+	q := r.db.Model(&models.Invoice{}).Where("organization_id = ? AND period_start = ?", orgID, periodStart)
+	if !includeVoid {
+		q = q.Where("status <> ?", models.InvoiceVoid)
+	}
+
+	var count int64
+	if err := q.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// IssueInvoice берет номер из последовательности invoice_number_seq (см. config.RunSQLMigrations)
+// и в той же транзакции переводит черновик в выставленные
+func (r *invoiceRepository) IssueInvoice(inv *models.Invoice) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var seq int64
+		if err := tx.Raw("SELECT nextval('invoice_number_seq')").Scan(&seq).Error; err != nil {
+			return err
+		}
+		number := fmt.Sprintf("INV-%d-%06d", inv.PeriodStart.Year(), seq)
+
+		// Условие на статус не дает выставить один черновик дважды под разными номерами
+		res := tx.Model(&models.Invoice{}).
+			Where("id = ? AND status = ?", inv.ID, models.InvoiceDraft).
+			Updates(map[string]any{"number": number, "status": inv.Status, "issued_at": inv.IssuedAt, "due_at": inv.DueAt})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("выставить можно только черновик")
+		}
+		inv.Number = &number
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("IssueInvoice failed", "invoice_id", inv.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// IsPostpaid проверяет, работает ли владелец счета в постоплате
+func (r *invoiceRepository) IsPostpaid(inv *models.Invoice) (bool, error) {
+	var postpaid bool
+	q := r.db.Model(&models.User{}).Where("id = ?", inv.UserID)
+	if inv.OrganizationID != nil {
+		q = r.db.Model(&models.Organization{}).Where("id = ?", *inv.OrganizationID)
+	}
+	if err := q.Select("postpaid").Scan(&postpaid).Error; err != nil {
+		r.logger.Error("IsPostpaid failed", "invoice_id", inv.ID, "error", err)
+		return false, err
+	}
+	return postpaid, nil
+}
+
+// SettleInvoice отмечает счет постоплаты оплаченным и в той же транзакции
+// зачисляет сумму на баланс владельца, погашая долг
+func (r *invoiceRepository) SettleInvoice(inv *models.Invoice) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Условие на статус не дает зачислить оплату одного счета дважды
+		res := tx.Model(&models.Invoice{}).
+			Where("id = ? AND status IN ?", inv.ID, []models.InvoiceStatus{models.InvoiceIssued, models.InvoiceOverdue}).
+			Updates(map[string]any{"status": inv.Status, "paid_at": inv.PaidAt})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("счет уже оплачен или аннулирован")
+		}
+
+		posting := models.LedgerPosting{
+			OrganizationID: inv.OrganizationID,
+			Type:           models.LedgerSettlement,
+			Amount:         inv.Total,
+			Reason:         "invoice_settlement",
+		}
+		if inv.UserID != nil {
+			posting.UserID = *inv.UserID
+		}
+		_, err := PostLedger(tx, posting)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("SettleInvoice failed", "invoice_id", inv.ID, "error", err)
+		return err
+	}
+	r.logger.Info("invoice settled", "invoice_id", inv.ID, "amount", inv.Total)
+	return nil
+}
+
+func (r *invoiceRepository) MarkOverdue(now time.Time) (int64, error) {
+	res := r.db.Model(&models.Invoice{}).
+		Where("status = ? AND postpaid AND due_at < ?", models.InvoiceIssued, now).
+		Update("status", models.InvoiceOverdue)
+	if res.Error != nil {
+		r.logger.Error("MarkOverdue failed", "error", res.Error)
+		return 0, res.Error
+	}
+	return res.RowsAffected, nil
+}
+
+// CountOverdue считает просроченные счета пользователя и организации, из кошелька которой он платит
+func (r *invoiceRepository) CountOverdue(userID uint, orgID *uint) (int64, error) {
+	q := r.db.Model(&models.Invoice{}).Where("status = ?", models.InvoiceOverdue)
+	if orgID != nil {
+		q = q.Where("user_id = ? OR organization_id = ?", userID, *orgID)
+	} else {
+		q = q.Where("user_id = ?", userID)
+	}
+
+	var count int64
+	if err := q.Count(&count).Error; err != nil {
+		r.logger.Error("CountOverdue failed", "user_id", userID, "error", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *invoiceRepository) ListBillableUsers(start, end time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Raw(`
+		SELECT user_id FROM ledger_entries
+		WHERE account = 'user:' || user_id AND type IN ? AND created_at >= ? AND created_at < ?
+		UNION
+		SELECT user_id FROM bookings
+		WHERE deleted_at IS NULL AND status = ? AND included_hours > 0 AND end_time >= ? AND end_time < ?
+		ORDER BY user_id`,
+		billableEntryTypes, start, end,
+		models.BookingActive, start, end,
+	).Scan(&ids).Error
+	if err != nil {
+		r.logger.Error("ListBillableUsers failed", "error", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *invoiceRepository) ListBillableOrganizations(start, end time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.LedgerEntry{}).
+		Distinct("organization_id").
+		Where("account = 'org:' || organization_id AND type IN ? AND created_at >= ? AND created_at < ?", billableEntryTypes, start, end).
+		Order("organization_id").
+		Pluck("organization_id", &ids).Error
+	if err != nil {
+		r.logger.Error("ListBillableOrganizations failed", "error", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ListBillableEntries — проводки счета account (models.UserAccount или models.OrganizationAccount) за период
+func (r *invoiceRepository) ListBillableEntries(account string, start, end time.Time) ([]models.LedgerEntry, error) {
+	var entries []models.LedgerEntry
+	err := r.db.
+		Where("account = ? AND type IN ? AND created_at >= ? AND created_at < ?", account, billableEntryTypes, start, end).
+		Order("id").
+		Find(&entries).Error
+	if err != nil {
+		r.logger.Error("ListBillableEntries failed", "account", account, "error", err)
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ListCoveredBookings — завершенные за период брони, целиком оплаченные часами абонемента:
+// по ним нет проводок, но в счете они должны быть видны
+func (r *invoiceRepository) ListCoveredBookings(userID uint, start, end time.Time) ([]models.Booking, error) {
+	var bookings []models.Booking
+	err := r.db.Preload("Place").
+		Where("user_id = ? AND status = ? AND included_hours > 0 AND charged_amount = 0 AND end_time >= ? AND end_time < ?",
+			userID, models.BookingActive, start, end).
+		Order("start_time").
+		Find(&bookings).Error
+	if err != nil {
+		r.logger.Error("ListCoveredBookings failed", "user_id", userID, "error", err)
+		return nil, err
+	}
+	return bookings, nil
+}
+
+func (r *invoiceRepository) GetBookingsByIDs(ids []uint) (map[uint]models.Booking, error) {
+	result := make(map[uint]models.Booking, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var bookings []models.Booking
+	if err := r.db.Unscoped().Preload("Place").Preload("User").Where("id IN ?", ids).Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	for _, b := range bookings {
+		result[b.ID] = b
+	}
+	return result, nil
+}
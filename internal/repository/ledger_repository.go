@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientFunds   = errors.New("недостаточно средств")
+	ErrCreditLimitExceeded = errors.New("превышен кредитный лимит")
+)
+
+// PostLedger — единственный способ изменить User.Balance или Organization.Balance.
+// Вызывать внутри транзакции бизнес-операции: строка кошелька блокируется,
+// баланс меняется, в журнал пишется пара проводок и событие balance.changed в outbox.
+func PostLedger(tx *gorm.DB, p models.LedgerPosting) (*models.LedgerEntry, error) {
+	if p.Amount == 0 {
+		return nil, nil
+	}
+
+	var (
+		wallet        any = &models.User{}
+		walletID          = p.UserID
+		aggregateType     = models.AggregateUser
+	)
+	if p.OrganizationID != nil {
+		wallet, walletID, aggregateType = &models.Organization{}, *p.OrganizationID, models.AggregateOrganization
+	}
+
+	var state struct {
+		Balance     int
+		Postpaid    bool
+		CreditLimit int
+	}
+	if err := tx.Model(wallet).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", walletID).Select("balance", "postpaid", "credit_limit").Take(&state).Error; err != nil {
+		return nil, err
+	}
+
+	balanceAfter, err := ledgerBalanceAfter(state.Balance, state.Postpaid, state.CreditLimit, p)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(wallet).Where("id = ?", walletID).
+		Update("balance", gorm.Expr("balance + ?", p.Amount)).Error; err != nil {
+		return nil, err
+	}
+
+	entry, err := insertLedgerPair(tx, p, balanceAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := p.Reason
+	if reason == "" {
+		reason = string(p.Type)
+	}
+	if err := AddOutboxEvent(tx, aggregateType, walletID, models.EventBalanceChanged, models.BalanceEvent{
+		UserID:         p.UserID,
+		Amount:         p.Amount,
+		Reason:         reason,
+		BookingID:      p.BookingID,
+		OrganizationID: p.OrganizationID,
+	}); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// ledgerBalanceAfter считает баланс после проводки. В постоплате списания
+// уводят баланс в минус, но не глубже кредитного лимита.
+func ledgerBalanceAfter(balance int, postpaid bool, creditLimit int, p models.LedgerPosting) (int, error) {
+	balanceAfter := balance + p.Amount
+	if p.Amount < 0 && !p.AllowNegative {
+		if !postpaid && balanceAfter < 0 {
+			return 0, ErrInsufficientFunds
+		}
+		if postpaid && balanceAfter < -creditLimit {
+			return 0, ErrCreditLimitExceeded
+		}
+	}
+	return balanceAfter, nil
+}
+
+func insertLedgerPair(tx *gorm.DB, p models.LedgerPosting, balanceAfter int) (*models.LedgerEntry, error) {
+	txID, err := newLedgerTransactionID()
+	if err != nil {
+		return nil, err
+	}
+
+	var userID *uint
+	if p.UserID != 0 {
+		id := p.UserID
+		userID = &id
+	}
+	account := models.UserAccount(p.UserID)
+	if p.OrganizationID != nil {
+		account = models.OrganizationAccount(*p.OrganizationID)
+	}
+
+	entries := []models.LedgerEntry{
+		{
+			TransactionID:  txID,
+			Account:        account,
+			UserID:         userID,
+			OrganizationID: p.OrganizationID,
+			Type:           p.Type,
+			Amount:         p.Amount,
+			BalanceAfter:   &balanceAfter,
+			Reason:         p.Reason,
+			BookingID:      p.BookingID,
+			AdminID:        p.AdminID,
+			PaymentID:      p.PaymentID,
+		},
+		{
+			TransactionID:  txID,
+			Account:        counterAccount(p.Type),
+			OrganizationID: p.OrganizationID,
+			Type:           p.Type,
+			Amount:         -p.Amount,
+			Reason:         p.Reason,
+			BookingID:      p.BookingID,
+			AdminID:        p.AdminID,
+			PaymentID:      p.PaymentID,
+		},
+	}
+	if err := tx.Create(&entries).Error; err != nil {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+func counterAccount(t models.LedgerEntryType) string {
+	switch t {
+	case models.LedgerTopup, models.LedgerTopupRefund, models.LedgerSettlement:
+		return models.AccountCash
+	case models.LedgerAdjustment:
+		return models.AccountAdjustments
+	default:
+		return models.AccountRevenue
+	}
+}
+
+func newLedgerTransactionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type LedgerRepository interface {
+	ListUserEntries(userID uint, limit, offset int) ([]models.LedgerEntry, int64, error)
+
+	// Для сверки журнала с балансами
+	FindBalanceDrift() ([]models.BalanceDrift, error)
+	FindUnbalancedTransactions() ([]string, error)
+	PostOpeningBalance(userID uint) (*models.LedgerEntry, error)
+}
+
+type ledgerRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewLedgerRepository(db *gorm.DB, logger *slog.Logger) LedgerRepository {
+	return &ledgerRepository{db: db, logger: logger}
+}
+
+func (r *ledgerRepository) ListUserEntries(userID uint, limit, offset int) ([]models.LedgerEntry, int64, error) {
+	q := r.db.Model(&models.LedgerEntry{}).Where("account = ?", models.UserAccount(userID))
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		r.logger.Error("ListUserEntries count failed", "user_id", userID, "error", err)
+		return nil, 0, err
+	}
+
+	var entries []models.LedgerEntry
+	if err := q.Order("id desc").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		r.logger.Error("ListUserEntries failed", "user_id", userID, "error", err)
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+func (r *ledgerRepository) FindBalanceDrift() ([]models.BalanceDrift, error) {
+	var drift []models.BalanceDrift
+	err := r.db.Raw(`
+		SELECT 'user:' || u.id AS account,
+		       u.id AS user_id,
+		       u.balance AS balance,
+		       COALESCE(SUM(l.amount), 0) AS ledger_balance,
+		       COUNT(l.id) AS entries
+		FROM users u
+		LEFT JOIN ledger_entries l ON l.account = 'user:' || u.id
+		WHERE u.deleted_at IS NULL
+		GROUP BY u.id, u.balance
+		HAVING u.balance <> COALESCE(SUM(l.amount), 0)
+		ORDER BY u.id`).Scan(&drift).Error
+	if err != nil {
+		r.logger.Error("FindBalanceDrift failed", "error", err)
+		return nil, err
+	}
+
+	var orgDrift []models.BalanceDrift
+	err = r.db.Raw(`
+		SELECT 'org:' || o.id AS account,
+		       o.balance AS balance,
+		       COALESCE(SUM(l.amount), 0) AS ledger_balance,
+		       COUNT(l.id) AS entries
+		FROM organizations o
+		LEFT JOIN ledger_entries l ON l.account = 'org:' || o.id
+		WHERE o.deleted_at IS NULL
+		GROUP BY o.id, o.balance
+		HAVING o.balance <> COALESCE(SUM(l.amount), 0)
+		ORDER BY o.id`).Scan(&orgDrift).Error
+	if err != nil {
+		r.logger.Error("FindBalanceDrift failed", "error", err)
+		return nil, err
+	}
+
+	return append(drift, orgDrift...), nil
+}
+
+func (r *ledgerRepository) FindUnbalancedTransactions() ([]string, error) {
+	var ids []string
+	err := r.db.Model(&models.LedgerEntry{}).
+		Select("transaction_id").
+		Group("transaction_id").
+		Having("SUM(amount) <> 0").
+		Pluck("transaction_id", &ids).Error
+	if err != nil {
+		r.logger.Error("FindUnbalancedTransactions failed", "error", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// PostOpeningBalance переносит в журнал баланс, накопленный до его появления.
+// Баланс пользователя не меняется: проводка лишь догоняет проекцию.
+func (r *ledgerRepository) PostOpeningBalance(userID uint) (*models.LedgerEntry, error) {
+	var entry *models.LedgerEntry
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "balance").First(&user, userID).Error; err != nil {
+			return err
+		}
+
+		var sum int
+		if err := tx.Model(&models.LedgerEntry{}).
+			Where("account = ?", models.UserAccount(userID)).
+			Select("COALESCE(SUM(amount), 0)").Scan(&sum).Error; err != nil {
+			return err
+		}
+		if user.Balance == sum {
+			return nil
+		}
+
+		var err error
+		entry, err = insertLedgerPair(tx, models.LedgerPosting{
+			UserID: userID,
+			Type:   models.LedgerAdjustment,
+			Amount: user.Balance - sum,
+			Reason: "opening_balance",
+		}, user.Balance)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("PostOpeningBalance failed", "user_id", userID, "error", err)
+		return nil, err
+	}
+	return entry, nil
+}
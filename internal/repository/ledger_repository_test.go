@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+)
+
+func TestPostLedgerZeroAmount(t *testing.T) {
+	// Нулевая проводка ничего не меняет и не обращается к транзакции
+	entry, err := PostLedger(nil, models.LedgerPosting{UserID: 1, Type: models.LedgerBookingCharge})
+	if err != nil || entry != nil {
+		t.Fatalf("PostLedger = %v, %v, ожидалось nil, nil", entry, err)
+	}
+}
+
+func TestLedgerBalanceAfter(t *testing.T) {
+	tests := []struct {
+		name        string
+		balance     int
+		postpaid    bool
+		creditLimit int
+		amount      int
+		allowNeg    bool
+		want        int
+		wantErr     error
+	}{
+		{name: "пополнение", balance: 100, amount: 50, want: 150},
+		{name: "списание в пределах баланса", balance: 100, amount: -100, want: 0},
+		{name: "списание больше баланса", balance: 100, amount: -101, wantErr: ErrInsufficientFunds},
+		{name: "списание с разрешенным минусом", balance: 100, amount: -300, allowNeg: true, want: -200},
+		{name: "пополнение при долге", balance: -500, amount: 100, want: -400},
+		{name: "постоплата в пределах лимита", balance: 0, postpaid: true, creditLimit: 1000, amount: -1000, want: -1000},
+		{name: "постоплата сверх лимита", balance: -900, postpaid: true, creditLimit: 1000, amount: -101, wantErr: ErrCreditLimitExceeded},
+		{name: "постоплата без лимита", balance: 0, postpaid: true, amount: -1, wantErr: ErrCreditLimitExceeded},
+		{name: "постоплата сверх лимита с разрешенным минусом", balance: -900, postpaid: true, creditLimit: 1000, amount: -500, allowNeg: true, want: -1400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := models.LedgerPosting{UserID: 1, Amount: tt.amount, AllowNegative: tt.allowNeg}
+			got, err := ledgerBalanceAfter(tt.balance, tt.postpaid, tt.creditLimit, p)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ошибка %v, ожидалась %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("баланс %d, ожидался %d", got, tt.want)
+			}
+		})
+	}
+}
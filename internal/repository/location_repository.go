@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"log/slog"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"gorm.io/gorm"
+)
+
+type LocationRepository interface {
+	CreateLocation(loc *models.Location) error
+	GetLocation(id uint) (*models.Location, error)
+	// ListLocations с ids == nil возвращает все площадки
+	ListLocations(ids []uint) ([]models.Location, error)
+	UpdateLocation(loc *models.Location) error
+	DeleteLocation(id uint) error
+
+	CreateFloor(floor *models.Floor) error
+	GetFloor(id uint) (*models.Floor, error)
+	UpdateFloor(floor *models.Floor) error
+	DeleteFloor(id uint) error
+
+	CreateZone(zone *models.Zone) error
+	GetZone(id uint) (*models.Zone, error)
+	UpdateZone(zone *models.Zone) error
+	DeleteZone(id uint) error
+
+	// CountPlaces считает места, размещенные в площадке, этаже или зоне
+	CountPlaces(column string, id uint) (int64, error)
+	CountFloors(locationID uint) (int64, error)
+	CountZones(floorID uint) (int64, error)
+	CountAdmins(locationID uint) (int64, error)
+
+	IsAdminUnrestricted(adminID uint) (bool, error)
+	ListAdminLocationIDs(adminID uint) ([]uint, error)
+	// SetAdminLocations задает флаг доступа ко всем площадкам и заменяет
+	// список закрепленных площадок
+	SetAdminLocations(adminID uint, unrestricted bool, locationIDs []uint) error
+}
+
+type locationRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewLocationRepository(db *gorm.DB, logger *slog.Logger) LocationRepository {
+	return &locationRepository{db: db, logger: logger}
+}
+
+func (r *locationRepository) CreateLocation(loc *models.Location) error {
+	if err := r.db.Create(loc).Error; err != nil {
+		r.logger.Error("CreateLocation failed", "error", err)
+		return err
+	}
+	r.logger.Info("location created", "location_id", loc.ID)
+	return nil
+}
+
+func (r *locationRepository) GetLocation(id uint) (*models.Location, error) {
+	var loc models.Location
+	err := r.db.
+		Preload("Floors", func(db *gorm.DB) *gorm.DB { return db.Order("level, id") }).
+		Preload("Floors.Zones", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(&loc, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &loc, nil
+}
+
+func (r *locationRepository) ListLocations(ids []uint) ([]models.Location, error) {
+	query := r.db.
+		Preload("Floors", func(db *gorm.DB) *gorm.DB { return db.Order("level, id") }).
+		Preload("Floors.Zones", func(db *gorm.DB) *gorm.DB { return db.Order("id") })
+	if ids != nil {
+		query = query.Where("id IN ?", ids)
+	}
+
+	var locations []models.Location
+	if err := query.Order("id").Find(&locations).Error; err != nil {
+		r.logger.Error("ListLocations failed", "error", err)
+		return nil, err
+	}
+	return locations, nil
+}
+
+func (r *locationRepository) UpdateLocation(loc *models.Location) error {
+	err := r.db.Model(loc).Select("name", "address", "timezone", "latitude", "longitude").Updates(loc).Error
+	if err != nil {
+		r.logger.Error("UpdateLocation failed", "location_id", loc.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteLocation не трогает закрепления администраторов: площадку с
+// закрепленными администраторами сервис удалять не дает
+func (r *locationRepository) DeleteLocation(id uint) error {
+	if err := r.db.Delete(&models.Location{}, id).Error; err != nil {
+		r.logger.Error("DeleteLocation failed", "location_id", id, "error", err)
+		return err
+	}
+	r.logger.Info("location deleted", "location_id", id)
+	return nil
+}
+
+func (r *locationRepository) CreateFloor(floor *models.Floor) error {
+	if err := r.db.Create(floor).Error; err != nil {
+		r.logger.Error("CreateFloor failed", "location_id", floor.LocationID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *locationRepository) GetFloor(id uint) (*models.Floor, error) {
+	var floor models.Floor
+	if err := r.db.Preload("Zones").First(&floor, id).Error; err != nil {
+		return nil, err
+	}
+	return &floor, nil
+}
+
+func (r *locationRepository) UpdateFloor(floor *models.Floor) error {
+	if err := r.db.Model(floor).Select("name", "level").Updates(floor).Error; err != nil {
+		r.logger.Error("UpdateFloor failed", "floor_id", floor.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *locationRepository) DeleteFloor(id uint) error {
+	if err := r.db.Delete(&models.Floor{}, id).Error; err != nil {
+		r.logger.Error("DeleteFloor failed", "floor_id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *locationRepository) CreateZone(zone *models.Zone) error {
+	if err := r.db.Create(zone).Error; err != nil {
+		r.logger.Error("CreateZone failed", "floor_id", zone.FloorID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *locationRepository) GetZone(id uint) (*models.Zone, error) {
+	var zone models.Zone
+	if err := r.db.First(&zone, id).Error; err != nil {
+		return nil, err
+	}
+	return &zone, nil
+}
+
+func (r *locationRepository) UpdateZone(zone *models.Zone) error {
+	if err := r.db.Model(zone).Select("name", "description").Updates(zone).Error; err != nil {
+		r.logger.Error("UpdateZone failed", "zone_id", zone.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *locationRepository) DeleteZone(id uint) error {
+	if err := r.db.Delete(&models.Zone{}, id).Error; err != nil {
+		r.logger.Error("DeleteZone failed", "zone_id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *locationRepository) CountPlaces(column string, id uint) (int64, error) {
+	allowed := map[string]bool{"location_id": true, "floor_id": true, "zone_id": true}
+	if !allowed[column] {
+		column = "location_id"
+	}
+
+	var count int64
+	err := r.db.Model(&models.Place{}).Where(column+" = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r *locationRepository) CountFloors(locationID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Floor{}).Where("location_id = ?", locationID).Count(&count).Error
+	return count, err
+}
+
+func (r *locationRepository) CountZones(floorID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Zone{}).Where("floor_id = ?", floorID).Count(&count).Error
+	return count, err
+}
+
+func (r *locationRepository) CountAdmins(locationID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.AdminLocation{}).Where("location_id = ?", locationID).Count(&count).Error
+	return count, err
+}
+
+func (r *locationRepository) IsAdminUnrestricted(adminID uint) (bool, error) {
+	var admin models.Admin
+	if err := r.db.Select("id", "unrestricted").First(&admin, adminID).Error; err != nil {
+		r.logger.Error("IsAdminUnrestricted failed", "admin_id", adminID, "error", err)
+		return false, err
+	}
+	return admin.Unrestricted, nil
+}
+
+func (r *locationRepository) ListAdminLocationIDs(adminID uint) ([]uint, error) {
+	ids := []uint{}
+	err := r.db.Model(&models.AdminLocation{}).Where("admin_id = ?", adminID).Order("location_id").Pluck("location_id", &ids).Error
+	if err != nil {
+		r.logger.Error("ListAdminLocationIDs failed", "admin_id", adminID, "error", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *locationRepository) SetAdminLocations(adminID uint, unrestricted bool, locationIDs []uint) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Admin{}).Where("id = ?", adminID).Update("unrestricted", unrestricted)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Where("admin_id = ?", adminID).Delete(&models.AdminLocation{}).Error; err != nil {
+			return err
+		}
+		if len(locationIDs) == 0 {
+			return nil
+		}
+
+		rows := make([]models.AdminLocation, 0, len(locationIDs))
+		for _, id := range locationIDs {
+			rows = append(rows, models.AdminLocation{AdminID: adminID, LocationID: id})
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		r.logger.Error("SetAdminLocations failed", "admin_id", adminID, "error", err)
+		return err
+	}
+	r.logger.Info("admin locations updated", "admin_id", adminID, "unrestricted", unrestricted, "locations", locationIDs)
+	return nil
+}
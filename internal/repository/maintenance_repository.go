@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"gorm.io/gorm"
+)
+
+type MaintenanceRepository interface {
+	CreateWindow(w *models.MaintenanceWindow) error
+	GetWindow(id uint) (*models.MaintenanceWindow, error)
+	ListWindows(filter *models.FilterMaintenance) ([]models.MaintenanceWindow, int64, error)
+	UpdateWindow(w *models.MaintenanceWindow) error
+	DeleteWindow(id uint) error
+
+	// Overlapping возвращает окна места и его зоны, пересекающие интервал
+	Overlapping(placeID uint, start, end time.Time) ([]models.MaintenanceWindow, error)
+	// AffectedBookings — еще не закончившиеся брони, попадающие в окно
+	AffectedBookings(w *models.MaintenanceWindow, now time.Time) ([]models.Booking, error)
+}
+
+type maintenanceRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewMaintenanceRepository(db *gorm.DB, logger *slog.Logger) MaintenanceRepository {
+	return &maintenanceRepository{db: db, logger: logger}
+}
+
+func (r *maintenanceRepository) CreateWindow(w *models.MaintenanceWindow) error {
+	if err := r.db.Create(w).Error; err != nil {
+		r.logger.Error("CreateWindow failed", "error", err)
+		return err
+	}
+	r.logger.Info("maintenance window created", "window_id", w.ID, "place_id", w.PlaceID, "zone_id", w.ZoneID)
+	return nil
+}
+
+func (r *maintenanceRepository) GetWindow(id uint) (*models.MaintenanceWindow, error) {
+	var w models.MaintenanceWindow
+	if err := r.db.First(&w, id).Error; err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *maintenanceRepository) ListWindows(filter *models.FilterMaintenance) ([]models.MaintenanceWindow, int64, error) {
+	query := r.db.Model(&models.MaintenanceWindow{})
+	if filter.PlaceID != nil {
+		query = query.Where("place_id = ?", *filter.PlaceID)
+	}
+	if filter.ZoneID != nil {
+		query = query.Where("zone_id = ?", *filter.ZoneID)
+	}
+	if filter.From != nil {
+		query = query.Where("end_time > ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("start_time < ?", *filter.To)
+	}
+	if filter.LocationIDs != nil {
+		query = query.Where(
+			r.db.Where("place_id IN (?)", r.db.Model(&models.Place{}).Select("id").Where("location_id IN ?", filter.LocationIDs)).
+				Or("zone_id IN (?)", r.db.Table("zones").Select("zones.id").
+					Joins("JOIN floors ON floors.id = zones.floor_id").
+					Where("floors.location_id IN ? AND zones.deleted_at IS NULL", filter.LocationIDs)),
+		)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		r.logger.Error("ListWindows count failed", "error", err)
+		return nil, 0, err
+	}
+
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	var windows []models.MaintenanceWindow
+	if err := query.Order("start_time, id").Limit(filter.Limit).Offset(filter.Offset).Find(&windows).Error; err != nil {
+		r.logger.Error("ListWindows failed", "error", err)
+		return nil, 0, err
+	}
+	return windows, total, nil
+}
+
+func (r *maintenanceRepository) UpdateWindow(w *models.MaintenanceWindow) error {
+	if err := r.db.Model(w).Select("start_time", "end_time", "reason").Updates(w).Error; err != nil {
+		r.logger.Error("UpdateWindow failed", "window_id", w.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *maintenanceRepository) DeleteWindow(id uint) error {
+	if err := r.db.Delete(&models.MaintenanceWindow{}, id).Error; err != nil {
+		r.logger.Error("DeleteWindow failed", "window_id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *maintenanceRepository) Overlapping(placeID uint, start, end time.Time) ([]models.MaintenanceWindow, error) {
+	var windows []models.MaintenanceWindow
+	err := r.db.
+		Where("place_id = ? OR zone_id = (SELECT zone_id FROM places WHERE id = ?)", placeID, placeID).
+		Where("start_time < ? AND end_time > ?", end, start).
+		Order("start_time").
+		Find(&windows).Error
+	if err != nil {
+		r.logger.Error("Overlapping maintenance failed", "place_id", placeID, "error", err)
+		return nil, err
+	}
+	return windows, nil
+}
+
+func (r *maintenanceRepository) AffectedBookings(w *models.MaintenanceWindow, now time.Time) ([]models.Booking, error) {
+	query := r.db.Preload("Place").
+		Where("status IN ?", []models.BookingStatus{models.BookingActive, models.BookingNonActive}).
+		Where("start_time < ? AND end_time > ?", w.EndTime, w.StartTime).
+		Where("end_time > ?", now)
+	if w.PlaceID != nil {
+		query = query.Where("place_id = ?", *w.PlaceID)
+	} else {
+		query = query.Where("place_id IN (?)", r.db.Model(&models.Place{}).Select("id").Where("zone_id = ?", w.ZoneID))
+	}
+
+	var bookings []models.Booking
+	if err := query.Order("start_time, id").Find(&bookings).Error; err != nil {
+		r.logger.Error("AffectedBookings failed", "window_id", w.ID, "error", err)
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// maintenanceBlocks — подзапрос для NOT EXISTS: окно обслуживания места или его
+// зоны, пересекающее интервал; нужен в запросах, где места выбираются как places
+func maintenanceBlocks(db *gorm.DB, start, end any) *gorm.DB {
+	return db.Table("maintenance_windows mw").Select("1").
+		Where("mw.deleted_at IS NULL").
+		Where("mw.place_id = places.id OR mw.zone_id = places.zone_id").
+		Where("mw.start_time < ? AND mw.end_time > ?", end, start)
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository interface {
+	GetPreferences(userID uint) ([]models.NotificationPreference, error)
+	UpsertPreference(pref *models.NotificationPreference) error
+	ListBookingsForReminder(from, to time.Time) ([]models.Booking, error)
+	ClaimReminder(bookingID uint) (bool, error)
+}
+
+type notificationRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewNotificationRepository(db *gorm.DB, logger *slog.Logger) NotificationRepository {
+	return &notificationRepository{db: db, logger: logger}
+}
+
+func (r *notificationRepository) GetPreferences(userID uint) ([]models.NotificationPreference, error) {
+	var prefs []models.NotificationPreference
+	if err := r.db.Where("user_id = ?", userID).Find(&prefs).Error; err != nil {
+		r.logger.Error("GetPreferences failed", "user_id", userID, "error", err)
+		return nil, err
+	}
+	return prefs, nil
+}
+
+func (r *notificationRepository) UpsertPreference(pref *models.NotificationPreference) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(pref).Error
+	if err != nil {
+		r.logger.Error("UpsertPreference failed", "user_id", pref.UserID, "channel", pref.Channel, "error", err)
+		return err
+	}
+	r.logger.Info("notification preference saved", "user_id", pref.UserID, "channel", pref.Channel, "enabled", pref.Enabled)
+	return nil
+}
+
+// ListBookingsForReminder возвращает активные брони, начинающиеся в окне [from, to],
+// по которым напоминание еще не отправлялось
+func (r *notificationRepository) ListBookingsForReminder(from, to time.Time) ([]models.Booking, error) {
+	var bookings []models.Booking
+	err := r.db.Preload("User").Preload("Place").
+		Where("status = ?", models.BookingActive).
+		Where("reminder_sent_at IS NULL").
+		Where("start_time BETWEEN ? AND ?", from, to).
+		Find(&bookings).Error
+	if err != nil {
+		r.logger.Error("ListBookingsForReminder failed", "error", err)
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// ClaimReminder помечает напоминание отправленным. Возвращает false, если его
+// уже забрал другой экземпляр API — так напоминание не уйдет дважды.
+func (r *notificationRepository) ClaimReminder(bookingID uint) (bool, error) {
+	res := r.db.Model(&models.Booking{}).
+		Where("id = ? AND reminder_sent_at IS NULL", bookingID).
+		Update("reminder_sent_at", time.Now())
+	if res.Error != nil {
+		r.logger.Error("ClaimReminder failed", "booking_id", bookingID, "error", res.Error)
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
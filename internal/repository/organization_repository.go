@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OrganizationRepository interface {
+	CreateOrganization(org *models.Organization, owner *models.OrganizationMember) error
+	GetOrganization(id uint) (*models.Organization, error)
+	ListOrganizations() ([]models.Organization, error)
+	UpdateOrganization(org *models.Organization) error
+	UpdateBillingSettings(org *models.Organization) error
+
+	GetMembership(userID uint) (*models.OrganizationMember, error)
+	ListMembers(orgID uint) ([]models.OrganizationMember, error)
+	UpdateMember(member *models.OrganizationMember) error
+	RemoveMember(orgID, userID uint) error
+	CountOwners(orgID uint) (int64, error)
+
+	CreateInvitation(inv *models.OrganizationInvitation) error
+	ListInvitations(orgID uint) ([]models.OrganizationInvitation, error)
+	GetInvitationByToken(token string) (*models.OrganizationInvitation, error)
+	AcceptInvitation(inv *models.OrganizationInvitation, member *models.OrganizationMember) error
+
+	ListMemberBookings(orgID uint, filter models.FilterOrgBookings) ([]models.Booking, error)
+
+	// Методы ниже работают внутри транзакции оплаты брони
+	LockMembership(tx *gorm.DB, userID uint) (*models.OrganizationMember, error)
+	MemberSpent(tx *gorm.DB, orgID, userID uint, since time.Time) (int, error)
+}
+
+type organizationRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewOrganizationRepository(db *gorm.DB, logger *slog.Logger) OrganizationRepository {
+	return &organizationRepository{db: db, logger: logger}
+}
+
+func (r *organizationRepository) CreateOrganization(org *models.Organization, owner *models.OrganizationMember) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		owner.OrganizationID = org.ID
+		return tx.Create(owner).Error
+	})
+	if err != nil {
+		r.logger.Error("CreateOrganization failed", "name", org.Name, "error", err)
+		return err
+	}
+	r.logger.Info("organization created", "organization_id", org.ID, "owner_id", owner.UserID)
+	return nil
+}
+
+func (r *organizationRepository) GetOrganization(id uint) (*models.Organization, error) {
+	var org models.Organization
+	if err := r.db.First(&org, id).Error; err != nil {
+		r.logger.Warn("GetOrganization failed", "organization_id", id, "error", err)
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *organizationRepository) ListOrganizations() ([]models.Organization, error) {
+	var orgs []models.Organization
+	if err := r.db.Order("id asc").Find(&orgs).Error; err != nil {
+		r.logger.Error("ListOrganizations failed", "error", err)
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// UpdateOrganization не трогает баланс: он меняется только через журнал
+func (r *organizationRepository) UpdateOrganization(org *models.Organization) error {
+	if err := r.db.Model(org).Select("name", "tax_id", "address", "billing_policy").Updates(org).Error; err != nil {
+		r.logger.Error("UpdateOrganization failed", "organization_id", org.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *organizationRepository) UpdateBillingSettings(org *models.Organization) error {
+	if err := r.db.Model(org).Select("postpaid", "credit_limit").Updates(org).Error; err != nil {
+		r.logger.Error("UpdateBillingSettings failed", "organization_id", org.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *organizationRepository) GetMembership(userID uint) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	if err := r.db.Where("user_id = ?", userID).First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *organizationRepository) ListMembers(orgID uint) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	if err := r.db.Preload("User").Where("organization_id = ?", orgID).Order("id asc").Find(&members).Error; err != nil {
+		r.logger.Error("ListMembers failed", "organization_id", orgID, "error", err)
+		return nil, err
+	}
+	return members, nil
+}
+
+func (r *organizationRepository) UpdateMember(member *models.OrganizationMember) error {
+	if err := r.db.Model(member).Select("role", "monthly_limit").Updates(member).Error; err != nil {
+		r.logger.Error("UpdateMember failed", "member_id", member.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// RemoveMember удаляет участие физически: иначе пользователь не сможет вступить в другую организацию
+func (r *organizationRepository) RemoveMember(orgID, userID uint) error {
+	res := r.db.Unscoped().Where("organization_id = ? AND user_id = ?", orgID, userID).Delete(&models.OrganizationMember{})
+	if res.Error != nil {
+		r.logger.Error("RemoveMember failed", "organization_id", orgID, "user_id", userID, "error", res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	r.logger.Info("member removed", "organization_id", orgID, "user_id", userID)
+	return nil
+}
+
+func (r *organizationRepository) CountOwners(orgID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.OrganizationMember{}).
+		Where("organization_id = ? AND role = ?", orgID, models.OrgOwner).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *organizationRepository) CreateInvitation(inv *models.OrganizationInvitation) error {
+	if err := r.db.Create(inv).Error; err != nil {
+		r.logger.Error("CreateInvitation failed", "organization_id", inv.OrganizationID, "error", err)
+		return err
+	}
+	r.logger.Info("invitation created", "organization_id", inv.OrganizationID, "email", inv.Email)
+	return nil
+}
+
+func (r *organizationRepository) ListInvitations(orgID uint) ([]models.OrganizationInvitation, error) {
+	var invs []models.OrganizationInvitation
+	if err := r.db.Where("organization_id = ?", orgID).Order("id desc").Find(&invs).Error; err != nil {
+		r.logger.Error("ListInvitations failed", "organization_id", orgID, "error", err)
+		return nil, err
+	}
+	return invs, nil
+}
+
+func (r *organizationRepository) GetInvitationByToken(token string) (*models.OrganizationInvitation, error) {
+	var inv models.OrganizationInvitation
+	if err := r.db.Where("token = ?", token).First(&inv).Error; err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func (r *organizationRepository) AcceptInvitation(inv *models.OrganizationInvitation, member *models.OrganizationMember) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Повторное принятие того же приглашения не должно создать второго участника
+		res := tx.Model(&models.OrganizationInvitation{}).
+			Where("id = ? AND accepted_at IS NULL", inv.ID).
+			Update("accepted_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Create(member).Error
+	})
+	if err != nil {
+		r.logger.Error("AcceptInvitation failed", "invitation_id", inv.ID, "error", err)
+		return err
+	}
+	r.logger.Info("invitation accepted", "organization_id", member.OrganizationID, "user_id", member.UserID)
+	return nil
+}
+
+func (r *organizationRepository) ListMemberBookings(orgID uint, filter models.FilterOrgBookings) ([]models.Booking, error) {
+	members := r.db.Model(&models.OrganizationMember{}).Select("user_id").Where("organization_id = ?", orgID)
+
+	q := r.db.Preload("User").Preload("Place").Where("user_id IN (?)", members)
+	if filter.UserID != nil {
+		q = q.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Status != nil {
+		q = q.Where("status = ?", *filter.Status)
+	}
+
+	var bookings []models.Booking
+	if err := q.Order("start_time desc").Limit(filter.Limit).Offset(filter.Offset).Find(&bookings).Error; err != nil {
+		r.logger.Error("ListMemberBookings failed", "organization_id", orgID, "error", err)
+		return nil, err
+	}
+	return bookings, nil
+}
+
+func (r *organizationRepository) LockMembership(tx *gorm.DB, userID uint) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// MemberSpent — сколько участник потратил с кошелька организации с момента since
+// за вычетом возвратов
+func (r *organizationRepository) MemberSpent(tx *gorm.DB, orgID, userID uint, since time.Time) (int, error) {
+	var spent int
+	err := tx.Model(&models.LedgerEntry{}).
+		Where("account = ? AND user_id = ? AND created_at >= ?", models.OrganizationAccount(orgID), userID, since).
+		Where("type IN ?", []models.LedgerEntryType{models.LedgerBookingCharge, models.LedgerRefund}).
+		Select("COALESCE(-SUM(amount), 0)").Scan(&spent).Error
+	if err != nil {
+		r.logger.Error("MemberSpent failed", "organization_id", orgID, "user_id", userID, "error", err)
+		return 0, err
+	}
+	return spent, nil
+}
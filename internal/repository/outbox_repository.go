@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"gorm.io/gorm"
+)
+
+// outboxLockKey — ключ advisory-лока: пачку outbox обрабатывает только один relay,
+// иначе порядок событий внутри агрегата не гарантирован
+const outboxLockKey = 7_346_001
+
+// AddOutboxEvent записывает событие в outbox. Вызывать внутри транзакции,
+// которая меняет сам агрегат.
+func AddOutboxEvent(tx *gorm.DB, aggregateType string, aggregateID uint, eventType models.EventType, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.OutboxEvent{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       string(data),
+	}).Error
+}
+
+type OutboxRepository interface {
+	// ClaimBatch забирает готовые к отправке события по порядку id и
+	// откладывает их повторную выдачу на lease, чтобы отправка шла вне
+	// транзакции и событие не взял другой экземпляр relay
+	ClaimBatch(limit int, lease time.Duration) ([]models.OutboxEvent, error)
+	MarkPublished(id uint) error
+	// MarkFailed сохраняет попытки, ошибку, принявших получателей и время
+	// следующей попытки (или DeadAt)
+	MarkFailed(ev *models.OutboxEvent) error
+	// Release возвращает необработанные события в очередь без траты попытки
+	Release(ids []uint) error
+}
+
+type outboxRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewOutboxRepository(db *gorm.DB, logger *slog.Logger) OutboxRepository {
+	return &outboxRepository{db: db, logger: logger}
+}
+
+// outboxReady — событие не опубликовано, не мертво, его время пришло, и у
+// агрегата нет более раннего события, ожидающего повтора или занятого другим
+// relay: подписчики видят события агрегата в порядке записи
+const outboxReady = `published_at IS NULL AND dead_at IS NULL
+	AND (next_attempt_at IS NULL OR next_attempt_at <= ?)
+	AND NOT EXISTS (
+		SELECT 1 FROM outbox_events e
+		WHERE e.aggregate_type = outbox_events.aggregate_type
+			AND e.aggregate_id = outbox_events.aggregate_id
+			AND e.id < outbox_events.id
+			AND e.published_at IS NULL AND e.dead_at IS NULL
+			AND e.next_attempt_at > ?
+	)`
+
+func (r *outboxRepository) ClaimBatch(limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", outboxLockKey).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			// пачку сейчас забирает другой экземпляр
+			return nil
+		}
+
+		now := time.Now()
+		if err := tx.Where(outboxReady, now, now).Order("id asc").Limit(limit).Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(events))
+		for i := range events {
+			ids[i] = events[i].ID
+		}
+		return tx.Model(&models.OutboxEvent{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		r.logger.Error("outbox ClaimBatch failed", "error", err)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (r *outboxRepository) MarkPublished(id uint) error {
+	err := r.db.Model(&models.OutboxEvent{}).Where("id = ?", id).Updates(map[string]any{
+		"published_at":    time.Now(),
+		"next_attempt_at": nil,
+	}).Error
+	if err != nil {
+		r.logger.Error("outbox MarkPublished failed", "event_id", id, "error", err)
+	}
+	return err
+}
+
+func (r *outboxRepository) MarkFailed(ev *models.OutboxEvent) error {
+	err := r.db.Model(ev).Select("attempts", "last_error", "delivered", "next_attempt_at", "dead_at").Updates(ev).Error
+	if err != nil {
+		r.logger.Error("outbox MarkFailed failed", "event_id", ev.ID, "error", err)
+	}
+	return err
+}
+
+func (r *outboxRepository) Release(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	err := r.db.Model(&models.OutboxEvent{}).Where("id IN ?", ids).Update("next_attempt_at", nil).Error
+	if err != nil {
+		r.logger.Error("outbox Release failed", "event_ids", ids, "error", err)
+	}
+	return err
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PaymentRepository interface {
+	CreateIntent(intent *models.PaymentIntent) error
+	UpdateIntent(intent *models.PaymentIntent) error
+	GetUserIntent(userID, id uint) (*models.PaymentIntent, error)
+	GetIntentByIdempotencyKey(userID uint, key string) (*models.PaymentIntent, error)
+	ListUserIntents(userID uint, limit, offset int) ([]models.PaymentIntent, int64, error)
+	ExpirePending(now time.Time) (int64, error)
+
+	// Методы ниже работают внутри транзакции обработки вебхука/возврата
+	RecordWebhookEvent(tx *gorm.DB, ev *models.PaymentWebhookEvent) (bool, error)
+	LockIntent(tx *gorm.DB, id uint) (*models.PaymentIntent, error)
+	LockIntentByProviderID(tx *gorm.DB, provider, providerPaymentID string) (*models.PaymentIntent, error)
+}
+
+type paymentRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewPaymentRepository(db *gorm.DB, logger *slog.Logger) PaymentRepository {
+	return &paymentRepository{db: db, logger: logger}
+}
+
+func (r *paymentRepository) CreateIntent(intent *models.PaymentIntent) error {
+	if err := r.db.Create(intent).Error; err != nil {
+		r.logger.Error("CreateIntent failed", "user_id", intent.UserID, "error", err)
+		return err
+	}
+	r.logger.Info("payment intent created", "intent_id", intent.ID, "user_id", intent.UserID, "amount", intent.Amount)
+	return nil
+}
+
+func (r *paymentRepository) UpdateIntent(intent *models.PaymentIntent) error {
+	if err := r.db.Save(intent).Error; err != nil {
+		r.logger.Error("UpdateIntent failed", "intent_id", intent.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *paymentRepository) GetUserIntent(userID, id uint) (*models.PaymentIntent, error) {
+	var intent models.PaymentIntent
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&intent).Error; err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+func (r *paymentRepository) GetIntentByIdempotencyKey(userID uint, key string) (*models.PaymentIntent, error) {
+	var intent models.PaymentIntent
+	if err := r.db.Where("user_id = ? AND idempotency_key = ?", userID, key).First(&intent).Error; err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+func (r *paymentRepository) ListUserIntents(userID uint, limit, offset int) ([]models.PaymentIntent, int64, error) {
+	q := r.db.Model(&models.PaymentIntent{}).Where("user_id = ?", userID)
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		r.logger.Error("ListUserIntents count failed", "user_id", userID, "error", err)
+		return nil, 0, err
+	}
+
+	var intents []models.PaymentIntent
+	if err := q.Order("id desc").Limit(limit).Offset(offset).Find(&intents).Error; err != nil {
+		r.logger.Error("ListUserIntents failed", "user_id", userID, "error", err)
+		return nil, 0, err
+	}
+	return intents, total, nil
+}
+
+// ExpirePending помечает просроченными неоплаченные намерения. Если провайдер
+// позже все-таки пришлет успешную оплату, деньги будут зачислены.
+func (r *paymentRepository) ExpirePending(now time.Time) (int64, error) {
+	res := r.db.Model(&models.PaymentIntent{}).
+		Where("status = ? AND expires_at <= ?", models.PaymentPending, now).
+		Update("status", models.PaymentExpired)
+	if res.Error != nil {
+		r.logger.Error("ExpirePending failed", "error", res.Error)
+		return 0, res.Error
+	}
+	return res.RowsAffected, nil
+}
+
+// RecordWebhookEvent возвращает false, если событие с таким id уже обработано
+func (r *paymentRepository) RecordWebhookEvent(tx *gorm.DB, ev *models.PaymentWebhookEvent) (bool, error) {
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(ev)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (r *paymentRepository) LockIntent(tx *gorm.DB, id uint) (*models.PaymentIntent, error) {
+	var intent models.PaymentIntent
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&intent, id).Error; err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+func (r *paymentRepository) LockIntentByProviderID(tx *gorm.DB, provider, providerPaymentID string) (*models.PaymentIntent, error) {
+	var intent models.PaymentIntent
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("provider = ? AND provider_payment_id = ?", provider, providerPaymentID).
+		First(&intent).Error
+	if err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"log/slog"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"gorm.io/gorm"
+)
+
+type PlaceImageRepository interface {
+	// CreateImage ставит снимок в конец галереи места
+	CreateImage(img *models.PlaceImage) error
+	GetImage(placeID, id uint) (*models.PlaceImage, error)
+	ListImages(placeID uint) ([]models.PlaceImage, error)
+	UpdateImage(img *models.PlaceImage) error
+	// Reorder проставляет позиции по порядку ids; ids — все снимки места
+	Reorder(placeID uint, ids []uint) error
+	DeleteImage(placeID, id uint) error
+}
+
+type placeImageRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewPlaceImageRepository(db *gorm.DB, logger *slog.Logger) PlaceImageRepository {
+	return &placeImageRepository{db: db, logger: logger}
+}
+
+func (r *placeImageRepository) CreateImage(img *models.PlaceImage) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// блокируем место, чтобы параллельные загрузки не получили одну позицию
+		if err := tx.Exec("SELECT 1 FROM places WHERE id = ? FOR UPDATE", img.PlaceID).Error; err != nil {
+			return err
+		}
+
+		var last int
+		if err := tx.Model(&models.PlaceImage{}).
+			Where("place_id = ?", img.PlaceID).
+			Select("COALESCE(MAX(position), 0)").
+			Scan(&last).Error; err != nil {
+			return err
+		}
+
+		img.Position = last + 1
+		return tx.Create(img).Error
+	})
+	if err != nil {
+		r.logger.Error("CreateImage failed", "place_id", img.PlaceID, "error", err)
+		return err
+	}
+
+	r.logger.Info("place image created", "place_id", img.PlaceID, "image_id", img.ID)
+	return nil
+}
+
+func (r *placeImageRepository) GetImage(placeID, id uint) (*models.PlaceImage, error) {
+	var img models.PlaceImage
+	if err := r.db.Where("id = ? AND place_id = ?", id, placeID).First(&img).Error; err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+func (r *placeImageRepository) ListImages(placeID uint) ([]models.PlaceImage, error) {
+	var images []models.PlaceImage
+	if err := r.db.Where("place_id = ?", placeID).Order("position, id").Find(&images).Error; err != nil {
+		r.logger.Error("ListImages failed", "place_id", placeID, "error", err)
+		return nil, err
+	}
+	return images, nil
+}
+
+func (r *placeImageRepository) UpdateImage(img *models.PlaceImage) error {
+	if err := r.db.Model(img).Select("caption").Updates(img).Error; err != nil {
+		r.logger.Error("UpdateImage failed", "image_id", img.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *placeImageRepository) Reorder(placeID uint, ids []uint) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for i, id := range ids {
+			if err := tx.Model(&models.PlaceImage{}).
+				Where("id = ? AND place_id = ?", id, placeID).
+				Update("position", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("Reorder images failed", "place_id", placeID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteImage удаляет запись окончательно: файлы после этого тоже удаляются
+func (r *placeImageRepository) DeleteImage(placeID, id uint) error {
+	if err := r.db.Unscoped().Where("id = ? AND place_id = ?", id, placeID).Delete(&models.PlaceImage{}).Error; err != nil {
+		r.logger.Error("DeleteImage failed", "image_id", id, "error", err)
+		return err
+	}
+	return nil
+}
@@ -1,19 +1,34 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
+	"html"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/IslamCHup/coworking-manager-project/internal/models"
 	"gorm.io/gorm"
 )
 
+// ErrPlaceHasBookings — место нельзя отключить без разбора его будущих броней
+var ErrPlaceHasBookings = errors.New("у места есть будущие брони")
+
 type PlaceRepository interface {
 	CreatePlace(req *models.Place) error
 	GetPlaceByID(id uint) (*models.Place, error)
 	UpdatePlace(req *models.Place) error
+	// DeactivatePlace сохраняет отключенное место и возвращает число его будущих броней
+	DeactivatePlace(req *models.Place, now time.Time, allowBookings bool) (int64, error)
 	DeletePlace(id uint) error
 	ListPlaces(filter *models.FilterPlace) (*[]models.Place, error)
 	ListFreePlaces(filter *models.FilterPlace) (*[]models.Place, error)
+	// ListFutureBookings — активные и ожидающие брони места, которые еще не закончились
+	ListFutureBookings(placeID uint, now time.Time) ([]models.Booking, error)
+	// SearchPlaces ищет активные места по тексту и возвращает их по убыванию релевантности
+	SearchPlaces(filter *models.FilterPlaceSearch) ([]models.PlaceSearchHit, int64, error)
 }
 
 type placeRepository struct {
@@ -39,7 +54,7 @@ func (r *placeRepository) CreatePlace(req *models.Place) error {
 
 func (r *placeRepository) GetPlaceByID(id uint) (*models.Place, error) {
 	var place models.Place
-	if err := r.db.First(&place, id).Error; err != nil {
+	if err := r.db.Preload("Rating").First(&place, id).Error; err != nil {
 		r.logger.Error("GetPlaceByID failed", "place_id", id, "error", err)
 		return nil, err
 	}
@@ -56,6 +71,38 @@ func (r *placeRepository) UpdatePlace(req *models.Place) error {
 	return nil
 }
 
+// DeactivatePlace сохраняет место и в той же транзакции считает его будущие
+// брони. Обновление блокирует строку места, а CreateBooking читает ее с FOR SHARE,
+// поэтому бронь, созданная параллельно, либо уже учтена в подсчете, либо после
+// коммита увидит место отключенным. Если брони есть, а allowBookings не задан,
+// изменения откатываются.
+func (r *placeRepository) DeactivatePlace(req *models.Place, now time.Time, allowBookings bool) (int64, error) {
+	var count int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(req).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Booking{}).
+			Where("place_id = ? AND end_time > ?", req.ID, now).
+			Where("status IN ?", []models.BookingStatus{models.BookingActive, models.BookingNonActive}).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 && !allowBookings {
+			return ErrPlaceHasBookings
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, ErrPlaceHasBookings) {
+			r.logger.Error("DeactivatePlace failed", "place_id", req.ID, "error", err)
+		}
+		return count, err
+	}
+	r.logger.Info("Place deactivated", "place_id", req.ID, "future_bookings", count)
+	return count, nil
+}
+
 func (r *placeRepository) DeletePlace(id uint) error {
 	if err := r.db.Delete(&models.Place{}, id).Error; err != nil {
 		r.logger.Error("DeletePlace failed", "place_id", id, "error", err)
@@ -86,13 +133,14 @@ func (r *placeRepository) ListPlaces(filter *models.FilterPlace) (*[]models.Plac
 	query := r.db.Model(&models.Place{})
 
 	if filter.Type != nil {
-		query = query.Where("type = ?", *filter.Type)
+		query = query.Where("places.type = ?", *filter.Type)
 	}
 	if filter.IsActive != nil {
-		query = query.Where("is_active = ?", *filter.IsActive)
+		query = query.Where("places.is_active = ?", *filter.IsActive)
 	}
+	query = applyPlaceLocation(query, filter)
 
-	allowed := map[string]bool{"created_at": true, "price_per_hour": true, "id": true}
+	allowed := map[string]bool{"created_at": true, "price_per_hour": true, "id": true, "rating": true}
 	sortBy := filter.SortBy
 	if !allowed[sortBy] {
 		sortBy = "created_at"
@@ -102,7 +150,16 @@ func (r *placeRepository) ListPlaces(filter *models.FilterPlace) (*[]models.Plac
 		order = "asc"
 	}
 
-	query = query.Order(sortBy + " " + order).Limit(filter.Limit).Offset(filter.Offset)
+	if sortBy == "rating" {
+		// места без отзывов считаются с нулевым рейтингом; при равной средней
+		// выше то место, у которого больше отзывов
+		query = query.Joins("LEFT JOIN place_ratings ON place_ratings.place_id = places.id").
+			Order("COALESCE(place_ratings.average, 0) " + order).
+			Order("COALESCE(place_ratings.count, 0) " + order)
+	} else {
+		query = query.Order("places." + sortBy + " " + order)
+	}
+	query = query.Order("places.id").Preload("Rating").Limit(filter.Limit).Offset(filter.Offset)
 
 	if err := query.Find(&places).Error; err != nil {
 		r.logger.Error("ListPlaces failed", "error", err)
@@ -112,28 +169,27 @@ func (r *placeRepository) ListPlaces(filter *models.FilterPlace) (*[]models.Plac
 	return &places, nil
 }
 
-// ListFreePlaces возвращает места, на которые нет активной брони в указанном промежутке времени
+// ListFreePlaces возвращает места, на которые нет активной брони и окна обслуживания в указанном промежутке времени
 // Если StartTime и EndTime не заданы, проверяется текущее время
 func (r *placeRepository) ListFreePlaces(filter *models.FilterPlace) (*[]models.Place, error) {
 	var places []models.Place
 
 	query := r.db.Model(&models.Place{}).Where("is_active = ?", true)
 
-	// постройка подзапроса: существует ли активная бронь, пересекающаяся с заданным периодом
-	sub := r.db.Table("bookings").Select("1").Where("bookings.place_id = places.id").Where("status = ?", models.BookingActive)
-
 	if filter != nil && filter.StartTime != nil && filter.EndTime != nil {
-		// overlap condition: booking.start_time < end AND booking.end_time > start
-		sub = sub.Where("start_time < ? AND end_time > ?", *filter.EndTime, *filter.StartTime)
+		query = r.freeIn(query, *filter.StartTime, *filter.EndTime)
 	} else {
 		// проверяем текущее время
 		// используем NOW() в SQL
-		sub = sub.Where("start_time <= NOW() AND end_time >= NOW()")
+		query = r.freeIn(query, gorm.Expr("NOW()"), gorm.Expr("NOW()"))
 	}
 
-	query = query.Where("NOT EXISTS (?)", sub)
-
 	if filter != nil {
+		if filter.Type != nil {
+			query = query.Where("type = ?", *filter.Type)
+		}
+		query = applyPlaceLocation(query, filter)
+
 		if filter.Limit <= 0 || filter.Limit > 100 {
 			filter.Limit = 20
 		}
@@ -143,7 +199,7 @@ func (r *placeRepository) ListFreePlaces(filter *models.FilterPlace) (*[]models.
 		query = query.Limit(filter.Limit).Offset(filter.Offset)
 	}
 
-	if err := query.Find(&places).Error; err != nil {
+	if err := query.Preload("Rating").Find(&places).Error; err != nil {
 		r.logger.Error("ListFreePlaces failed", "error", err)
 		return nil, err
 	}
@@ -151,3 +207,241 @@ func (r *placeRepository) ListFreePlaces(filter *models.FilterPlace) (*[]models.
 	r.logger.Info("ListFreePlaces success", "count", len(places))
 	return &places, nil
 }
+
+func (r *placeRepository) ListFutureBookings(placeID uint, now time.Time) ([]models.Booking, error) {
+	var bookings []models.Booking
+	err := r.db.
+		Where("place_id = ? AND end_time > ?", placeID, now).
+		Where("status IN ?", []models.BookingStatus{models.BookingActive, models.BookingNonActive}).
+		Order("start_time").
+		Find(&bookings).Error
+	if err != nil {
+		r.logger.Error("ListFutureBookings failed", "place_id", placeID, "error", err)
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// Порог похожести для поиска с опечатками: значение pg_trgm по умолчанию (0.6)
+// не находит короткие слова с одной-двумя ошибками
+const searchSimilarityThreshold = 0.4
+
+// ts_headline не экранирует текст, поэтому совпадения отмечаются символами из
+// области частного использования Unicode, а в <mark> они превращаются уже
+// после HTML-экранирования (см. renderHighlight)
+const (
+	highlightStart = "\uE000"
+	highlightStop  = "\uE001"
+
+	nameHeadlineOptions        = "HighlightAll=true, StartSel=" + highlightStart + ", StopSel=" + highlightStop
+	descriptionHeadlineOptions = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", MaxWords=30, MinWords=10, MaxFragments=2"
+)
+
+func (r *placeRepository) SearchPlaces(filter *models.FilterPlaceSearch) ([]models.PlaceSearchHit, int64, error) {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	// Запрос разбирается в обеих конфигурациях: русская находит кириллицу,
+	// английская — английские словоформы в названиях и удобствах
+	tsq := gorm.Expr("websearch_to_tsquery('russian', ?) || websearch_to_tsquery('english', ?)", filter.Query, filter.Query)
+	text := gorm.Expr("lower(?)", filter.Query)
+
+	// подсветка строится в обеих конфигурациях и затем объединяется
+	var hits []struct {
+		ID            uint
+		Rank          float64
+		NameRu        string
+		NameEn        string
+		DescriptionRu string
+		DescriptionEn string
+	}
+	ruq := gorm.Expr("websearch_to_tsquery('russian', ?)", filter.Query)
+	enq := gorm.Expr("websearch_to_tsquery('english', ?)", filter.Query)
+	markers := highlightStart + highlightStop
+	var total int64
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// порог действует только внутри транзакции и нужен оператору <%,
+		// который умеет пользоваться триграммным индексом
+		if err := tx.Exec("SELECT set_config('pg_trgm.word_similarity_threshold', ?, true)", fmt.Sprint(searchSimilarityThreshold)).Error; err != nil {
+			return err
+		}
+
+		query := tx.Model(&models.Place{}).
+			Where("places.is_active = ?", true).
+			Where("places.search_vector @@ (?) OR ? <% lower(places.name)", tsq, text)
+		if filter.Type != nil {
+			query = query.Where("places.type = ?", *filter.Type)
+		}
+		if filter.LocationID != nil {
+			query = query.Where("places.location_id = ?", *filter.LocationID)
+		}
+		if filter.FloorID != nil {
+			query = query.Where("places.floor_id = ?", *filter.FloorID)
+		}
+		if filter.ZoneID != nil {
+			query = query.Where("places.zone_id = ?", *filter.ZoneID)
+		}
+		if filter.MinPrice != nil {
+			query = query.Where("places.price_per_hour >= ?", *filter.MinPrice)
+		}
+		if filter.MaxPrice != nil {
+			query = query.Where("places.price_per_hour <= ?", *filter.MaxPrice)
+		}
+		if filter.StartTime != nil && filter.EndTime != nil {
+			query = r.freeIn(query, *filter.StartTime, *filter.EndTime)
+		}
+
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return err
+		}
+
+		return query.
+			Select(`places.id,
+				ts_rank_cd(places.search_vector, ?) + word_similarity(?, lower(places.name)) AS rank,
+				ts_headline('russian', translate(places.name, ?, ''), ?, ?) AS name_ru,
+				ts_headline('english', translate(places.name, ?, ''), ?, ?) AS name_en,
+				ts_headline('russian', translate(coalesce(places.description, ''), ?, ''), ?, ?) AS description_ru,
+				ts_headline('english', translate(coalesce(places.description, ''), ?, ''), ?, ?) AS description_en`,
+				tsq, text,
+				markers, ruq, nameHeadlineOptions,
+				markers, enq, nameHeadlineOptions,
+				markers, ruq, descriptionHeadlineOptions,
+				markers, enq, descriptionHeadlineOptions).
+			Order("rank DESC, places.id").
+			Limit(filter.Limit).Offset(filter.Offset).
+			Scan(&hits).Error
+	})
+	if err != nil {
+		r.logger.Error("SearchPlaces failed", "query", filter.Query, "error", err)
+		return nil, 0, err
+	}
+	if len(hits) == 0 {
+		return []models.PlaceSearchHit{}, total, nil
+	}
+
+	ids := make([]uint, 0, len(hits))
+	for _, h := range hits {
+		ids = append(ids, h.ID)
+	}
+	var places []models.Place
+	if err := r.db.Preload("Rating").Where("id IN ?", ids).Find(&places).Error; err != nil {
+		r.logger.Error("SearchPlaces load failed", "error", err)
+		return nil, 0, err
+	}
+
+	result := make([]models.PlaceSearchHit, 0, len(hits))
+	for _, h := range hits {
+		i := slices.IndexFunc(places, func(p models.Place) bool { return p.ID == h.ID })
+		if i < 0 {
+			continue
+		}
+		result = append(result, models.PlaceSearchHit{
+			Place:                places[i],
+			Rank:                 h.Rank,
+			NameHighlight:        renderHighlight(mergeHighlights(h.NameRu, h.NameEn)),
+			DescriptionHighlight: renderHighlight(mergeHighlights(h.DescriptionRu, h.DescriptionEn)),
+		})
+	}
+
+	r.logger.Info("SearchPlaces success", "query", filter.Query, "count", len(result), "total", total)
+	return result, total, nil
+}
+
+// mergeHighlights объединяет подсветку одного текста из двух конфигураций.
+// Если фрагменты разные (длинное описание режется по-разному), берется тот,
+// где есть совпадения, с приоритетом a.
+func mergeHighlights(a, b string) string {
+	plainA, marksA := splitHighlight(a)
+	plainB, marksB := splitHighlight(b)
+	if plainA != plainB {
+		if slices.Contains(marksA, true) || !slices.Contains(marksB, true) {
+			return a
+		}
+		return b
+	}
+
+	var sb strings.Builder
+	marked := false
+	for i := 0; i < len(plainA); i++ {
+		m := marksA[i] || marksB[i]
+		if m != marked {
+			if m {
+				sb.WriteString(highlightStart)
+			} else {
+				sb.WriteString(highlightStop)
+			}
+			marked = m
+		}
+		sb.WriteByte(plainA[i])
+	}
+	if marked {
+		sb.WriteString(highlightStop)
+	}
+	return sb.String()
+}
+
+// splitHighlight убирает маркеры и возвращает текст и признак подсветки для
+// каждого его байта
+func splitHighlight(s string) (string, []bool) {
+	var sb strings.Builder
+	marks := make([]bool, 0, len(s))
+	marked := false
+	for len(s) > 0 {
+		switch {
+		case strings.HasPrefix(s, highlightStart):
+			marked = true
+			s = s[len(highlightStart):]
+		case strings.HasPrefix(s, highlightStop):
+			marked = false
+			s = s[len(highlightStop):]
+		default:
+			sb.WriteByte(s[0])
+			marks = append(marks, marked)
+			s = s[1:]
+		}
+	}
+	return sb.String(), marks
+}
+
+// renderHighlight экранирует текст для HTML и только затем превращает маркеры в <mark>
+func renderHighlight(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, highlightStart, "<mark>")
+	return strings.ReplaceAll(s, highlightStop, "</mark>")
+}
+
+// freeIn оставляет места без активной брони и окна обслуживания, пересекающих [start, end)
+func (r *placeRepository) freeIn(query *gorm.DB, start, end any) *gorm.DB {
+	// постройка подзапроса: существует ли активная бронь, пересекающаяся с заданным периодом
+	// overlap condition: booking.start_time < end AND booking.end_time > start
+	sub := r.db.Table("bookings").Select("1").
+		Where("bookings.place_id = places.id").
+		Where("status = ?", models.BookingActive).
+		Where("start_time < ? AND end_time > ?", end, start)
+
+	query = query.Where("NOT EXISTS (?)", sub)
+	// места на обслуживании тоже не свободны
+	return query.Where("NOT EXISTS (?)", maintenanceBlocks(r.db, start, end))
+}
+
+// applyPlaceLocation фильтрует места по площадке, этажу и зоне
+func applyPlaceLocation(query *gorm.DB, filter *models.FilterPlace) *gorm.DB {
+	if filter.LocationID != nil {
+		query = query.Where("places.location_id = ?", *filter.LocationID)
+	}
+	if filter.FloorID != nil {
+		query = query.Where("places.floor_id = ?", *filter.FloorID)
+	}
+	if filter.ZoneID != nil {
+		query = query.Where("places.zone_id = ?", *filter.ZoneID)
+	}
+	if filter.LocationIDs != nil {
+		query = query.Where("places.location_id IN ?", filter.LocationIDs)
+	}
+	return query
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"log/slog"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"gorm.io/gorm"
+)
+
+type PriceRuleRepository interface {
+	CreateRule(rule *models.PriceRule) error
+	GetRule(id uint) (*models.PriceRule, error)
+	ListRules() ([]models.PriceRule, error)
+	ListActiveRulesForPlace(place *models.Place) ([]models.PriceRule, error)
+	UpdateRule(rule *models.PriceRule) error
+	DeleteRule(id uint) error
+}
+
+type priceRuleRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewPriceRuleRepository(db *gorm.DB, logger *slog.Logger) PriceRuleRepository {
+	return &priceRuleRepository{db: db, logger: logger}
+}
+
+func (r *priceRuleRepository) CreateRule(rule *models.PriceRule) error {
+	if err := r.db.Create(rule).Error; err != nil {
+		r.logger.Error("CreateRule failed", "error", err)
+		return err
+	}
+	r.logger.Info("price rule created", "rule_id", rule.ID, "name", rule.Name)
+	return nil
+}
+
+func (r *priceRuleRepository) GetRule(id uint) (*models.PriceRule, error) {
+	var rule models.PriceRule
+	if err := r.db.First(&rule, id).Error; err != nil {
+		r.logger.Warn("GetRule failed", "rule_id", id, "error", err)
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *priceRuleRepository) ListRules() ([]models.PriceRule, error) {
+	var rules []models.PriceRule
+	if err := r.db.Order("priority desc, id asc").Find(&rules).Error; err != nil {
+		r.logger.Error("ListRules failed", "error", err)
+		return nil, err
+	}
+	return rules, nil
+}
+
+// ListActiveRulesForPlace возвращает активные правила, применимые к месту:
+// общие, по типу места и персональные для этого места
+func (r *priceRuleRepository) ListActiveRulesForPlace(place *models.Place) ([]models.PriceRule, error) {
+	var rules []models.PriceRule
+	err := r.db.
+		Where("is_active = ?", true).
+		Where("place_id IS NULL OR place_id = ?", place.ID).
+		Where("place_type IS NULL OR place_type = ?", place.Type).
+		Order("priority desc, id asc").
+		Find(&rules).Error
+	if err != nil {
+		r.logger.Error("ListActiveRulesForPlace failed", "place_id", place.ID, "error", err)
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *priceRuleRepository) UpdateRule(rule *models.PriceRule) error {
+	if err := r.db.Save(rule).Error; err != nil {
+		r.logger.Error("UpdateRule failed", "rule_id", rule.ID, "error", err)
+		return err
+	}
+	r.logger.Info("price rule updated", "rule_id", rule.ID)
+	return nil
+}
+
+func (r *priceRuleRepository) DeleteRule(id uint) error {
+	res := r.db.Delete(&models.PriceRule{}, id)
+	if res.Error != nil {
+		r.logger.Error("DeleteRule failed", "rule_id", id, "error", res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	r.logger.Info("price rule deleted", "rule_id", id)
+	return nil
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PromoRepository interface {
+	CreatePromo(promo *models.PromoCode) error
+	GetPromo(id uint) (*models.PromoCode, error)
+	GetPromoByCode(code string) (*models.PromoCode, error)
+	ListPromos() ([]models.PromoCode, error)
+	UpdatePromo(promo *models.PromoCode) error
+	DeletePromo(id uint) error
+	CountUserRedemptions(promoID, userID uint) (int64, error)
+
+	// Методы ниже работают внутри транзакции списания/возврата
+	LockPromo(tx *gorm.DB, id uint) (*models.PromoCode, error)
+	CountUserRedemptionsTx(tx *gorm.DB, promoID, userID uint) (int64, error)
+	SaveRedemption(tx *gorm.DB, redemption *models.PromoRedemption) error
+	GetRedemptionByBooking(tx *gorm.DB, bookingID uint) (*models.PromoRedemption, error)
+	AddRedemptionsCount(tx *gorm.DB, promoID uint, delta int) error
+}
+
+type promoRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewPromoRepository(db *gorm.DB, logger *slog.Logger) PromoRepository {
+	return &promoRepository{db: db, logger: logger}
+}
+
+func (r *promoRepository) CreatePromo(promo *models.PromoCode) error {
+	if err := r.db.Create(promo).Error; err != nil {
+		r.logger.Error("CreatePromo failed", "code", promo.Code, "error", err)
+		return err
+	}
+	r.logger.Info("promo code created", "promo_id", promo.ID, "code", promo.Code)
+	return nil
+}
+
+func (r *promoRepository) GetPromo(id uint) (*models.PromoCode, error) {
+	var promo models.PromoCode
+	if err := r.db.First(&promo, id).Error; err != nil {
+		r.logger.Warn("GetPromo failed", "promo_id", id, "error", err)
+		return nil, err
+	}
+	return &promo, nil
+}
+
+func (r *promoRepository) GetPromoByCode(code string) (*models.PromoCode, error) {
+	var promo models.PromoCode
+	if err := r.db.Where("code = ?", strings.ToUpper(code)).First(&promo).Error; err != nil {
+		r.logger.Warn("GetPromoByCode failed", "code", code, "error", err)
+		return nil, err
+	}
+	return &promo, nil
+}
+
+func (r *promoRepository) ListPromos() ([]models.PromoCode, error) {
+	var promos []models.PromoCode
+	if err := r.db.Order("id desc").Find(&promos).Error; err != nil {
+		r.logger.Error("ListPromos failed", "error", err)
+		return nil, err
+	}
+	return promos, nil
+}
+
+func (r *promoRepository) UpdatePromo(promo *models.PromoCode) error {
+	if err := r.db.Save(promo).Error; err != nil {
+		r.logger.Error("UpdatePromo failed", "promo_id", promo.ID, "error", err)
+		return err
+	}
+	r.logger.Info("promo code updated", "promo_id", promo.ID)
+	return nil
+}
+
+func (r *promoRepository) DeletePromo(id uint) error {
+	res := r.db.Delete(&models.PromoCode{}, id)
+	if res.Error != nil {
+		r.logger.Error("DeletePromo failed", "promo_id", id, "error", res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	r.logger.Info("promo code deleted", "promo_id", id)
+	return nil
+}
+
+func (r *promoRepository) CountUserRedemptions(promoID, userID uint) (int64, error) {
+	return r.CountUserRedemptionsTx(r.db, promoID, userID)
+}
+
+// LockPromo берет строку промокода FOR UPDATE, чтобы параллельные оплаты
+// не превысили лимит погашений
+func (r *promoRepository) LockPromo(tx *gorm.DB, id uint) (*models.PromoCode, error) {
+	var promo models.PromoCode
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&promo, id).Error; err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+func (r *promoRepository) CountUserRedemptionsTx(tx *gorm.DB, promoID, userID uint) (int64, error) {
+	var count int64
+	err := tx.Model(&models.PromoRedemption{}).
+		Where("promo_code_id = ? AND user_id = ? AND status = ?", promoID, userID, models.PromoRedemptionApplied).
+		Count(&count).Error
+	if err != nil {
+		r.logger.Error("CountUserRedemptions failed", "promo_id", promoID, "user_id", userID, "error", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *promoRepository) SaveRedemption(tx *gorm.DB, redemption *models.PromoRedemption) error {
+	return tx.Save(redemption).Error
+}
+
+func (r *promoRepository) GetRedemptionByBooking(tx *gorm.DB, bookingID uint) (*models.PromoRedemption, error) {
+	var redemption models.PromoRedemption
+	if err := tx.Where("booking_id = ?", bookingID).First(&redemption).Error; err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+func (r *promoRepository) AddRedemptionsCount(tx *gorm.DB, promoID uint, delta int) error {
+	return tx.Model(&models.PromoCode{}).
+		Where("id = ?", promoID).
+		Update("redemptions_count", gorm.Expr("redemptions_count + ?", delta)).Error
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RelocationRepository interface {
+	// FindCandidates подбирает свободные в интервале места, равноценные source:
+	// та же площадка и тип, не меньшая вместимость и все удобства source.
+	// Сначала идут места с ближайшей ценой, при равенстве — с того же этажа.
+	FindCandidates(source *models.Place, start, end time.Time, limit int) ([]models.Place, error)
+
+	// SaveRelocation обновляет открытую запись по той же брони вместо создания дубля
+	SaveRelocation(r *models.BookingRelocation) error
+	GetRelocation(id uint) (*models.BookingRelocation, error)
+	// LockRelocation читает запись с блокировкой строки до конца транзакции tx
+	LockRelocation(tx *gorm.DB, id uint) (*models.BookingRelocation, error)
+	// ResolveRelocation сохраняет результат обработки записи внутри транзакции tx
+	ResolveRelocation(tx *gorm.DB, r *models.BookingRelocation) error
+	ListRelocations(filter *models.FilterRelocation) ([]models.BookingRelocation, int64, error)
+}
+
+type relocationRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewRelocationRepository(db *gorm.DB, logger *slog.Logger) RelocationRepository {
+	return &relocationRepository{db: db, logger: logger}
+}
+
+func (r *relocationRepository) FindCandidates(source *models.Place, start, end time.Time, limit int) ([]models.Place, error) {
+	amenities, err := json.Marshal(source.Amenities)
+	if err != nil {
+		return nil, err
+	}
+	if source.Amenities == nil {
+		amenities = []byte("[]")
+	}
+
+	busy := r.db.Table("bookings").Select("1").
+		Where("bookings.place_id = places.id AND bookings.deleted_at IS NULL").
+		Where("bookings.status = ?", models.BookingActive).
+		Where("bookings.start_time < ? AND bookings.end_time > ?", end, start)
+
+	var places []models.Place
+	err = r.db.Model(&models.Place{}).
+		Where("is_active = ? AND id <> ? AND type = ?", true, source.ID, source.Type).
+		Where("location_id IS NOT DISTINCT FROM ?", source.LocationID).
+		Where("capacity >= ?", source.Capacity).
+		Where("amenities @> ?::jsonb", string(amenities)).
+		Where("NOT EXISTS (?)", busy).
+		Where("NOT EXISTS (?)", maintenanceBlocks(r.db, start, end)).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "ABS(price_per_hour - ?), (floor_id IS NOT DISTINCT FROM ?) DESC, id",
+			Vars:               []any{source.PricePerHour, source.FloorID},
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Find(&places).Error
+	if err != nil {
+		r.logger.Error("FindCandidates failed", "place_id", source.ID, "error", err)
+		return nil, err
+	}
+	return places, nil
+}
+
+func (r *relocationRepository) SaveRelocation(rel *models.BookingRelocation) error {
+	var existing models.BookingRelocation
+	err := r.db.Where("booking_id = ? AND status = ?", rel.BookingID, models.RelocationPending).First(&existing).Error
+	switch {
+	case err == nil:
+		rel.ID = existing.ID
+		rel.CreatedAt = existing.CreatedAt
+		err = r.db.Omit(clause.Associations).Save(rel).Error
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		err = r.db.Omit(clause.Associations).Create(rel).Error
+	}
+	if err != nil {
+		r.logger.Error("SaveRelocation failed", "booking_id", rel.BookingID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *relocationRepository) GetRelocation(id uint) (*models.BookingRelocation, error) {
+	var rel models.BookingRelocation
+	if err := r.db.Preload("Booking").Preload("Booking.Place").First(&rel, id).Error; err != nil {
+		return nil, err
+	}
+	return &rel, nil
+}
+
+func (r *relocationRepository) LockRelocation(tx *gorm.DB, id uint) (*models.BookingRelocation, error) {
+	var rel models.BookingRelocation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&rel, id).Error; err != nil {
+		return nil, err
+	}
+	return &rel, nil
+}
+
+func (r *relocationRepository) ResolveRelocation(tx *gorm.DB, rel *models.BookingRelocation) error {
+	err := tx.Model(rel).
+		Select("status", "to_place_id", "new_price", "difference", "error", "resolved_by", "resolved_at").
+		Updates(rel).Error
+	if err != nil {
+		r.logger.Error("ResolveRelocation failed", "relocation_id", rel.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *relocationRepository) ListRelocations(filter *models.FilterRelocation) ([]models.BookingRelocation, int64, error) {
+	query := r.db.Model(&models.BookingRelocation{})
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+	if filter.FromPlaceID != nil {
+		query = query.Where("from_place_id = ?", *filter.FromPlaceID)
+	}
+	if filter.LocationIDs != nil {
+		query = query.Where("from_place_id IN (?)", r.db.Model(&models.Place{}).Unscoped().Select("id").Where("location_id IN ?", filter.LocationIDs))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		r.logger.Error("ListRelocations count failed", "error", err)
+		return nil, 0, err
+	}
+
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	var items []models.BookingRelocation
+	if err := query.Preload("Booking").Order("created_at, id").Limit(filter.Limit).Offset(filter.Offset).Find(&items).Error; err != nil {
+		r.logger.Error("ListRelocations failed", "error", err)
+		return nil, 0, err
+	}
+	return items, total, nil
+}
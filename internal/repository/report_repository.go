@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"gorm.io/gorm"
+)
+
+// ReportQuery — нормализованные параметры отчета, интервал [From, To)
+type ReportQuery struct {
+	From    time.Time
+	To      time.Time
+	Period  models.ReportPeriod
+	GroupBy models.ReportGroupBy
+	PlaceID *uint
+	Type    *string
+
+	LocationID  *uint
+	LocationIDs []uint
+}
+
+// ReportPlace — место с часовым поясом его площадки, по которому считаются часы работы
+type ReportPlace struct {
+	ID       uint
+	Name     string
+	Type     models.PlaceType
+	Timezone string
+}
+
+// ReportDefaultTimezone — пояс мест без площадки, как у models.Location по умолчанию
+const ReportDefaultTimezone = "Europe/Moscow"
+
+// Интервалы отчета — местные даты площадки: бронь попадает в тот день, в который
+// она началась по времени своей площадки, независимо от пояса сессии БД.
+// Границы From/To тоже понимаются как местное время без пояса.
+const (
+	reportTimezone   = "COALESCE(l.timezone, '" + ReportDefaultTimezone + "')"
+	reportLocalStart = "(b.start_time AT TIME ZONE " + reportTimezone + ")"
+)
+
+type ReportRepository interface {
+	Revenue(q ReportQuery) ([]models.RevenueRow, error)
+	BookedHours(q ReportQuery) ([]models.OccupancyRow, error)
+	ListReportPlaces(q ReportQuery) ([]ReportPlace, error)
+}
+
+type reportRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewReportRepository(db *gorm.DB, logger *slog.Logger) ReportRepository {
+	return &reportRepository{db: db, logger: logger}
+}
+
+// Revenue считает выручку по проводкам счета revenue, привязанным к броням.
+// Период определяется временем начала брони, а не датой проводки, чтобы отчет
+// совпадал с отчетом о загрузке.
+func (r *reportRepository) Revenue(q ReportQuery) ([]models.RevenueRow, error) {
+	cols, group := reportGrouping(q.GroupBy)
+
+	query := r.db.Table("ledger_entries le").
+		Select(cols+`,
+			COUNT(DISTINCT b.id) FILTER (WHERE le.type = ?) AS bookings,
+			COALESCE(SUM(le.amount) FILTER (WHERE le.type = ?), 0) AS gross,
+			COALESCE(-SUM(le.amount) FILTER (WHERE le.type = ?), 0) AS refunds`,
+			q.Period, models.LedgerBookingCharge, models.LedgerBookingCharge, models.LedgerRefund).
+		Joins("JOIN bookings b ON b.id = le.booking_id").
+		Joins("JOIN places p ON p.id = b.place_id").
+		Joins("LEFT JOIN locations l ON l.id = p.location_id").
+		Where("le.account = ? AND le.type IN ?", models.AccountRevenue, []models.LedgerEntryType{models.LedgerBookingCharge, models.LedgerRefund})
+	query = applyReportRange(query, q)
+	query = applyReportFilter(query, q)
+
+	var rows []models.RevenueRow
+	if err := query.Group(group).Order(group).Scan(&rows).Error; err != nil {
+		r.logger.Error("Revenue report failed", "error", err)
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].Net = rows[i].Gross - rows[i].Refunds
+	}
+	return rows, nil
+}
+
+// BookedHours суммирует длительность активных броней; часы работы считает сервис
+func (r *reportRepository) BookedHours(q ReportQuery) ([]models.OccupancyRow, error) {
+	cols, group := reportGrouping(q.GroupBy)
+
+	query := r.db.Table("bookings b").
+		Select(cols+`,
+			COUNT(*) AS bookings,
+			COALESCE(SUM(EXTRACT(EPOCH FROM b.end_time - b.start_time)) / 3600, 0) AS booked_hours`,
+			q.Period).
+		Joins("JOIN places p ON p.id = b.place_id").
+		Joins("LEFT JOIN locations l ON l.id = p.location_id").
+		Where("b.deleted_at IS NULL AND b.status = ?", models.BookingActive)
+	query = applyReportRange(query, q)
+	query = applyReportFilter(query, q)
+
+	var rows []models.OccupancyRow
+	if err := query.Group(group).Order(group).Scan(&rows).Error; err != nil {
+		r.logger.Error("BookedHours report failed", "error", err)
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *reportRepository) ListReportPlaces(q ReportQuery) ([]ReportPlace, error) {
+	query := r.db.Table("places p").
+		Select("p.id, p.name, p.type, "+reportTimezone+" AS timezone").
+		Joins("LEFT JOIN locations l ON l.id = p.location_id").
+		Where("p.deleted_at IS NULL AND p.is_active = ?", true)
+	if q.PlaceID != nil {
+		query = query.Where("p.id = ?", *q.PlaceID)
+	}
+	if q.Type != nil {
+		query = query.Where("p.type = ?", *q.Type)
+	}
+	if q.LocationID != nil {
+		query = query.Where("p.location_id = ?", *q.LocationID)
+	}
+	if q.LocationIDs != nil {
+		query = query.Where("p.location_id IN ?", q.LocationIDs)
+	}
+
+	var places []ReportPlace
+	if err := query.Order("p.id").Scan(&places).Error; err != nil {
+		r.logger.Error("ListReportPlaces failed", "error", err)
+		return nil, err
+	}
+	return places, nil
+}
+
+func reportGrouping(groupBy models.ReportGroupBy) (cols, group string) {
+	cols = "date_trunc(?, " + reportLocalStart + ") AS period"
+	group = "1"
+	switch groupBy {
+	case models.ReportByPlace:
+		cols += ", p.id AS place_id, p.name AS place_name, p.type AS place_type"
+		group += ", p.id, p.name, p.type"
+	case models.ReportByType:
+		cols += ", p.type AS place_type"
+		group += ", p.type"
+	}
+	return cols, group
+}
+
+// applyReportRange отбирает брони, начавшиеся в [From, To) по местному времени площадки
+func applyReportRange(query *gorm.DB, q ReportQuery) *gorm.DB {
+	const layout = "2006-01-02 15:04:05"
+	return query.Where(reportLocalStart+" >= CAST(? AS text)::timestamp AND "+reportLocalStart+" < CAST(? AS text)::timestamp",
+		q.From.Format(layout), q.To.Format(layout))
+}
+
+func applyReportFilter(query *gorm.DB, q ReportQuery) *gorm.DB {
+	if q.PlaceID != nil {
+		query = query.Where("b.place_id = ?", *q.PlaceID)
+	}
+	if q.Type != nil {
+		query = query.Where("p.type = ?", *q.Type)
+	}
+	if q.LocationID != nil {
+		query = query.Where("p.location_id = ?", *q.LocationID)
+	}
+	if q.LocationIDs != nil {
+		query = query.Where("p.location_id IN ?", q.LocationIDs)
+	}
+	return query
+}
@@ -2,10 +2,13 @@ package repository
 
 import (
 	"errors"
+	"fmt"
+	"time"
 	
 
 	"github.com/IslamCHup/coworking-manager-project/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ReviewRepository interface {
@@ -13,10 +16,35 @@ type ReviewRepository interface {
 	GetReview(id uint) (*models.Review, error)
 	UpdateReview(req *models.Review) error
 	 DeleteReview(id uint) error
+	// Moderate меняет статус модерации и пересчитывает рейтинг места
+	Moderate(id, adminID uint, status models.ReviewStatus, reason string) (*models.Review, error)
+	ListReviews(filter *models.FilterReviews) ([]models.Review, int64, error)
+	ListUserReviews(userID uint) ([]models.Review, error)
 
+	// GetByUserAndPlace возвращает nil, nil, если пользователь еще не оставлял отзыв
+	GetByUserAndPlace(userID, placeID uint) (*models.Review, error)
+	// FindCompletedBooking ищет завершенную бронь пользователя на месте: конкретную,
+	// если bookingID задан, иначе самую позднюю
+	FindCompletedBooking(userID, placeID uint, bookingID *uint, now time.Time) (*models.Booking, error)
+
+	// SetReply сохраняет ответ администрации; пустой текст удаляет ответ
+	SetReply(id, adminID uint, text string) (*models.Review, error)
+	// AddVote и RemoveVote идемпотентны и поддерживают helpful_count
+	AddVote(reviewID, userID uint) (*models.Review, error)
+	RemoveVote(reviewID, userID uint) (*models.Review, error)
+	// AddReport сохраняет жалобу и, если открытых жалоб набралось threshold,
+	// возвращает одобренный отзыв на модерацию
+	AddReport(report *models.ReviewReport, threshold int) (*models.Review, bool, error)
+	ListReports(reviewID uint) ([]models.ReviewReport, error)
+
+	// ListPlaceReviews возвращает только одобренные отзывы места
+	ListPlaceReviews(placeID uint, filter *models.FilterPlaceReviews) ([]models.Review, int64, error)
+	GetPlaceRating(placeID uint) (*models.PlaceRating, error)
 }
 
 var ErrReviewNil = errors.New("review nil")
+var ErrReportExists = errors.New("report already exists")
+var ErrReviewExists = errors.New("review already exists")
 
 type reviewRepository struct {
 	db *gorm.DB
@@ -40,10 +68,21 @@ func (r *reviewRepository) CreateReview(review *models.Review) error {
 	if review == nil {
 		return ErrReviewNil
 	}
-	if err := r.db.Create(review).Error; err != nil {
-		return err
-	}
-	return nil
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(review).Error; err != nil {
+			// параллельный запрос успел создать отзыв после проверки в сервисе
+			if isUniqueViolation(tx, err) {
+				return ErrReviewExists
+			}
+			return err
+		}
+		return AddOutboxEvent(tx, models.AggregateReview, review.ID, models.EventReviewCreated, models.ReviewEvent{
+			ReviewID: review.ID,
+			UserID:   review.UserID,
+			PlaceID:  review.PlaceID,
+			Rating:   review.Rating,
+		})
+	})
 }
 func (r *reviewRepository) GetReview(id uint) (*models.Review, error) {
 	var review models.Review
@@ -57,15 +96,332 @@ func (r *reviewRepository) GetReview(id uint) (*models.Review, error) {
 	return &review, nil
 }
 func (r *reviewRepository) UpdateReview(review *models.Review) error {
-	return r.db.Save(review).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var old models.Review
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&old, review.ID).Error; err != nil {
+			return err
+		}
+		// счетчики голосов и жалоб меняются параллельно, поэтому пишем только
+		// то, что правит автор, и сброс модерации
+		err := tx.Model(review).
+			Select("rating", "text", "status", "is_approved", "rejection_reason", "admin_id", "moderated_at").
+			Updates(review).Error
+		if err != nil {
+			return err
+		}
+		return moveRating(tx, &old, review)
+	})
 }
 func (r *reviewRepository) DeleteReview(id uint) error {
-	result := r.db.Delete(&models.Review{}, id)
-	if result.Error != nil {
-		return result.Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var old models.Review
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&old, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&old).Error; err != nil {
+			return err
+		}
+		return moveRating(tx, &old, nil)
+	})
+}
+
+func (r *reviewRepository) Moderate(id, adminID uint, status models.ReviewStatus, reason string) (*models.Review, error) {
+	var review models.Review
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&review, id).Error; err != nil {
+			return err
+		}
+		old := review
+		now := time.Now()
+		review.Status = status
+		review.IsApproved = status == models.ReviewApproved
+		review.RejectionReason = reason
+		review.AdminID = &adminID
+		review.ModeratedAt = &now
+		review.ReportCount = 0
+		err := tx.Model(&review).
+			Select("status", "is_approved", "rejection_reason", "admin_id", "moderated_at", "report_count").
+			Updates(&review).Error
+		if err != nil {
+			return err
+		}
+		// решение модератора закрывает накопившиеся жалобы
+		err = tx.Model(&models.ReviewReport{}).
+			Where("review_id = ? AND resolved_at IS NULL", id).
+			Update("resolved_at", now).Error
+		if err != nil {
+			return err
+		}
+		return moveRating(tx, &old, &review)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *reviewRepository) ListReviews(filter *models.FilterReviews) ([]models.Review, int64, error) {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	if filter.Status == "" {
+		filter.Status = models.ReviewPending
+	}
+
+	query := r.db.Model(&models.Review{}).Where("status = ?", filter.Status)
+	if filter.PlaceID != nil {
+		query = query.Where("place_id = ?", *filter.PlaceID)
+	}
+	if filter.LocationIDs != nil {
+		places := r.db.Unscoped().Model(&models.Place{}).Select("id").Where("location_id IN ?", filter.LocationIDs)
+		query = query.Where("place_id IN (?)", places)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// старые отзывы модерируются первыми
+	var reviews []models.Review
+	err := query.Order("created_at, id").Limit(filter.Limit).Offset(filter.Offset).Find(&reviews).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
+
+func (r *reviewRepository) ListUserReviews(userID uint) ([]models.Review, error) {
+	var reviews []models.Review
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC, id DESC").Find(&reviews).Error; err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+func (r *reviewRepository) ListPlaceReviews(placeID uint, filter *models.FilterPlaceReviews) ([]models.Review, int64, error) {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	sortBy := filter.SortBy
+	if sortBy != "rating" {
+		sortBy = "created_at"
+	}
+	order := filter.Order
+	if order != "asc" {
+		order = "desc"
+	}
+
+	query := r.db.Model(&models.Review{}).Where("place_id = ? AND is_approved = ?", placeID, true)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reviews []models.Review
+	err := query.Order(sortBy + " " + order).Order("id " + order).
+		Limit(filter.Limit).Offset(filter.Offset).
+		Find(&reviews).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
+
+func (r *reviewRepository) GetPlaceRating(placeID uint) (*models.PlaceRating, error) {
+	var rating models.PlaceRating
+	err := r.db.Where("place_id = ?", placeID).First(&rating).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.PlaceRating{PlaceID: placeID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rating, nil
+}
+
+// moveRating переносит вклад отзыва в рейтинг места: убирает старое состояние,
+// если отзыв был одобрен, и добавляет новое; next == nil — отзыв удален
+func moveRating(tx *gorm.DB, old, next *models.Review) error {
+	wasCounted := old != nil && old.IsApproved
+	isCounted := next != nil && next.IsApproved
+	if wasCounted && isCounted && old.PlaceID == next.PlaceID && old.Rating == next.Rating {
+		return nil
+	}
+	if wasCounted {
+		if err := adjustPlaceRating(tx, old.PlaceID, old.Rating, -1); err != nil {
+			return err
+		}
 	}
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
+	if isCounted {
+		return adjustPlaceRating(tx, next.PlaceID, next.Rating, 1)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// adjustPlaceRating добавляет (delta = 1) или убирает (delta = -1) одну оценку
+// из агрегатов места одним upsert, без пересчета по всем отзывам
+func adjustPlaceRating(tx *gorm.DB, placeID uint, rating, delta int) error {
+	if rating < 1 || rating > 5 {
+		return fmt.Errorf("рейтинг вне диапазона: %d", rating)
+	}
+	stars := fmt.Sprintf("stars_%d", rating)
+
+	average := 0
+	if delta > 0 {
+		average = rating
+	}
+	return tx.Exec(`INSERT INTO place_ratings (place_id, count, sum, average, `+stars+`, updated_at)
+		VALUES (?, ?, ?, ?, ?, NOW())
+		ON CONFLICT (place_id) DO UPDATE SET
+			count = place_ratings.count + EXCLUDED.count,
+			sum = place_ratings.sum + EXCLUDED.sum,
+			`+stars+` = place_ratings.`+stars+` + EXCLUDED.`+stars+`,
+			average = CASE WHEN place_ratings.count + EXCLUDED.count > 0
+				THEN round((place_ratings.sum + EXCLUDED.sum)::numeric / (place_ratings.count + EXCLUDED.count), 2)
+				ELSE 0 END,
+			updated_at = NOW()`,
+		placeID, delta, delta*rating, average, delta).Error
+}
+
+func (r *reviewRepository) FindCompletedBooking(userID, placeID uint, bookingID *uint, now time.Time) (*models.Booking, error) {
+	query := r.db.Where("user_id = ? AND place_id = ?", userID, placeID).
+		Where("status = ? AND end_time <= ?", models.BookingActive, now)
+	if bookingID != nil {
+		query = query.Where("id = ?", *bookingID)
+	}
+
+	var booking models.Booking
+	if err := query.Order("end_time DESC").First(&booking).Error; err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+func (r *reviewRepository) SetReply(id, adminID uint, text string) (*models.Review, error) {
+	var review models.Review
+	if err := r.db.First(&review, id).Error; err != nil {
+		return nil, err
+	}
+
+	review.Reply = text
+	review.ReplyAdminID = nil
+	review.RepliedAt = nil
+	if text != "" {
+		now := time.Now()
+		review.ReplyAdminID = &adminID
+		review.RepliedAt = &now
+	}
+	err := r.db.Model(&review).Select("reply", "reply_admin_id", "replied_at").Updates(&review).Error
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *reviewRepository) AddVote(reviewID, userID uint) (*models.Review, error) {
+	return r.vote(reviewID, func(tx *gorm.DB) (int, error) {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.ReviewVote{ReviewID: reviewID, UserID: userID})
+		return int(res.RowsAffected), res.Error
+	})
+}
+
+func (r *reviewRepository) RemoveVote(reviewID, userID uint) (*models.Review, error) {
+	return r.vote(reviewID, func(tx *gorm.DB) (int, error) {
+		res := tx.Where("review_id = ? AND user_id = ?", reviewID, userID).Delete(&models.ReviewVote{})
+		return -int(res.RowsAffected), res.Error
+	})
+}
+
+// vote меняет голоса и сдвигает счетчик на число реально добавленных или удаленных строк
+func (r *reviewRepository) vote(reviewID uint, change func(tx *gorm.DB) (int, error)) (*models.Review, error) {
+	var review models.Review
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		delta, err := change(tx)
+		if err != nil {
+			return err
+		}
+		if delta != 0 {
+			err := tx.Model(&models.Review{}).Where("id = ?", reviewID).
+				Update("helpful_count", gorm.Expr("helpful_count + ?", delta)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.First(&review, reviewID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *reviewRepository) AddReport(report *models.ReviewReport, threshold int) (*models.Review, bool, error) {
+	var review models.Review
+	requeued := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// блокировка отзыва упорядочивает параллельные жалобы на него
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&review, report.ReviewID).Error; err != nil {
+			return err
+		}
+
+		var open int64
+		err := tx.Model(&models.ReviewReport{}).
+			Where("review_id = ? AND user_id = ? AND resolved_at IS NULL", report.ReviewID, report.UserID).
+			Count(&open).Error
+		if err != nil {
+			return err
+		}
+		if open > 0 {
+			return ErrReportExists
+		}
+		if err := tx.Create(report).Error; err != nil {
+			if isUniqueViolation(tx, err) {
+				return ErrReportExists
+			}
+			return err
+		}
+
+		old := review
+		review.ReportCount++
+		fields := []string{"report_count"}
+		if review.Status == models.ReviewApproved && review.ReportCount >= threshold {
+			review.Status = models.ReviewPending
+			review.IsApproved = false
+			fields = append(fields, "status", "is_approved")
+			requeued = true
+		}
+		if err := tx.Model(&review).Select(fields).Updates(&review).Error; err != nil {
+			return err
+		}
+		return moveRating(tx, &old, &review)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &review, requeued, nil
+}
+
+func (r *reviewRepository) ListReports(reviewID uint) ([]models.ReviewReport, error) {
+	var reports []models.ReviewReport
+	if err := r.db.Where("review_id = ?", reviewID).Order("created_at DESC, id DESC").Find(&reports).Error; err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// isUniqueViolation сообщает, что запрос нарушил уникальный индекс
+func isUniqueViolation(db *gorm.DB, err error) bool {
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
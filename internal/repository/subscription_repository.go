@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SubscriptionRepository interface {
+	CreatePlan(plan *models.Plan) error
+	GetPlan(id uint) (*models.Plan, error)
+	ListPlans(onlyActive bool) ([]models.Plan, error)
+	UpdatePlan(plan *models.Plan) error
+	DeletePlan(id uint) error
+
+	GetActiveSubscription(userID uint) (*models.Subscription, error)
+	ListDueRenewals(now time.Time, limit int) ([]models.Subscription, error)
+
+	// Методы ниже работают внутри транзакции и блокируют строки FOR UPDATE
+	LockActiveSubscription(tx *gorm.DB, userID uint) (*models.Subscription, error)
+	LockSubscription(tx *gorm.DB, id uint) (*models.Subscription, error)
+	LockBucketFor(tx *gorm.DB, userID uint, placeType models.PlaceType, at time.Time) (*models.HourBucket, error)
+	LockBucket(tx *gorm.DB, id uint) (*models.HourBucket, error)
+	ListBuckets(tx *gorm.DB, subscriptionID uint, periodStart time.Time) ([]models.HourBucket, error)
+}
+
+type subscriptionRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewSubscriptionRepository(db *gorm.DB, logger *slog.Logger) SubscriptionRepository {
+	return &subscriptionRepository{db: db, logger: logger}
+}
+
+func (r *subscriptionRepository) CreatePlan(plan *models.Plan) error {
+	if err := r.db.Create(plan).Error; err != nil {
+		r.logger.Error("CreatePlan failed", "error", err)
+		return err
+	}
+	r.logger.Info("plan created", "plan_id", plan.ID, "name", plan.Name)
+	return nil
+}
+
+func (r *subscriptionRepository) GetPlan(id uint) (*models.Plan, error) {
+	var plan models.Plan
+	if err := r.db.Preload("Allowances").First(&plan, id).Error; err != nil {
+		r.logger.Warn("GetPlan failed", "plan_id", id, "error", err)
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (r *subscriptionRepository) ListPlans(onlyActive bool) ([]models.Plan, error) {
+	var plans []models.Plan
+	q := r.db.Preload("Allowances").Order("price asc")
+	if onlyActive {
+		q = q.Where("is_active = ?", true)
+	}
+	if err := q.Find(&plans).Error; err != nil {
+		r.logger.Error("ListPlans failed", "error", err)
+		return nil, err
+	}
+	return plans, nil
+}
+
+// UpdatePlan целиком заменяет набор включенных часов плана
+func (r *subscriptionRepository) UpdatePlan(plan *models.Plan) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("plan_id = ?", plan.ID).Delete(&models.PlanAllowance{}).Error; err != nil {
+			return err
+		}
+		for i := range plan.Allowances {
+			plan.Allowances[i].ID = 0
+			plan.Allowances[i].PlanID = plan.ID
+		}
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(plan).Error
+	})
+	if err != nil {
+		r.logger.Error("UpdatePlan failed", "plan_id", plan.ID, "error", err)
+		return err
+	}
+	r.logger.Info("plan updated", "plan_id", plan.ID)
+	return nil
+}
+
+func (r *subscriptionRepository) DeletePlan(id uint) error {
+	res := r.db.Delete(&models.Plan{}, id)
+	if res.Error != nil {
+		r.logger.Error("DeletePlan failed", "plan_id", id, "error", res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	r.logger.Info("plan deleted", "plan_id", id)
+	return nil
+}
+
+func (r *subscriptionRepository) GetActiveSubscription(userID uint) (*models.Subscription, error) {
+	var sub models.Subscription
+	err := r.db.
+		Preload("Plan.Allowances").
+		Where("user_id = ? AND status = ?", userID, models.SubscriptionActive).
+		First(&sub).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.db.Where("subscription_id = ? AND period_start = ?", sub.ID, sub.PeriodStart).
+		Order("place_type").Find(&sub.Buckets).Error; err != nil {
+		r.logger.Error("failed to load hour buckets", "subscription_id", sub.ID, "error", err)
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *subscriptionRepository) ListDueRenewals(now time.Time, limit int) ([]models.Subscription, error) {
+	var subs []models.Subscription
+	err := r.db.
+		Where("status = ? AND period_end <= ?", models.SubscriptionActive, now).
+		Order("period_end").
+		Limit(limit).
+		Find(&subs).Error
+	if err != nil {
+		r.logger.Error("ListDueRenewals failed", "error", err)
+		return nil, err
+	}
+	return subs, nil
+}
+
+func (r *subscriptionRepository) LockActiveSubscription(tx *gorm.DB, userID uint) (*models.Subscription, error) {
+	var sub models.Subscription
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND status = ?", userID, models.SubscriptionActive).
+		First(&sub).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *subscriptionRepository) LockSubscription(tx *gorm.DB, id uint) (*models.Subscription, error) {
+	var sub models.Subscription
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sub, id).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// LockBucketFor ищет остаток часов активного абонемента пользователя
+// на период, в который попадает момент at
+func (r *subscriptionRepository) LockBucketFor(tx *gorm.DB, userID uint, placeType models.PlaceType, at time.Time) (*models.HourBucket, error) {
+	var bucket models.HourBucket
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "hour_buckets"}}).
+		Joins("JOIN subscriptions ON subscriptions.id = hour_buckets.subscription_id AND subscriptions.deleted_at IS NULL").
+		Where("subscriptions.user_id = ? AND subscriptions.status = ?", userID, models.SubscriptionActive).
+		Where("hour_buckets.place_type = ? AND hour_buckets.period_start <= ? AND hour_buckets.period_end > ?", placeType, at, at).
+		First(&bucket).Error
+	if err != nil {
+		return nil, err
+	}
+	return &bucket, nil
+}
+
+func (r *subscriptionRepository) LockBucket(tx *gorm.DB, id uint) (*models.HourBucket, error) {
+	var bucket models.HourBucket
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bucket, id).Error; err != nil {
+		return nil, err
+	}
+	return &bucket, nil
+}
+
+func (r *subscriptionRepository) ListBuckets(tx *gorm.DB, subscriptionID uint, periodStart time.Time) ([]models.HourBucket, error) {
+	var buckets []models.HourBucket
+	err := tx.Where("subscription_id = ? AND period_start = ?", subscriptionID, periodStart).Find(&buckets).Error
+	return buckets, err
+}
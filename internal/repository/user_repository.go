@@ -16,7 +16,7 @@ type UserRepository interface {
 	DeleteUser(id uint) error
 
 	GetAllUsers() ([]models.User, error)
-	UpdateUserBalance(userID uint, amount int) error
+	UpdateBillingSettings(userID uint, req models.BillingSettingsDTO) error
 }
 
 type userRepository struct {
@@ -81,7 +81,15 @@ func (r *userRepository) CreateUser(user *models.User) error {
 }
 
 func (r *userRepository) UpdateUser(user *models.User) error {
-	if err := r.db.Save(user).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// баланс и настройки оплаты меняются только через журнал проводок,
+		// поэтому сохраняются лишь редактируемые поля профиля
+		if err := tx.Model(user).Select("first_name", "last_name").Updates(user).Error; err != nil {
+			return err
+		}
+		return AddOutboxEvent(tx, models.AggregateUser, user.ID, models.EventUserUpdated, models.UserEvent{UserID: user.ID})
+	})
+	if err != nil {
 		r.logger.Error(
 			"UpdateUser failed",
 			"user_id", user.ID,
@@ -98,16 +106,27 @@ func (r *userRepository) UpdateUser(user *models.User) error {
 }
 
 func (r *userRepository) DeleteUser(id uint) error {
-	res := r.db.Delete(&models.User{}, id)
-	if res.Error != nil {
-		r.logger.Error("DeleteUser failed", "user_id", id, "error", res.Error)
-		return res.Error
+	var rows int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Delete(&models.User{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		rows = res.RowsAffected
+		if rows == 0 {
+			return nil
+		}
+		return AddOutboxEvent(tx, models.AggregateUser, id, models.EventUserDeleted, models.UserEvent{UserID: id})
+	})
+	if err != nil {
+		r.logger.Error("DeleteUser failed", "user_id", id, "error", err)
+		return err
 	}
 
 	r.logger.Info(
 		"DeleteUser success",
 		"user_id", id,
-		"rows", res.RowsAffected,
+		"rows", rows,
 	)
 	return nil
 }
@@ -124,31 +143,27 @@ func (r *userRepository) GetAllUsers() ([]models.User, error) {
 	return users, nil
 }
 
-func (r *userRepository) UpdateUserBalance(userID uint, amount int) error {
-	result := r.db.
-		Model(&models.User{}).
-		Where("id = ?", userID).
-		Update("balance", gorm.Expr("balance + ?", amount))
-
-	if result.Error != nil {
-		r.logger.Error(
-			"UpdateUserBalance failed",
-			"user_id", userID,
-			"amount", amount,
-			"error", result.Error,
-		)
-		return result.Error
+func (r *userRepository) UpdateBillingSettings(userID uint, req models.BillingSettingsDTO) error {
+	updates := map[string]any{}
+	if req.Postpaid != nil {
+		updates["postpaid"] = *req.Postpaid
+	}
+	if req.CreditLimit != nil {
+		updates["credit_limit"] = *req.CreditLimit
+	}
+	if len(updates) == 0 {
+		return nil
 	}
 
-	if result.RowsAffected == 0 {
-		r.logger.Warn("UpdateUserBalance: user not found", "user_id", userID)
+	res := r.db.Model(&models.User{}).Where("id = ?", userID).Updates(updates)
+	if res.Error != nil {
+		r.logger.Error("UpdateBillingSettings failed", "user_id", userID, "error", res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
 		return gorm.ErrRecordNotFound
 	}
 
-	r.logger.Info(
-		"UpdateUserBalance success",
-		"user_id", userID,
-		"amount", amount,
-	)
+	r.logger.Info("UpdateBillingSettings success", "user_id", userID, "settings", updates)
 	return nil
 }
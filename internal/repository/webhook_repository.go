@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(sub *models.WebhookSubscription) error
+	GetSubscription(id uint) (*models.WebhookSubscription, error)
+	ListSubscriptions() ([]models.WebhookSubscription, error)
+	ListActiveSubscriptions() ([]models.WebhookSubscription, error)
+	UpdateSubscription(sub *models.WebhookSubscription) error
+	DeleteSubscription(id uint) error
+
+	CreateDeliveries(deliveries []models.WebhookDelivery) error
+	GetDelivery(id uint) (*models.WebhookDelivery, error)
+	ListDeliveries(subscriptionID uint, filter *models.FilterWebhookDelivery) ([]models.WebhookDelivery, error)
+	ClaimDueDeliveries(limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	UpdateDelivery(delivery *models.WebhookDelivery) error
+}
+
+type webhookRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewWebhookRepository(db *gorm.DB, logger *slog.Logger) WebhookRepository {
+	return &webhookRepository{db: db, logger: logger}
+}
+
+func (r *webhookRepository) CreateSubscription(sub *models.WebhookSubscription) error {
+	if err := r.db.Create(sub).Error; err != nil {
+		r.logger.Error("CreateSubscription failed", "url", sub.URL, "error", err)
+		return err
+	}
+	r.logger.Info("webhook subscription created", "subscription_id", sub.ID, "url", sub.URL)
+	return nil
+}
+
+func (r *webhookRepository) GetSubscription(id uint) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	if err := r.db.First(&sub, id).Error; err != nil {
+		r.logger.Warn("GetSubscription failed", "subscription_id", id, "error", err)
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *webhookRepository) ListSubscriptions() ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	if err := r.db.Order("id asc").Find(&subs).Error; err != nil {
+		r.logger.Error("ListSubscriptions failed", "error", err)
+		return nil, err
+	}
+	return subs, nil
+}
+
+func (r *webhookRepository) ListActiveSubscriptions() ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	if err := r.db.Where("is_active = ?", true).Find(&subs).Error; err != nil {
+		r.logger.Error("ListActiveSubscriptions failed", "error", err)
+		return nil, err
+	}
+	return subs, nil
+}
+
+func (r *webhookRepository) UpdateSubscription(sub *models.WebhookSubscription) error {
+	if err := r.db.Save(sub).Error; err != nil {
+		r.logger.Error("UpdateSubscription failed", "subscription_id", sub.ID, "error", err)
+		return err
+	}
+	r.logger.Info("webhook subscription updated", "subscription_id", sub.ID)
+	return nil
+}
+
+func (r *webhookRepository) DeleteSubscription(id uint) error {
+	res := r.db.Delete(&models.WebhookSubscription{}, id)
+	if res.Error != nil {
+		r.logger.Error("DeleteSubscription failed", "subscription_id", id, "error", res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	r.logger.Info("webhook subscription deleted", "subscription_id", id)
+	return nil
+}
+
+func (r *webhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	// событие могло прийти повторно (at-least-once), дубли пропускаем
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error; err != nil {
+		r.logger.Error("CreateDeliveries failed", "count", len(deliveries), "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *webhookRepository) GetDelivery(id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.db.First(&delivery, id).Error; err != nil {
+		r.logger.Warn("GetDelivery failed", "delivery_id", id, "error", err)
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepository) ListDeliveries(subscriptionID uint, filter *models.FilterWebhookDelivery) ([]models.WebhookDelivery, error) {
+	if filter == nil {
+		filter = &models.FilterWebhookDelivery{}
+	}
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	query := r.db.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("id desc").Limit(filter.Limit).Offset(filter.Offset).Find(&deliveries).Error; err != nil {
+		r.logger.Error("ListDeliveries failed", "subscription_id", subscriptionID, "error", err)
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ClaimDueDeliveries забирает доставки, время которых подошло, и сдвигает
+// next_attempt_at на lease, чтобы другие воркеры не взяли их одновременно
+func (r *webhookRepository) ClaimDueDeliveries(limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, time.Now()).
+			Order("id asc").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(deliveries))
+		for _, d := range deliveries {
+			ids = append(ids, d.ID)
+		}
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", time.Now().Add(lease)).Error
+	})
+	if err != nil {
+		r.logger.Error("ClaimDueDeliveries failed", "error", err)
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookRepository) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	if err := r.db.Save(delivery).Error; err != nil {
+		r.logger.Error("UpdateDelivery failed", "delivery_id", delivery.ID, "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAdjustmentNotFound  = errors.New("корректировка не найдена")
+	ErrAdjustmentProcessed = repository.ErrAdjustmentProcessed
+	ErrSelfApproval        = errors.New("подтвердить корректировку должен другой администратор")
+)
+
+type AdjustmentService interface {
+	// AdjustUser и AdjustOrganization применяют корректировку сразу, если ее сумма
+	// не превышает порог; иначе она ждет подтверждения вторым администратором
+	AdjustUser(adminID, userID uint, req models.BalanceAdjustmentDTO) (*models.BalanceAdjustment, error)
+	AdjustOrganization(adminID, orgID uint, req models.BalanceAdjustmentDTO) (*models.BalanceAdjustment, error)
+
+	List(filter *models.FilterAdjustments) ([]models.BalanceAdjustment, int64, error)
+	Approve(adminID, id uint, comment string) (*models.BalanceAdjustment, error)
+	Reject(adminID, id uint, comment string) (*models.BalanceAdjustment, error)
+	Report(from, to time.Time) ([]models.AdjustmentReportRow, error)
+}
+
+type adjustmentService struct {
+	repo      repository.AdjustmentRepository
+	threshold int
+	logger    *slog.Logger
+}
+
+// threshold — сумма в копейках по модулю, выше которой нужна вторая подпись
+func NewAdjustmentService(repo repository.AdjustmentRepository, threshold int, logger *slog.Logger) AdjustmentService {
+	return &adjustmentService{repo: repo, threshold: threshold, logger: logger}
+}
+
+func (s *adjustmentService) AdjustUser(adminID, userID uint, req models.BalanceAdjustmentDTO) (*models.BalanceAdjustment, error) {
+	return s.request(adminID, &models.BalanceAdjustment{UserID: &userID}, req)
+}
+
+func (s *adjustmentService) AdjustOrganization(adminID, orgID uint, req models.BalanceAdjustmentDTO) (*models.BalanceAdjustment, error) {
+	return s.request(adminID, &models.BalanceAdjustment{OrganizationID: &orgID}, req)
+}
+
+func (s *adjustmentService) request(adminID uint, adj *models.BalanceAdjustment, req models.BalanceAdjustmentDTO) (*models.BalanceAdjustment, error) {
+	if req.Amount == 0 {
+		return nil, errors.New("amount не может быть 0")
+	}
+
+	exists, err := s.repo.TargetExists(adj.UserID, adj.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	adj.Amount = req.Amount
+	adj.ReasonCode = req.ReasonCode
+	adj.Comment = strings.TrimSpace(req.Comment)
+	adj.RequestedBy = adminID
+	adj.Status = models.AdjustmentApplied
+	if s.requiresApproval(req.Amount) {
+		adj.Status = models.AdjustmentPending
+	}
+
+	if err := s.repo.CreateAdjustment(adj); err != nil {
+		return nil, err
+	}
+
+	if adj.Status == models.AdjustmentPending {
+		s.logger.Warn("balance adjustment awaits approval", "adjustment_id", adj.ID, "amount", adj.Amount, "admin_id", adminID, "threshold", s.threshold)
+	}
+	return adj, nil
+}
+
+func (s *adjustmentService) requiresApproval(amount int) bool {
+	if amount < 0 {
+		amount = -amount
+	}
+	return s.threshold > 0 && amount > s.threshold
+}
+
+func (s *adjustmentService) List(filter *models.FilterAdjustments) ([]models.BalanceAdjustment, int64, error) {
+	return s.repo.ListAdjustments(filter)
+}
+
+func (s *adjustmentService) Approve(adminID, id uint, comment string) (*models.BalanceAdjustment, error) {
+	adj, err := s.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if adj.RequestedBy == adminID {
+		return nil, ErrSelfApproval
+	}
+	return s.repo.ApproveAdjustment(id, adminID, strings.TrimSpace(comment))
+}
+
+// Reject отклоняет ожидающую корректировку; автор может отозвать свою сам
+func (s *adjustmentService) Reject(adminID, id uint, comment string) (*models.BalanceAdjustment, error) {
+	if _, err := s.get(id); err != nil {
+		return nil, err
+	}
+	return s.repo.RejectAdjustment(id, adminID, strings.TrimSpace(comment))
+}
+
+func (s *adjustmentService) Report(from, to time.Time) ([]models.AdjustmentReportRow, error) {
+	if !to.After(from) {
+		return nil, errors.New("конец периода должен быть позже начала")
+	}
+	return s.repo.Report(from, to)
+}
+
+func (s *adjustmentService) get(id uint) (*models.BalanceAdjustment, error) {
+	adj, err := s.repo.GetAdjustment(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAdjustmentNotFound
+		}
+		return nil, err
+	}
+	return adj, nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/realtime"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+)
+
+type AvailabilityService interface {
+	HandleEvent(ctx context.Context, ev *models.OutboxEvent) error
+	Subscribe(filter models.FilterOccupancy) (<-chan models.OccupancyEvent, func())
+	Since(ctx context.Context, lastID string, filter models.FilterOccupancy) ([]models.OccupancyEvent, error)
+}
+
+type availabilityService struct {
+	broker    *realtime.Broker
+	placeRepo repository.PlaceRepository
+	logger    *slog.Logger
+}
+
+func NewAvailabilityService(broker *realtime.Broker, placeRepo repository.PlaceRepository, logger *slog.Logger) AvailabilityService {
+	return &availabilityService{broker: broker, placeRepo: placeRepo, logger: logger}
+}
+
+// HandleEvent переводит события брони из outbox в изменения занятости места
+func (s *availabilityService) HandleEvent(ctx context.Context, ev *models.OutboxEvent) error {
+	var payload models.BookingEvent
+	if err := json.Unmarshal([]byte(ev.Payload), &payload); err != nil {
+		s.logger.Error("invalid booking event payload", "event_id", ev.ID, "error", err)
+		return nil
+	}
+
+	// Перенос активной брони освобождает прежнее место и занимает новое
+	if ev.EventType == models.EventBookingRelocated {
+		if payload.Status != models.BookingActive {
+			return nil
+		}
+		var relocation models.RelocationEvent
+		if err := json.Unmarshal([]byte(ev.Payload), &relocation); err != nil {
+			s.logger.Error("invalid relocation event payload", "event_id", ev.ID, "error", err)
+			return nil
+		}
+		if err := s.publish(ctx, ev, models.OccupancyReleased, relocation.FromPlaceID, payload); err != nil {
+			return err
+		}
+		return s.publish(ctx, ev, models.OccupancyBooked, payload.PlaceID, payload)
+	}
+
+	// Изменение активной брони администратором: прежний слот освобождается,
+	// новый занимается
+	if ev.EventType == models.EventBookingUpdated {
+		if payload.Status != models.BookingActive {
+			return nil
+		}
+		var update models.BookingUpdatedEvent
+		if err := json.Unmarshal([]byte(ev.Payload), &update); err != nil {
+			s.logger.Error("invalid booking update event payload", "event_id", ev.ID, "error", err)
+			return nil
+		}
+		if update.FromPlaceID == payload.PlaceID && update.FromStartTime.Equal(payload.StartTime) && update.FromEndTime.Equal(payload.EndTime) {
+			return nil
+		}
+		previous := payload
+		previous.StartTime, previous.EndTime = update.FromStartTime, update.FromEndTime
+		if err := s.publish(ctx, ev, models.OccupancyReleased, update.FromPlaceID, previous); err != nil {
+			return err
+		}
+		return s.publish(ctx, ev, models.OccupancyBooked, payload.PlaceID, payload)
+	}
+
+	kind, ok := occupancyKind(ev.EventType, payload)
+	if !ok {
+		return nil
+	}
+	return s.publish(ctx, ev, kind, payload.PlaceID, payload)
+}
+
+func (s *availabilityService) publish(ctx context.Context, ev *models.OutboxEvent, kind models.OccupancyKind, placeID uint, payload models.BookingEvent) error {
+	place, err := s.placeRepo.GetPlaceByID(placeID)
+	if err != nil {
+		return err
+	}
+
+	return s.broker.Publish(ctx, models.OccupancyEvent{
+		Kind:      kind,
+		PlaceID:   placeID,
+		PlaceType: place.Type,
+		BookingID: payload.BookingID,
+		StartTime: payload.StartTime,
+		EndTime:   payload.EndTime,
+		At:        ev.CreatedAt,
+	})
+}
+
+func (s *availabilityService) Subscribe(filter models.FilterOccupancy) (<-chan models.OccupancyEvent, func()) {
+	return s.broker.Subscribe(filter)
+}
+
+func (s *availabilityService) Since(ctx context.Context, lastID string, filter models.FilterOccupancy) ([]models.OccupancyEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	return s.broker.Since(ctx, lastID, filter)
+}
+
+// Место занято только активной бронью, поэтому booked/released считаются
+// по переходам в статус active и из него
+func occupancyKind(eventType models.EventType, payload models.BookingEvent) (models.OccupancyKind, bool) {
+	switch eventType {
+	case models.EventBookingStatusChanged:
+		if payload.Status == models.BookingActive {
+			return models.OccupancyBooked, true
+		}
+		if payload.OldStatus == models.BookingActive {
+			return models.OccupancyReleased, true
+		}
+	case models.EventBookingDeleted:
+		if payload.Status == models.BookingActive {
+			return models.OccupancyReleased, true
+		}
+	case models.EventBookingCheckedIn:
+		return models.OccupancyCheckedIn, true
+	}
+	return "", false
+}
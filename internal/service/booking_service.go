@@ -13,6 +13,12 @@ import (
 	"github.com/IslamCHup/coworking-manager-project/internal/redis"
 	"github.com/IslamCHup/coworking-manager-project/internal/repository"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientFunds   = repository.ErrInsufficientFunds
+	ErrCreditLimitExceeded = repository.ErrCreditLimitExceeded
 )
 
 type BookingService interface {
@@ -22,24 +28,44 @@ type BookingService interface {
 	ListBooking(filter *models.FilterBooking) ([]models.Booking, error)
 	UpdateBook(id uint, req *models.BookingReqUpdateDTO) error
 	UpdateStatus(id uint, status models.BookingStatusUpdateDTO) error
-	UpdateBookingStatusWithBalance(id uint, newStatus models.BookingStatus) error
+	// UpdateBookingStatusWithBalance возвращает сумму, возвращенную на баланс при отмене
+	UpdateBookingStatusWithBalance(id uint, newStatus models.BookingStatus) (int, error)
+	UpdateBookingStatusWithBalanceTx(tx *gorm.DB, id uint, newStatus models.BookingStatus) (int, error)
+	CheckIn(userID, id uint) error
+	// Relocate переносит бронь на другое место на то же время; цена пересчитывается,
+	// а разница для оплаченной брони списывается или возвращается через журнал
+	Relocate(id, placeID uint) (*models.RelocationOutcome, error)
+	RelocateTx(tx *gorm.DB, id, placeID uint) (*models.RelocationOutcome, error)
+	HandleEvent(ctx context.Context, ev *models.OutboxEvent) error
 }
 
 type bookingService struct {
-	repo      repository.BookingRepository
-	placeRepo repository.PlaceRepository
-	db        *gorm.DB
-	logger    *slog.Logger
-	redis     *redis.Client
+	repo        repository.BookingRepository
+	placeRepo   repository.PlaceRepository
+	db          *gorm.DB
+	logger      *slog.Logger
+	redis       *redis.Client
+	pricing     PricingService
+	promos      PromoService
+	plans       SubscriptionService
+	orgs        OrganizationService
+	invoices    InvoiceService
+	maintenance repository.MaintenanceRepository
 }
 
-func NewBookingService(repo repository.BookingRepository, placeRepo repository.PlaceRepository, db *gorm.DB, logger *slog.Logger, redis *redis.Client) BookingService {
+func NewBookingService(repo repository.BookingRepository, placeRepo repository.PlaceRepository, db *gorm.DB, logger *slog.Logger, redis *redis.Client, pricing PricingService, promos PromoService, plans SubscriptionService, orgs OrganizationService, invoices InvoiceService, maintenance repository.MaintenanceRepository) BookingService {
 	return &bookingService{
-		repo:      repo,
-		placeRepo: placeRepo,
-		db:        db,
-		logger:    logger,
-		redis:     redis,
+		repo:        repo,
+		placeRepo:   placeRepo,
+		db:          db,
+		logger:      logger,
+		redis:       redis,
+		pricing:     pricing,
+		promos:      promos,
+		plans:       plans,
+		orgs:        orgs,
+		invoices:    invoices,
+		maintenance: maintenance,
 	}
 }
 
@@ -58,6 +84,9 @@ func (s *bookingService) Create(id uint, req models.BookingReqDTO) (*models.Book
 	if duration <= 0 {
 		return nil, errors.New("неверный диапазон времени: время окончания должно быть позже времени начала")
 	}
+	if end.Sub(start) > maxQuoteDuration {
+		return nil, ErrQuoteRangeTooLong
+	}
 
 	if start.Weekday() == time.Saturday || start.Weekday() == time.Sunday {
 		return nil, errors.New("нельзя бронировать на выходной день")
@@ -71,6 +100,21 @@ func (s *bookingService) Create(id uint, req models.BookingReqDTO) (*models.Book
 		return nil, errors.New("мы работаем с 9 до 18 часов")
 	}
 
+	// Владелец или букер организации может бронировать за другого участника
+	userID := id
+	if req.UserID != 0 && req.UserID != id {
+		if err := s.orgs.CanBookFor(id, req.UserID); err != nil {
+			s.logger.Warn("booking on behalf rejected", "booker_id", id, "user_id", req.UserID, "error", err)
+			return nil, err
+		}
+		userID = req.UserID
+	}
+
+	// Пока счет постоплаты просрочен, новые брони клиента не принимаются
+	if err := s.checkOverdue(userID); err != nil {
+		return nil, err
+	}
+
 	status := models.BookingActive
 	filter := models.FilterBooking{
 		PlaceID:   &req.PlaceID,
@@ -89,8 +133,12 @@ func (s *bookingService) Create(id uint, req models.BookingReqDTO) (*models.Book
 		return nil, errors.New("это время занято другими")
 	}
 
+	if err := s.checkMaintenance(req.PlaceID, start, end); err != nil {
+		return nil, err
+	}
+
 	booking := &models.Booking{
-		UserID:    id,
+		UserID:    userID,
 		PlaceID:   req.PlaceID,
 		StartTime: start,
 		EndTime:   end,
@@ -102,11 +150,25 @@ func (s *bookingService) Create(id uint, req models.BookingReqDTO) (*models.Book
 		s.logger.Error("failed to get place for price calculation", "place_id", req.PlaceID, "error", err)
 		return nil, errors.New("место не найдено")
 	}
+	if !place.IsActive {
+		return nil, errors.New("место недоступно для бронирования")
+	}
+
+	if req.PromoCode != "" {
+		promo, err := s.promos.Check(userID, req.PromoCode, place, start)
+		if err != nil {
+			s.logger.Warn("promo code rejected", "user_id", userID, "code", req.PromoCode, "error", err)
+			return nil, err
+		}
+		booking.PromoCodeID = &promo.ID
+	}
 
-	booking.TotalPrice = int(duration * float64(place.PricePerHour))
+	if err := s.applyQuote(booking, place); err != nil {
+		return nil, err
+	}
 
 	if err := s.repo.CreateBooking(booking); err != nil {
-		s.logger.Error("Create booking failed", "error", err, "user_id", req.UserID, "place_id", req.PlaceID)
+		s.logger.Error("Create booking failed", "error", err, "user_id", userID, "place_id", req.PlaceID)
 		return nil, err
 	}
 
@@ -236,6 +298,15 @@ func buildBookingCacheKey(filter *models.FilterBooking) string {
 	if filter.PlaceID != nil {
 		parts = append(parts, fmt.Sprintf("place:%d", *filter.PlaceID))
 	}
+	if filter.LocationID != nil {
+		parts = append(parts, fmt.Sprintf("location:%d", *filter.LocationID))
+	}
+	if filter.FloorID != nil {
+		parts = append(parts, fmt.Sprintf("floor:%d", *filter.FloorID))
+	}
+	if filter.ZoneID != nil {
+		parts = append(parts, fmt.Sprintf("zone:%d", *filter.ZoneID))
+	}
 
 	if filter.StartTime != nil && filter.EndTime != nil {
 		parts = append(parts, fmt.Sprintf(
@@ -352,6 +423,9 @@ func (s *bookingService) UpdateBook(id uint, req *models.BookingReqUpdateDTO) er
 		if len(bookings) > 0 {
 			return errors.New("это время занято другими")
 		}
+		if err := s.checkMaintenance(booking.PlaceID, booking.StartTime, booking.EndTime); err != nil {
+			return err
+		}
 
 		// Получаем информацию о месте для расчета цены
 		place, err := s.placeRepo.GetPlaceByID(booking.PlaceID)
@@ -360,8 +434,9 @@ func (s *bookingService) UpdateBook(id uint, req *models.BookingReqUpdateDTO) er
 			return errors.New("место не найдено")
 		}
 
-		// Цена в копейках: часы * цена за час места в копейках
-		booking.TotalPrice = int(durationHours * float64(place.PricePerHour))
+		if err := s.applyQuote(booking, place); err != nil {
+			return err
+		}
 	}
 
 	if err := s.repo.UpdateBook(id, booking); err != nil {
@@ -377,6 +452,20 @@ func (s *bookingService) UpdateBook(id uint, req *models.BookingReqUpdateDTO) er
 	return nil
 }
 
+// checkMaintenance не дает забронировать место, закрытое на обслуживание
+func (s *bookingService) checkMaintenance(placeID uint, start, end time.Time) error {
+	windows, err := s.maintenance.Overlapping(placeID, start, end)
+	if err != nil {
+		s.logger.Error("failed to check maintenance windows", "place_id", placeID, "error", err)
+		return err
+	}
+	if len(windows) > 0 {
+		w := windows[0]
+		return fmt.Errorf("%w: %s – %s, %s", ErrPlaceUnderMaintenance, w.StartTime.Format("02.01.2006 15:04"), w.EndTime.Format("02.01.2006 15:04"), w.Reason)
+	}
+	return nil
+}
+
 func (s *bookingService) UpdateStatus(id uint, status models.BookingStatusUpdateDTO) error {
 	booking, err := s.repo.GetBookingById(id)
 	if err != nil {
@@ -389,11 +478,21 @@ func (s *bookingService) UpdateStatus(id uint, status models.BookingStatusUpdate
 
 	statusClear := models.BookingStatus(strings.ToLower(strings.TrimSpace(string(status.Status))))
 
+	oldStatus := booking.Status
 	booking.Status = statusClear
 
 	switch statusClear {
 	case models.BookingActive, models.BookingNonActive, models.BookingCancelled:
-		if err := s.repo.UpdateBook(booking.ID, booking); err != nil {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.Booking{}).Where("id = ?", booking.ID).Update("status", statusClear).Error; err != nil {
+				return err
+			}
+			if oldStatus == statusClear {
+				return nil
+			}
+			return repository.AddOutboxEvent(tx, models.AggregateBooking, booking.ID, models.EventBookingStatusChanged, models.NewBookingEvent(booking, oldStatus))
+		})
+		if err != nil {
 			return err
 		}
 		if s.redis != nil {
@@ -406,91 +505,376 @@ func (s *bookingService) UpdateStatus(id uint, status models.BookingStatusUpdate
 	}
 }
 
-func (s *bookingService) UpdateBookingStatusWithBalance(id uint, newStatus models.BookingStatus) error {
+func (s *bookingService) UpdateBookingStatusWithBalance(id uint, newStatus models.BookingStatus) (int, error) {
 	// Начинаем транзакцию
+	refunded := 0
 	err := s.db.Transaction(func(tx *gorm.DB) error {
-		// Получаем бронь в рамках транзакции
-		var booking models.Booking
-		if err := tx.Preload("User").Preload("Place").Where("id = ?", id).First(&booking).Error; err != nil {
-			s.logger.Error("failed to get booking in transaction", "booking_id", id, "error", err)
-			return err
-		}
+		var err error
+		refunded, err = s.UpdateBookingStatusWithBalanceTx(tx, id, newStatus)
+		return err
+	})
 
-		oldStatus := booking.Status
-		newStatusNormalized := models.BookingStatus(strings.ToLower(strings.TrimSpace(string(newStatus))))
+	if err != nil {
+		return 0, err
+	}
 
-		// Валидация статуса
-		switch newStatusNormalized {
-		case models.BookingActive, models.BookingNonActive, models.BookingCancelled:
-			// OK
-		default:
-			return errors.New("неверный статус бронирования")
-		}
+	// Инвалидируем кэш после успешной транзакции
+	if s.redis != nil {
+		ctx := context.Background()
+		s.invalidateBookingCache(ctx)
+	}
 
-		// Если статус не изменился, ничего не делаем
-		if oldStatus == newStatusNormalized {
-			s.logger.Info("booking status unchanged", "booking_id", id, "status", newStatusNormalized)
-			return nil
+	return refunded, nil
+}
+
+// UpdateBookingStatusWithBalanceTx меняет статус внутри транзакции вызывающего
+// и возвращает сумму, фактически возвращенную на баланс. Кэш сбросит
+// обработчик события после коммита.
+func (s *bookingService) UpdateBookingStatusWithBalanceTx(tx *gorm.DB, id uint, newStatus models.BookingStatus) (int, error) {
+	// Блокируем бронь: параллельная смена статуса дождется коммита и увидит
+	// уже новый статус, иначе списание или возврат прошли бы дважды
+	var booking models.Booking
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("User").Preload("Place").Where("id = ?", id).First(&booking).Error; err != nil {
+		s.logger.Error("failed to get booking in transaction", "booking_id", id, "error", err)
+		return 0, err
+	}
+
+	oldStatus := booking.Status
+	newStatusNormalized := models.BookingStatus(strings.ToLower(strings.TrimSpace(string(newStatus))))
+
+	// Валидация статуса
+	switch newStatusNormalized {
+	case models.BookingActive, models.BookingNonActive, models.BookingCancelled:
+		// OK
+	default:
+		return 0, errors.New("неверный статус бронирования")
+	}
+
+	// Если статус не изменился, ничего не делаем
+	if oldStatus == newStatusNormalized {
+		s.logger.Info("booking status unchanged", "booking_id", id, "status", newStatusNormalized)
+		return 0, nil
+	}
+
+	refunded := 0
+
+	// Логика для смены статуса на active
+	if newStatusNormalized == models.BookingActive {
+		// Погашаем промокод до списания: если лимит исчерпан, оплата не проходит
+		if err := s.promos.Redeem(tx, &booking); err != nil {
+			s.logger.Warn("promo redemption failed", "booking_id", booking.ID, "error", err)
+			return 0, err
 		}
 
-		// TotalPrice уже в копейках
-		priceInCents := booking.TotalPrice
+		// Сначала часы списываются из абонемента, остаток стоимости — с баланса
+		if err := s.plans.Draw(tx, &booking); err != nil {
+			s.logger.Error("failed to draw subscription hours", "booking_id", booking.ID, "error", err)
+			return 0, err
+		}
+		priceInCents := booking.ChargedAmount
 
-		// Логика для смены статуса на active
-		if newStatusNormalized == models.BookingActive {
-			// Проверяем баланс пользователя
-			if booking.User.Balance < priceInCents {
+		// Участник организации платит с общего кошелька, если так решил владелец
+		orgID, err := s.orgs.ResolveWallet(tx, booking.UserID, priceInCents)
+		if err != nil {
+			return 0, err
+		}
+		booking.OrganizationID = orgID
+
+		// Списываем деньги с баланса пользователя или организации через журнал
+		bookingID := booking.ID
+		if _, err := repository.PostLedger(tx, models.LedgerPosting{
+			UserID:         booking.UserID,
+			OrganizationID: orgID,
+			Type:           models.LedgerBookingCharge,
+			Amount:         -priceInCents,
+			Reason:         "booking_charge",
+			BookingID:      &bookingID,
+		}); err != nil {
+			if errors.Is(err, ErrInsufficientFunds) || errors.Is(err, ErrCreditLimitExceeded) {
 				s.logger.Warn("insufficient balance", "user_id", booking.UserID, "balance", booking.User.Balance, "required", priceInCents)
-				return errors.New("недостаточно средств")
-			}
-
-			// Списываем деньги с баланса пользователя
-			if err := tx.Model(&models.User{}).Where("id = ?", booking.UserID).
-				Update("balance", gorm.Expr("balance - ?", priceInCents)).Error; err != nil {
+			} else {
 				s.logger.Error("failed to deduct balance", "user_id", booking.UserID, "error", err)
-				return err
 			}
+			return 0, err
+		}
+
+		s.logger.Info("balance deducted", "user_id", booking.UserID, "organization_id", orgID, "amount", priceInCents)
+	}
 
-			s.logger.Info("balance deducted", "user_id", booking.UserID, "amount", priceInCents)
+	// Логика для возврата денег при отмене активной брони
+	if oldStatus == models.BookingActive && (newStatusNormalized == models.BookingCancelled || newStatusNormalized == models.BookingNonActive) {
+		priceInCents := paidAmount(&booking)
+
+		if err := s.plans.Return(tx, &booking); err != nil {
+			s.logger.Error("failed to return subscription hours", "booking_id", booking.ID, "error", err)
+			return 0, err
 		}
 
-		// Логика для возврата денег при отмене активной брони
-		if oldStatus == models.BookingActive && (newStatusNormalized == models.BookingCancelled || newStatusNormalized == models.BookingNonActive) {
-			// Возвращаем деньги пользователю
-			if err := tx.Model(&models.User{}).Where("id = ?", booking.UserID).
-				Update("balance", gorm.Expr("balance + ?", priceInCents)).Error; err != nil {
-				s.logger.Error("failed to refund balance", "user_id", booking.UserID, "error", err)
-				return err
-			}
+		// Возвращаем деньги в тот кошелек, с которого они были списаны
+		bookingID := booking.ID
+		if _, err := repository.PostLedger(tx, models.LedgerPosting{
+			UserID:         booking.UserID,
+			OrganizationID: booking.OrganizationID,
+			Type:           models.LedgerRefund,
+			Amount:         priceInCents,
+			Reason:         "booking_refund",
+			BookingID:      &bookingID,
+		}); err != nil {
+			s.logger.Error("failed to refund balance", "user_id", booking.UserID, "error", err)
+			return 0, err
+		}
+
+		s.logger.Info("balance refunded", "user_id", booking.UserID, "amount", priceInCents)
+		refunded = priceInCents
+
+		if err := s.promos.Release(tx, &booking); err != nil {
+			s.logger.Error("failed to release promo redemption", "booking_id", booking.ID, "error", err)
+			return 0, err
+		}
+	}
+
+	// Обновляем статус брони
+	booking.Status = newStatusNormalized
+	if err := tx.Model(&models.Booking{}).Where("id = ?", id).Updates(map[string]any{
+		"status":          newStatusNormalized,
+		"hour_bucket_id":  booking.HourBucketID,
+		"included_hours":  booking.IncludedHours,
+		"charged_amount":  booking.ChargedAmount,
+		"organization_id": booking.OrganizationID,
+	}).Error; err != nil {
+		s.logger.Error("failed to update booking status", "booking_id", id, "error", err)
+		return 0, err
+	}
+
+	s.logger.Info("booking status updated with balance transaction",
+		"booking_id", id,
+		"old_status", oldStatus,
+		"new_status", newStatusNormalized,
+		"user_id", booking.UserID)
+
+	event := models.NewBookingEvent(&booking, oldStatus)
+	event.Refunded = refunded
+	if err := repository.AddOutboxEvent(tx, models.AggregateBooking, booking.ID, models.EventBookingStatusChanged, event); err != nil {
+		return 0, err
+	}
+	return refunded, nil
+}
+
+func (s *bookingService) checkOverdue(userID uint) error {
+	orgID, err := s.orgs.BillingWallet(userID)
+	if err != nil {
+		return err
+	}
 
-			s.logger.Info("balance refunded", "user_id", booking.UserID, "amount", priceInCents)
+	overdue, err := s.invoices.HasOverdue(userID, orgID)
+	if err != nil {
+		s.logger.Error("failed to check overdue invoices", "user_id", userID, "error", err)
+		return err
+	}
+	if overdue {
+		s.logger.Warn("booking blocked by overdue invoice", "user_id", userID, "organization_id", orgID)
+		return ErrInvoiceOverdue
+	}
+	return nil
+}
+
+// applyQuote считает цену брони движком тарифов, применяет промокод брони
+// и сохраняет разбивку по часам
+func (s *bookingService) applyQuote(booking *models.Booking, place *models.Place) error {
+	quote, err := s.pricing.QuotePlace(place, booking.StartTime, booking.EndTime)
+	if err != nil {
+		s.logger.Error("failed to quote booking", "place_id", place.ID, "error", err)
+		return err
+	}
+
+	if booking.PromoCodeID != nil {
+		promo, err := s.promos.GetPromo(*booking.PromoCodeID)
+		if err != nil {
+			s.logger.Error("failed to get promo code for quote", "promo_id", *booking.PromoCodeID, "error", err)
+			return err
 		}
+		quote.PromoCode = promo.Code
+		quote.PromoDiscount = promoDiscount(promo, quote)
+		quote.Total -= quote.PromoDiscount
+	}
+
+	breakdown, err := json.Marshal(quote)
+	if err != nil {
+		return err
+	}
+
+	booking.TotalPrice = quote.Total
+	booking.Discount = quote.PromoDiscount
+	booking.PriceBreakdown = models.JSONText(breakdown)
+	return nil
+}
 
-		// Обновляем статус брони
-		booking.Status = newStatusNormalized
-		if err := tx.Model(&models.Booking{}).Where("id = ?", id).Update("status", newStatusNormalized).Error; err != nil {
-			s.logger.Error("failed to update booking status", "booking_id", id, "error", err)
+// CheckIn отмечает приход пользователя. Отметиться можно не раньше чем
+// за 15 минут до начала и до конца активной брони.
+func (s *bookingService) CheckIn(userID, id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var booking models.Booking
+		if err := tx.Where("id = ?", id).First(&booking).Error; err != nil {
 			return err
 		}
 
-		s.logger.Info("booking status updated with balance transaction",
-			"booking_id", id,
-			"old_status", oldStatus,
-			"new_status", newStatusNormalized,
-			"user_id", booking.UserID)
+		if booking.UserID != userID {
+			return errors.New("нет доступа к этому бронированию")
+		}
+		if booking.Status != models.BookingActive {
+			return errors.New("отметиться можно только по активной брони")
+		}
+		if booking.CheckedInAt != nil {
+			return errors.New("вы уже отметились")
+		}
 
-		return nil
+		now := time.Now()
+		if now.Before(booking.StartTime.Add(-15*time.Minute)) || now.After(booking.EndTime) {
+			return errors.New("отметиться можно только во время брони")
+		}
+
+		booking.CheckedInAt = &now
+		if err := tx.Model(&models.Booking{}).Where("id = ?", id).Update("checked_in_at", now).Error; err != nil {
+			s.logger.Error("failed to check in", "booking_id", id, "error", err)
+			return err
+		}
+
+		s.logger.Info("booking checked in", "booking_id", id, "user_id", userID)
+		return repository.AddOutboxEvent(tx, models.AggregateBooking, id, models.EventBookingCheckedIn, models.NewBookingEvent(&booking, ""))
 	})
+}
 
-	if err != nil {
+// Relocate переносит бронь на то же время на другое место: проверяет, что
+// место свободно и не на обслуживании, пересчитывает цену и для активной брони
+// проводит разницу через журнал. Все в одной транзакции с блокировкой брони.
+func (s *bookingService) Relocate(id, placeID uint) (*models.RelocationOutcome, error) {
+	var outcome *models.RelocationOutcome
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		outcome, err = s.RelocateTx(tx, id, placeID)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Инвалидируем кэш после успешной транзакции
+	s.logger.Info("booking relocated", "booking_id", id, "from_place_id", outcome.FromPlaceID, "to_place_id", placeID, "difference", outcome.Difference)
 	if s.redis != nil {
-		ctx := context.Background()
-		s.invalidateBookingCache(ctx)
+		s.invalidateBookingCache(context.Background())
+	}
+	return outcome, nil
+}
+
+// RelocateTx переносит бронь внутри транзакции вызывающего, чтобы вместе с
+// переносом он мог сохранить и свое состояние. Кэш сбросит обработчик события.
+func (s *bookingService) RelocateTx(tx *gorm.DB, id, placeID uint) (*models.RelocationOutcome, error) {
+	var booking models.Booking
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&booking).Error; err != nil {
+		return nil, err
+	}
+	if booking.Status == models.BookingCancelled || !booking.EndTime.After(time.Now()) {
+		return nil, ErrRelocationNotAllowed
+	}
+	if booking.PlaceID == placeID {
+		return nil, fmt.Errorf("%w: бронь уже на этом месте", ErrRelocationNotAllowed)
+	}
+
+	place, err := s.placeRepo.GetPlaceByID(placeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlaceNotFound
+		}
+		return nil, err
+	}
+	if !place.IsActive {
+		return nil, fmt.Errorf("%w: место отключено", ErrPlaceOccupied)
+	}
+
+	var busy int64
+	if err := tx.Model(&models.Booking{}).
+		Where("place_id = ? AND status = ? AND id <> ?", placeID, models.BookingActive, id).
+		Where("start_time < ? AND end_time > ?", booking.EndTime, booking.StartTime).
+		Count(&busy).Error; err != nil {
+		return nil, err
+	}
+	if busy > 0 {
+		return nil, ErrPlaceOccupied
+	}
+	if err := s.checkMaintenance(placeID, booking.StartTime, booking.EndTime); err != nil {
+		return nil, err
+	}
+
+	fromPlaceID, oldPrice := booking.PlaceID, booking.TotalPrice
+	fromPlace, err := s.placeRepo.GetPlaceByID(fromPlaceID)
+	if err != nil {
+		return nil, err
 	}
+	oldCharged := paidAmount(&booking)
+	booking.PlaceID = placeID
+	if err := s.applyQuote(&booking, place); err != nil {
+		return nil, err
+	}
+
+	// Часы абонемента остаются за бронью: тип места и длительность те же
+	difference := 0
+	if booking.Status == models.BookingActive {
+		booking.ChargedAmount = chargedAmount(booking.TotalPrice, booking.IncludedHours, booking.StartTime, booking.EndTime)
+		difference = booking.ChargedAmount - oldCharged
+
+		entryType, reason := models.LedgerBookingCharge, "relocation_charge"
+		if difference < 0 {
+			entryType, reason = models.LedgerRefund, "relocation_refund"
+		}
+		bookingID := booking.ID
+		if _, err := repository.PostLedger(tx, models.LedgerPosting{
+			UserID:         booking.UserID,
+			OrganizationID: booking.OrganizationID,
+			Type:           entryType,
+			Amount:         -difference,
+			Reason:         reason,
+			BookingID:      &bookingID,
+		}); err != nil {
+			s.logger.Warn("relocation settlement failed", "booking_id", id, "difference", difference, "error", err)
+			return nil, err
+		}
+	}
+
+	if err := tx.Model(&models.Booking{}).Where("id = ?", id).Updates(map[string]any{
+		"place_id":        placeID,
+		"total_price":     booking.TotalPrice,
+		"discount":        booking.Discount,
+		"price_breakdown": booking.PriceBreakdown,
+		"charged_amount":  booking.ChargedAmount,
+	}).Error; err != nil {
+		s.logger.Error("failed to relocate booking", "booking_id", id, "error", err)
+		return nil, err
+	}
+
+	outcome := &models.RelocationOutcome{
+		BookingID:   id,
+		FromPlaceID: fromPlaceID,
+		ToPlaceID:   placeID,
+		OldPrice:    oldPrice,
+		NewPrice:    booking.TotalPrice,
+		Difference:  difference,
+	}
+	event := models.RelocationEvent{
+		BookingEvent:  models.NewBookingEvent(&booking, ""),
+		FromPlaceID:   fromPlaceID,
+		FromPlaceName: fromPlace.Name,
+		PlaceName:     place.Name,
+		Difference:    difference,
+	}
+	if err := repository.AddOutboxEvent(tx, models.AggregateBooking, id, models.EventBookingRelocated, event); err != nil {
+		return nil, err
+	}
+	return outcome, nil
+}
 
+// HandleEvent сбрасывает кэш бронирований по событиям из outbox. Прямая
+// инвалидация после коммита остается для свежести ответа, а этот обработчик
+// гарантирует сброс, даже если процесс упал сразу после транзакции.
+func (s *bookingService) HandleEvent(ctx context.Context, ev *models.OutboxEvent) error {
+	s.invalidateBookingCache(ctx)
 	return nil
 }
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	maxFloorPlanSize   = 5 << 20
+	defaultMapDuration = time.Hour
+)
+
+var (
+	ErrFloorPlanNotFound = errors.New("план этажа не загружен")
+	ErrInvalidFloorPlan  = errors.New("план этажа должен быть изображением PNG, JPEG или SVG")
+	ErrInvalidPosition   = errors.New("неверное положение места на плане")
+	ErrPlaceWithoutFloor = errors.New("место не привязано к этажу")
+	ErrInvalidMapRange   = errors.New("время окончания должно быть позже времени начала")
+)
+
+type FloorPlanService interface {
+	// UploadPlan сохраняет подложку; для SVG размеры плана задаются явно,
+	// для растровых изображений по умолчанию берутся из файла
+	UploadPlan(adminID, floorID uint, data []byte, width, height int) (*models.FloorPlan, error)
+	GetBackground(floorID uint) (*models.FloorPlan, error)
+
+	SetPlacePosition(adminID, placeID uint, req models.PlacePositionDTO) (*models.Place, error)
+	ClearPlacePosition(adminID, placeID uint) error
+
+	FloorMap(floorID uint, filter models.FilterFloorMap) (*models.FloorMap, error)
+}
+
+type floorPlanService struct {
+	repo      repository.FloorPlanRepository
+	locations repository.LocationRepository
+	placeRepo repository.PlaceRepository
+	access    LocationService
+	logger    *slog.Logger
+}
+
+func NewFloorPlanService(repo repository.FloorPlanRepository, locations repository.LocationRepository, placeRepo repository.PlaceRepository, access LocationService, logger *slog.Logger) FloorPlanService {
+	return &floorPlanService{repo: repo, locations: locations, placeRepo: placeRepo, access: access, logger: logger}
+}
+
+func (s *floorPlanService) UploadPlan(adminID, floorID uint, data []byte, width, height int) (*models.FloorPlan, error) {
+	floor, err := s.floor(floorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.access.CheckLocation(adminID, floor.LocationID); err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 || len(data) > maxFloorPlanSize {
+		return nil, fmt.Errorf("%w: размер файла от 1 байта до %d МБ", ErrInvalidFloorPlan, maxFloorPlanSize>>20)
+	}
+
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/png", "image/jpeg":
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrInvalidFloorPlan
+		}
+		if width <= 0 || height <= 0 {
+			width, height = cfg.Width, cfg.Height
+		}
+	default:
+		if !bytes.Contains(data[:min(len(data), 1024)], []byte("<svg")) {
+			return nil, ErrInvalidFloorPlan
+		}
+		contentType = "image/svg+xml"
+		if width <= 0 || height <= 0 {
+			return nil, fmt.Errorf("%w: для SVG укажите width и height", ErrInvalidFloorPlan)
+		}
+	}
+
+	plan := &models.FloorPlan{
+		FloorID:     floorID,
+		Width:       width,
+		Height:      height,
+		ContentType: contentType,
+		Image:       data,
+	}
+	if err := s.repo.SavePlan(plan); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("floor plan uploaded", "floor_id", floorID, "admin_id", adminID, "content_type", contentType)
+	return plan, nil
+}
+
+func (s *floorPlanService) GetBackground(floorID uint) (*models.FloorPlan, error) {
+	plan, err := s.repo.GetPlan(floorID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFloorPlanNotFound
+		}
+		return nil, err
+	}
+	return plan, nil
+}
+
+// SetPlacePosition задает точку и/или контур места; координаты проверяются
+// по размерам плана, если он уже загружен
+func (s *floorPlanService) SetPlacePosition(adminID, placeID uint, req models.PlacePositionDTO) (*models.Place, error) {
+	if err := s.access.CheckPlace(adminID, placeID); err != nil {
+		return nil, err
+	}
+
+	place, err := s.placeRepo.GetPlaceByID(placeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlaceNotFound
+		}
+		return nil, err
+	}
+	if place.FloorID == nil {
+		return nil, ErrPlaceWithoutFloor
+	}
+
+	if (req.X == nil) != (req.Y == nil) {
+		return nil, fmt.Errorf("%w: x и y задаются вместе", ErrInvalidPosition)
+	}
+	if req.X == nil && len(req.Polygon) == 0 {
+		return nil, fmt.Errorf("%w: нужна точка или контур", ErrInvalidPosition)
+	}
+
+	plan, err := s.repo.GetPlanMeta(*place.FloorID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if plan != nil {
+		points := slices.Clone(req.Polygon)
+		if req.X != nil {
+			points = append(points, models.MapPoint{*req.X, *req.Y})
+		}
+		for _, p := range points {
+			if p[0] < 0 || p[1] < 0 || p[0] > float64(plan.Width) || p[1] > float64(plan.Height) {
+				return nil, fmt.Errorf("%w: точка (%g, %g) вне плана %dx%d", ErrInvalidPosition, p[0], p[1], plan.Width, plan.Height)
+			}
+		}
+	}
+
+	var polygon models.JSONText
+	if len(req.Polygon) > 0 {
+		raw, err := json.Marshal(req.Polygon)
+		if err != nil {
+			return nil, err
+		}
+		polygon = models.JSONText(raw)
+	}
+
+	if err := s.repo.UpdatePlacePosition(placeID, req.X, req.Y, polygon); err != nil {
+		return nil, err
+	}
+
+	place.MapX, place.MapY, place.MapPolygon = req.X, req.Y, polygon
+	s.logger.Info("place position updated", "place_id", placeID, "admin_id", adminID)
+	return place, nil
+}
+
+func (s *floorPlanService) ClearPlacePosition(adminID, placeID uint) error {
+	if err := s.access.CheckPlace(adminID, placeID); err != nil {
+		return err
+	}
+	if _, err := s.placeRepo.GetPlaceByID(placeID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPlaceNotFound
+		}
+		return err
+	}
+	return s.repo.UpdatePlacePosition(placeID, nil, nil, "")
+}
+
+// FloorMap возвращает места этажа с занятостью за интервал; по умолчанию — ближайший час
+func (s *floorPlanService) FloorMap(floorID uint, filter models.FilterFloorMap) (*models.FloorMap, error) {
+	floor, err := s.floor(floorID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	if filter.StartTime != nil {
+		start = *filter.StartTime
+	}
+	end := start.Add(defaultMapDuration)
+	if filter.EndTime != nil {
+		end = *filter.EndTime
+	}
+	if !end.After(start) {
+		return nil, ErrInvalidMapRange
+	}
+
+	result := &models.FloorMap{
+		FloorID:    floor.ID,
+		FloorName:  floor.Name,
+		Level:      floor.Level,
+		LocationID: floor.LocationID,
+		StartTime:  start,
+		EndTime:    end,
+		Places:     []models.FloorMapPlace{},
+	}
+
+	plan, err := s.repo.GetPlanMeta(floorID)
+	switch {
+	case err == nil:
+		result.Width, result.Height = plan.Width, plan.Height
+		result.Background = fmt.Sprintf("/floors/%d/plan/background", floorID)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	places, err := s.repo.ListFloorPlaces(floorID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(places))
+	for _, p := range places {
+		ids = append(ids, p.ID)
+	}
+	busy, err := s.repo.BusyPlaceIDs(ids, start, end)
+	if err != nil {
+		return nil, err
+	}
+	maintenance, err := s.repo.MaintenancePlaceIDs(floorID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range places {
+		item := models.FloorMapPlace{
+			ID:           p.ID,
+			Name:         p.Name,
+			Type:         p.Type,
+			PricePerHour: p.PricePerHour,
+			X:            p.MapX,
+			Y:            p.MapY,
+			Status:       models.PlaceFree,
+		}
+		if p.MapPolygon != "" {
+			if err := json.Unmarshal([]byte(p.MapPolygon), &item.Polygon); err != nil {
+				s.logger.Warn("invalid place polygon", "place_id", p.ID, "error", err)
+			}
+		}
+
+		switch {
+		case !p.IsActive:
+			item.Status = models.PlaceInactive
+		case slices.Contains(maintenance, p.ID):
+			item.Status = models.PlaceMaintenance
+		case slices.Contains(busy, p.ID):
+			item.Status = models.PlaceBusy
+		}
+		result.Places = append(result.Places, item)
+	}
+
+	return result, nil
+}
+
+func (s *floorPlanService) floor(id uint) (*models.Floor, error) {
+	floor, err := s.locations.GetFloor(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFloorNotFound
+		}
+		return nil, err
+	}
+	return floor, nil
+}
@@ -0,0 +1,482 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/invoice"
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvoiceNotFound = errors.New("счет не найден")
+	ErrInvoiceOverdue  = errors.New("есть просроченный счет, новые бронирования недоступны до его оплаты")
+)
+
+type InvoiceFormat string
+
+const (
+	InvoiceHTML InvoiceFormat = "html"
+	InvoicePDF  InvoiceFormat = "pdf"
+)
+
+type InvoiceService interface {
+	// Generate создает черновики счетов за месяц. Если не заданы ни userID, ни orgID —
+	// для всех пользователей и организаций с операциями за период. Уже существующие
+	// счета не трогает. Счета постоплаты сразу выставляются со сроком оплаты.
+	Generate(year, month int, userID, orgID *uint) ([]models.Invoice, error)
+	RunMonthly(ctx context.Context, interval time.Duration)
+	// HasOverdue проверяет просроченные счета пользователя и организации, из кошелька которой он платит
+	HasOverdue(userID uint, orgID *uint) (bool, error)
+
+	List(filter *models.FilterInvoice) ([]models.Invoice, int64, error)
+	Get(id uint) (*models.Invoice, error)
+	// GetForUser отдает только выставленные счета пользователя: черновики видит лишь администратор
+	GetForUser(userID, id uint) (*models.Invoice, error)
+	ListForUser(userID uint, filter *models.FilterInvoice) ([]models.Invoice, int64, error)
+	GetForOrganization(orgID, id uint) (*models.Invoice, error)
+	ListForOrganization(orgID uint, filter *models.FilterInvoice) ([]models.Invoice, int64, error)
+	Render(inv *models.Invoice, format InvoiceFormat) ([]byte, error)
+
+	Issue(id uint) (*models.Invoice, error)
+	MarkPaid(id uint) (*models.Invoice, error)
+	Void(id uint, reason string) (*models.Invoice, error)
+}
+
+type invoiceService struct {
+	repo         repository.InvoiceRepository
+	vatRate      int
+	paymentTerms time.Duration
+	seller       invoice.Party
+	logger       *slog.Logger
+}
+
+// paymentTerms — срок оплаты счета постоплаты с момента выставления
+func NewInvoiceService(repo repository.InvoiceRepository, vatRate int, paymentTerms time.Duration, seller invoice.Party, logger *slog.Logger) InvoiceService {
+	if paymentTerms <= 0 {
+		paymentTerms = 10 * 24 * time.Hour
+	}
+	return &invoiceService{repo: repo, vatRate: vatRate, paymentTerms: paymentTerms, seller: seller, logger: logger}
+}
+
+func (s *invoiceService) Generate(year, month int, userID, orgID *uint) ([]models.Invoice, error) {
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+	return s.generate(start, userID, orgID, false)
+}
+
+// generate: includeVoid=true пропускает владельцев даже с аннулированным
+// счетом — так фоновая генерация не пересоздает счета, аннулированные администратором
+func (s *invoiceService) generate(start time.Time, userID, orgID *uint, includeVoid bool) ([]models.Invoice, error) {
+	end := start.AddDate(0, 1, 0)
+	if end.After(time.Now()) {
+		return nil, errors.New("счет можно сформировать только за завершенный месяц")
+	}
+
+	var users, orgs []uint
+	switch {
+	case userID != nil:
+		users = []uint{*userID}
+	case orgID != nil:
+		orgs = []uint{*orgID}
+	default:
+		var err error
+		if users, err = s.repo.ListBillableUsers(start, end); err != nil {
+			return nil, err
+		}
+		if orgs, err = s.repo.ListBillableOrganizations(start, end); err != nil {
+			return nil, err
+		}
+	}
+
+	created := make([]models.Invoice, 0, len(users)+len(orgs))
+	for _, uid := range users {
+		exists, err := s.repo.HasInvoice(uid, start, includeVoid)
+		if err != nil {
+			return created, err
+		}
+		if exists {
+			continue
+		}
+
+		id := uid
+		inv, err := s.create(&models.Invoice{UserID: &id}, start, end)
+		if err != nil {
+			return created, err
+		}
+		if inv != nil {
+			created = append(created, *inv)
+		}
+	}
+
+	for _, oid := range orgs {
+		exists, err := s.repo.HasOrganizationInvoice(oid, start, includeVoid)
+		if err != nil {
+			return created, err
+		}
+		if exists {
+			continue
+		}
+
+		id := oid
+		inv, err := s.create(&models.Invoice{OrganizationID: &id}, start, end)
+		if err != nil {
+			return created, err
+		}
+		if inv != nil {
+			created = append(created, *inv)
+		}
+	}
+
+	s.logger.Info("invoices generated", "period_start", start, "count", len(created))
+	return created, nil
+}
+
+// create собирает и сохраняет счет владельца inv; пустые счета не создаются
+func (s *invoiceService) create(inv *models.Invoice, start, end time.Time) (*models.Invoice, error) {
+	if err := s.build(inv, start, end); err != nil {
+		return nil, err
+	}
+	if len(inv.Lines) == 0 {
+		return nil, nil
+	}
+
+	postpaid, err := s.repo.IsPostpaid(inv)
+	if err != nil {
+		return nil, err
+	}
+	inv.Postpaid = postpaid
+
+	if err := s.repo.CreateInvoice(inv); err != nil {
+		return nil, err
+	}
+
+	// Долг постоплаты должен быть выставлен сразу, иначе срок оплаты не начнет идти
+	if inv.Postpaid {
+		return s.Issue(inv.ID)
+	}
+	return inv, nil
+}
+
+func (s *invoiceService) build(inv *models.Invoice, start, end time.Time) error {
+	account := models.OrganizationAccount(0)
+	if inv.OrganizationID != nil {
+		account = models.OrganizationAccount(*inv.OrganizationID)
+	} else {
+		account = models.UserAccount(*inv.UserID)
+	}
+
+	entries, err := s.repo.ListBillableEntries(account, start, end)
+	if err != nil {
+		return err
+	}
+
+	bookingIDs := make([]uint, 0, len(entries))
+	for _, e := range entries {
+		if e.BookingID != nil {
+			bookingIDs = append(bookingIDs, *e.BookingID)
+		}
+	}
+	bookings, err := s.repo.GetBookingsByIDs(bookingIDs)
+	if err != nil {
+		return err
+	}
+
+	inv.PeriodStart = start
+	inv.PeriodEnd = end
+	inv.Status = models.InvoiceDraft
+	inv.VATRate = s.vatRate
+
+	for _, e := range entries {
+		entryID := e.ID
+		line := models.InvoiceLine{
+			Amount:        -e.Amount, // на счете пользователя списание отрицательное
+			Quantity:      1,
+			BookingID:     e.BookingID,
+			LedgerEntryID: &entryID,
+			Date:          e.CreatedAt,
+		}
+
+		var booking *models.Booking
+		if e.BookingID != nil {
+			if b, ok := bookings[*e.BookingID]; ok {
+				booking = &b
+			}
+		}
+
+		switch {
+		case e.Type == models.LedgerBookingCharge:
+			line.Kind = models.InvoiceLineBooking
+			line.Description = "Бронирование"
+			if booking != nil {
+				line.Description = bookingLineDescription(booking)
+				line.Quantity = max(int(math.Ceil(booking.EndTime.Sub(booking.StartTime).Hours()))-booking.IncludedHours, 1)
+			}
+		case e.Type == models.LedgerRefund && booking != nil:
+			line.Kind = models.InvoiceLineRefund
+			line.Description = "Возврат: " + bookingLineDescription(booking)
+		case strings.HasPrefix(e.Reason, "subscription"):
+			line.Kind = models.InvoiceLineSubscription
+			line.Description = "Абонемент"
+			if e.Reason == "subscription_proration" {
+				line.Description = "Перерасчет абонемента при смене плана"
+			}
+		case e.Type == models.LedgerRefund:
+			line.Kind = models.InvoiceLineRefund
+			line.Description = "Возврат"
+		default:
+			line.Kind = models.InvoiceLineFee
+			line.Description = "Сбор"
+			if e.Reason != "" {
+				line.Description = "Сбор: " + e.Reason
+			}
+		}
+
+		// В счете организации видно, кто из сотрудников потратил деньги
+		if inv.OrganizationID != nil && booking != nil && booking.User != nil {
+			line.Description += " — " + strings.TrimSpace(booking.User.FirstName+" "+booking.User.LastName)
+		}
+
+		// Цена за час должна давать сумму строки без остатка, иначе строка
+		// выставляется одной позицией на точную сумму
+		if line.Amount%line.Quantity != 0 {
+			line.Quantity = 1
+		}
+		line.UnitPrice = line.Amount / line.Quantity
+		inv.Lines = append(inv.Lines, line)
+	}
+
+	// Часы абонемента личные, у организации таких броней нет
+	if inv.UserID != nil {
+		covered, err := s.repo.ListCoveredBookings(*inv.UserID, start, end)
+		if err != nil {
+			return err
+		}
+		for _, b := range covered {
+			bookingID := b.ID
+			inv.Lines = append(inv.Lines, models.InvoiceLine{
+				Kind:        models.InvoiceLineBooking,
+				Description: bookingLineDescription(&b) + " — по абонементу",
+				Quantity:    b.IncludedHours,
+				BookingID:   &bookingID,
+				Date:        b.StartTime,
+			})
+		}
+	}
+
+	for _, l := range inv.Lines {
+		inv.Total += l.Amount
+	}
+	// Цены включают НДС, поэтому налог выделяется из суммы
+	inv.VATAmount = int(math.Round(float64(inv.Total) * float64(inv.VATRate) / float64(100+inv.VATRate)))
+	inv.Subtotal = inv.Total - inv.VATAmount
+
+	return nil
+}
+
+func bookingLineDescription(b *models.Booking) string {
+	place := fmt.Sprintf("место #%d", b.PlaceID)
+	if b.Place != nil {
+		place = b.Place.Name
+	}
+	return fmt.Sprintf("Бронь «%s» %s %s–%s",
+		place,
+		b.StartTime.Format("02.01.2006"),
+		b.StartTime.Format("15:04"),
+		b.EndTime.Format("15:04"))
+}
+
+// RunMonthly формирует черновики за прошедший месяц и отмечает просроченные
+// счета постоплаты. Запуск идемпотентен: владельцы, у которых счет за период
+// уже есть, пропускаются.
+func (s *invoiceService) RunMonthly(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		prev := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local).AddDate(0, -1, 0)
+		if _, err := s.generate(prev, nil, nil, true); err != nil {
+			s.logger.Error("monthly invoice generation failed", "error", err)
+		}
+
+		if n, err := s.repo.MarkOverdue(now); err != nil {
+			s.logger.Error("mark overdue invoices failed", "error", err)
+		} else if n > 0 {
+			s.logger.Warn("invoices became overdue", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *invoiceService) HasOverdue(userID uint, orgID *uint) (bool, error) {
+	count, err := s.repo.CountOverdue(userID, orgID)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (s *invoiceService) List(filter *models.FilterInvoice) ([]models.Invoice, int64, error) {
+	return s.repo.ListInvoices(filter)
+}
+
+func (s *invoiceService) Get(id uint) (*models.Invoice, error) {
+	inv, err := s.repo.GetInvoice(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvoiceNotFound
+		}
+		return nil, err
+	}
+	return inv, nil
+}
+
+func (s *invoiceService) GetForUser(userID, id uint) (*models.Invoice, error) {
+	inv, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if inv.UserID == nil || *inv.UserID != userID || inv.Status == models.InvoiceDraft {
+		return nil, ErrInvoiceNotFound
+	}
+	return inv, nil
+}
+
+func (s *invoiceService) ListForUser(userID uint, filter *models.FilterInvoice) ([]models.Invoice, int64, error) {
+	filter.UserID = &userID
+	filter.ExcludeDrafts = true
+	return s.repo.ListInvoices(filter)
+}
+
+func (s *invoiceService) GetForOrganization(orgID, id uint) (*models.Invoice, error) {
+	inv, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if inv.OrganizationID == nil || *inv.OrganizationID != orgID || inv.Status == models.InvoiceDraft {
+		return nil, ErrInvoiceNotFound
+	}
+	return inv, nil
+}
+
+func (s *invoiceService) ListForOrganization(orgID uint, filter *models.FilterInvoice) ([]models.Invoice, int64, error) {
+	filter.UserID = nil
+	filter.OrganizationID = &orgID
+	filter.ExcludeDrafts = true
+	return s.repo.ListInvoices(filter)
+}
+
+func (s *invoiceService) Render(inv *models.Invoice, format InvoiceFormat) ([]byte, error) {
+	doc := invoice.Document{Invoice: inv, Seller: s.seller}
+	switch {
+	case inv.Organization != nil:
+		doc.Buyer = invoice.Party{
+			Name:    inv.Organization.Name,
+			TaxID:   inv.Organization.TaxID,
+			Address: inv.Organization.Address,
+		}
+	case inv.User != nil:
+		doc.Buyer = invoice.Party{
+			Name:  strings.TrimSpace(inv.User.FirstName + " " + inv.User.LastName),
+			Email: inv.User.Email,
+		}
+	}
+
+	switch format {
+	case InvoicePDF:
+		return invoice.RenderPDF(doc)
+	case InvoiceHTML:
+		var buf bytes.Buffer
+		if err := invoice.RenderHTML(&buf, doc); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, errors.New("неизвестный формат счета")
+	}
+}
+
+func (s *invoiceService) Issue(id uint) (*models.Invoice, error) {
+	inv, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if inv.Status != models.InvoiceDraft {
+		return nil, errors.New("выставить можно только черновик")
+	}
+
+	now := time.Now()
+	inv.Status = models.InvoiceIssued
+	inv.IssuedAt = &now
+	if inv.Postpaid {
+		due := now.Add(s.paymentTerms)
+		inv.DueAt = &due
+	}
+	if err := s.repo.IssueInvoice(inv); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("invoice issued", "invoice_id", inv.ID, "number", *inv.Number)
+	return inv, nil
+}
+
+func (s *invoiceService) MarkPaid(id uint) (*models.Invoice, error) {
+	inv, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if inv.Status != models.InvoiceIssued && inv.Status != models.InvoiceOverdue {
+		return nil, errors.New("оплаченным можно отметить только выставленный счет")
+	}
+
+	now := time.Now()
+	inv.Status = models.InvoicePaid
+	inv.PaidAt = &now
+
+	// Оплата счета постоплаты гасит долг на балансе владельца
+	if inv.Postpaid && inv.Total > 0 {
+		err = s.repo.SettleInvoice(inv)
+	} else {
+		err = s.repo.UpdateInvoice(inv)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("invoice paid", "invoice_id", inv.ID)
+	return inv, nil
+}
+
+func (s *invoiceService) Void(id uint, reason string) (*models.Invoice, error) {
+	inv, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if inv.Status != models.InvoiceDraft && inv.Status != models.InvoiceIssued && inv.Status != models.InvoiceOverdue {
+		return nil, errors.New("аннулировать можно только черновик или выставленный счет")
+	}
+
+	now := time.Now()
+	inv.Status = models.InvoiceVoid
+	inv.VoidedAt = &now
+	inv.VoidReason = reason
+	if err := s.repo.UpdateInvoice(inv); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("invoice voided", "invoice_id", inv.ID, "reason", reason)
+	return inv, nil
+}
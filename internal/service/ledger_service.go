@@ -0,0 +1,41 @@
+package service
+
+import (
+	"log/slog"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+)
+
+type LedgerService interface {
+	ListUserTransactions(userID uint, filter models.FilterTransactions) (*models.TransactionsPage, error)
+}
+
+type ledgerService struct {
+	repo   repository.LedgerRepository
+	logger *slog.Logger
+}
+
+func NewLedgerService(repo repository.LedgerRepository, logger *slog.Logger) LedgerService {
+	return &ledgerService{repo: repo, logger: logger}
+}
+
+func (s *ledgerService) ListUserTransactions(userID uint, filter models.FilterTransactions) (*models.TransactionsPage, error) {
+	limit := filter.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset := max(filter.Offset, 0)
+
+	entries, total, err := s.repo.ListUserEntries(userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TransactionsPage{
+		Items:  entries,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
@@ -0,0 +1,426 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrLocationNotFound  = errors.New("площадка не найдена")
+	ErrFloorNotFound     = errors.New("этаж не найден")
+	ErrZoneNotFound      = errors.New("зона не найдена")
+	ErrLocationForbidden = errors.New("площадка вне зоны ответственности администратора")
+	ErrLocationInUse     = errors.New("к объекту привязаны этажи, зоны или места")
+	ErrLocationHasAdmins = errors.New("за площадкой закреплены администраторы")
+	ErrAdminNotFound     = errors.New("администратор не найден")
+	ErrInvalidTimezone   = errors.New("неизвестный часовой пояс")
+	ErrInvalidPlacement  = errors.New("этаж и зона должны принадлежать указанной площадке")
+)
+
+type LocationService interface {
+	// Scope возвращает площадки администратора: nil — доступ ко всем,
+	// пустой список — ни к одной
+	Scope(adminID uint) ([]uint, error)
+	CheckLocation(adminID, locationID uint) error
+	CheckPlace(adminID, placeID uint) error
+	CheckBooking(adminID, bookingID uint) error
+	// ResolvePlacement достраивает размещение по самому нижнему заданному уровню
+	// и проверяет, что уровни согласованы между собой
+	ResolvePlacement(locationID, floorID, zoneID *uint) (*uint, *uint, *uint, error)
+
+	ListLocations() ([]models.Location, error)
+	GetLocation(id uint) (*models.Location, error)
+	ListManagedLocations(adminID uint) ([]models.Location, error)
+	CreateLocation(adminID uint, req models.LocationDTO) (*models.Location, error)
+	UpdateLocation(adminID, id uint, req models.LocationUpdateDTO) (*models.Location, error)
+	DeleteLocation(adminID, id uint) error
+
+	CreateFloor(adminID, locationID uint, req models.FloorDTO) (*models.Floor, error)
+	UpdateFloor(adminID, id uint, req models.FloorUpdateDTO) (*models.Floor, error)
+	DeleteFloor(adminID, id uint) error
+
+	CreateZone(adminID, floorID uint, req models.ZoneDTO) (*models.Zone, error)
+	UpdateZone(adminID, id uint, req models.ZoneUpdateDTO) (*models.Zone, error)
+	DeleteZone(adminID, id uint) error
+
+	SetAdminLocations(adminID, targetAdminID uint, req models.AdminLocationsDTO) ([]uint, error)
+}
+
+type locationService struct {
+	repo        repository.LocationRepository
+	placeRepo   repository.PlaceRepository
+	bookingRepo repository.BookingRepository
+	logger      *slog.Logger
+}
+
+func NewLocationService(repo repository.LocationRepository, placeRepo repository.PlaceRepository, bookingRepo repository.BookingRepository, logger *slog.Logger) LocationService {
+	return &locationService{repo: repo, placeRepo: placeRepo, bookingRepo: bookingRepo, logger: logger}
+}
+
+func (s *locationService) Scope(adminID uint) ([]uint, error) {
+	unrestricted, err := s.repo.IsAdminUnrestricted(adminID)
+	if err != nil {
+		return nil, err
+	}
+	if unrestricted {
+		return nil, nil
+	}
+	return s.repo.ListAdminLocationIDs(adminID)
+}
+
+func (s *locationService) CheckLocation(adminID, locationID uint) error {
+	scope, err := s.Scope(adminID)
+	if err != nil {
+		return err
+	}
+	if scope != nil && !slices.Contains(scope, locationID) {
+		return ErrLocationForbidden
+	}
+	return nil
+}
+
+// CheckPlace пускает к местам без площадки только администраторов без ограничений
+func (s *locationService) CheckPlace(adminID, placeID uint) error {
+	scope, err := s.Scope(adminID)
+	if err != nil || scope == nil {
+		return err
+	}
+
+	place, err := s.placeRepo.GetPlaceByID(placeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPlaceNotFound
+		}
+		return err
+	}
+	if place.LocationID == nil || !slices.Contains(scope, *place.LocationID) {
+		return ErrLocationForbidden
+	}
+	return nil
+}
+
+func (s *locationService) CheckBooking(adminID, bookingID uint) error {
+	scope, err := s.Scope(adminID)
+	if err != nil || scope == nil {
+		return err
+	}
+
+	booking, err := s.bookingRepo.GetBookingById(bookingID)
+	if err != nil {
+		return err
+	}
+	if booking.Place == nil || booking.Place.LocationID == nil || !slices.Contains(scope, *booking.Place.LocationID) {
+		return ErrLocationForbidden
+	}
+	return nil
+}
+
+func (s *locationService) ResolvePlacement(locationID, floorID, zoneID *uint) (*uint, *uint, *uint, error) {
+	if zoneID != nil {
+		zone, err := s.repo.GetZone(*zoneID)
+		if err != nil {
+			return nil, nil, nil, s.notFound(err, ErrZoneNotFound)
+		}
+		if floorID != nil && *floorID != zone.FloorID {
+			return nil, nil, nil, ErrInvalidPlacement
+		}
+		floorID = &zone.FloorID
+	}
+
+	if floorID != nil {
+		floor, err := s.repo.GetFloor(*floorID)
+		if err != nil {
+			return nil, nil, nil, s.notFound(err, ErrFloorNotFound)
+		}
+		if locationID != nil && *locationID != floor.LocationID {
+			return nil, nil, nil, ErrInvalidPlacement
+		}
+		locationID = &floor.LocationID
+	}
+
+	if locationID != nil {
+		if _, err := s.repo.GetLocation(*locationID); err != nil {
+			return nil, nil, nil, s.notFound(err, ErrLocationNotFound)
+		}
+	}
+
+	return locationID, floorID, zoneID, nil
+}
+
+func (s *locationService) ListLocations() ([]models.Location, error) {
+	return s.repo.ListLocations(nil)
+}
+
+func (s *locationService) GetLocation(id uint) (*models.Location, error) {
+	loc, err := s.repo.GetLocation(id)
+	if err != nil {
+		return nil, s.notFound(err, ErrLocationNotFound)
+	}
+	return loc, nil
+}
+
+func (s *locationService) ListManagedLocations(adminID uint) ([]models.Location, error) {
+	scope, err := s.Scope(adminID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListLocations(scope)
+}
+
+// CreateLocation доступен только администраторам без ограничений: новая
+// площадка ни за кем не закреплена
+func (s *locationService) CreateLocation(adminID uint, req models.LocationDTO) (*models.Location, error) {
+	if err := s.requireGlobal(adminID); err != nil {
+		return nil, err
+	}
+	if err := validateTimezone(req.Timezone); err != nil {
+		return nil, err
+	}
+
+	loc := &models.Location{
+		Name:      strings.TrimSpace(req.Name),
+		Address:   strings.TrimSpace(req.Address),
+		Timezone:  req.Timezone,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+	}
+	if err := s.repo.CreateLocation(loc); err != nil {
+		return nil, err
+	}
+	return loc, nil
+}
+
+func (s *locationService) UpdateLocation(adminID, id uint, req models.LocationUpdateDTO) (*models.Location, error) {
+	if err := s.CheckLocation(adminID, id); err != nil {
+		return nil, err
+	}
+	loc, err := s.GetLocation(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		loc.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Address != nil {
+		loc.Address = strings.TrimSpace(*req.Address)
+	}
+	if req.Timezone != nil {
+		if err := validateTimezone(*req.Timezone); err != nil {
+			return nil, err
+		}
+		loc.Timezone = *req.Timezone
+	}
+	if req.Latitude != nil {
+		loc.Latitude = *req.Latitude
+	}
+	if req.Longitude != nil {
+		loc.Longitude = *req.Longitude
+	}
+
+	if err := s.repo.UpdateLocation(loc); err != nil {
+		return nil, err
+	}
+	return loc, nil
+}
+
+func (s *locationService) DeleteLocation(adminID, id uint) error {
+	if err := s.requireGlobal(adminID); err != nil {
+		return err
+	}
+	if _, err := s.GetLocation(id); err != nil {
+		return err
+	}
+	if err := s.ensureEmpty(s.repo.CountFloors(id)); err != nil {
+		return err
+	}
+	if err := s.ensureEmpty(s.repo.CountPlaces("location_id", id)); err != nil {
+		return err
+	}
+	// иначе администраторы, закрепленные только за этой площадкой, остались бы
+	// без площадок, а их закрепления — висящими ссылками
+	admins, err := s.repo.CountAdmins(id)
+	if err != nil {
+		return err
+	}
+	if admins > 0 {
+		return ErrLocationHasAdmins
+	}
+	return s.repo.DeleteLocation(id)
+}
+
+func (s *locationService) CreateFloor(adminID, locationID uint, req models.FloorDTO) (*models.Floor, error) {
+	if err := s.CheckLocation(adminID, locationID); err != nil {
+		return nil, err
+	}
+	if _, err := s.GetLocation(locationID); err != nil {
+		return nil, err
+	}
+
+	floor := &models.Floor{LocationID: locationID, Name: strings.TrimSpace(req.Name), Level: req.Level}
+	if err := s.repo.CreateFloor(floor); err != nil {
+		return nil, err
+	}
+	return floor, nil
+}
+
+func (s *locationService) UpdateFloor(adminID, id uint, req models.FloorUpdateDTO) (*models.Floor, error) {
+	floor, err := s.floor(adminID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		floor.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Level != nil {
+		floor.Level = *req.Level
+	}
+	if err := s.repo.UpdateFloor(floor); err != nil {
+		return nil, err
+	}
+	return floor, nil
+}
+
+func (s *locationService) DeleteFloor(adminID, id uint) error {
+	if _, err := s.floor(adminID, id); err != nil {
+		return err
+	}
+	if err := s.ensureEmpty(s.repo.CountZones(id)); err != nil {
+		return err
+	}
+	if err := s.ensureEmpty(s.repo.CountPlaces("floor_id", id)); err != nil {
+		return err
+	}
+	return s.repo.DeleteFloor(id)
+}
+
+func (s *locationService) CreateZone(adminID, floorID uint, req models.ZoneDTO) (*models.Zone, error) {
+	if _, err := s.floor(adminID, floorID); err != nil {
+		return nil, err
+	}
+
+	zone := &models.Zone{FloorID: floorID, Name: strings.TrimSpace(req.Name), Description: req.Description}
+	if err := s.repo.CreateZone(zone); err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+func (s *locationService) UpdateZone(adminID, id uint, req models.ZoneUpdateDTO) (*models.Zone, error) {
+	zone, err := s.zone(adminID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		zone.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		zone.Description = *req.Description
+	}
+	if err := s.repo.UpdateZone(zone); err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+func (s *locationService) DeleteZone(adminID, id uint) error {
+	if _, err := s.zone(adminID, id); err != nil {
+		return err
+	}
+	if err := s.ensureEmpty(s.repo.CountPlaces("zone_id", id)); err != nil {
+		return err
+	}
+	return s.repo.DeleteZone(id)
+}
+
+// SetAdminLocations закрепляет площадки за администратором. Доступ ко всем
+// площадкам выдается только явно через Unrestricted, пустой список его не дает.
+func (s *locationService) SetAdminLocations(adminID, targetAdminID uint, req models.AdminLocationsDTO) ([]uint, error) {
+	if err := s.requireGlobal(adminID); err != nil {
+		return nil, err
+	}
+
+	ids := []uint{}
+	if !req.Unrestricted {
+		ids = append(ids, req.LocationIDs...)
+	}
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	for _, id := range ids {
+		if _, err := s.GetLocation(id); err != nil {
+			return nil, fmt.Errorf("%w: %d", err, id)
+		}
+	}
+
+	if err := s.repo.SetAdminLocations(targetAdminID, req.Unrestricted, ids); err != nil {
+		return nil, s.notFound(err, ErrAdminNotFound)
+	}
+	return ids, nil
+}
+
+func (s *locationService) requireGlobal(adminID uint) error {
+	scope, err := s.Scope(adminID)
+	if err != nil {
+		return err
+	}
+	if scope != nil {
+		return ErrLocationForbidden
+	}
+	return nil
+}
+
+func (s *locationService) floor(adminID, id uint) (*models.Floor, error) {
+	floor, err := s.repo.GetFloor(id)
+	if err != nil {
+		return nil, s.notFound(err, ErrFloorNotFound)
+	}
+	if err := s.CheckLocation(adminID, floor.LocationID); err != nil {
+		return nil, err
+	}
+	return floor, nil
+}
+
+func (s *locationService) zone(adminID, id uint) (*models.Zone, error) {
+	zone, err := s.repo.GetZone(id)
+	if err != nil {
+		return nil, s.notFound(err, ErrZoneNotFound)
+	}
+	if _, err := s.floor(adminID, zone.FloorID); err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+func (s *locationService) ensureEmpty(count int64, err error) error {
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrLocationInUse
+	}
+	return nil
+}
+
+func (s *locationService) notFound(err, target error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return target
+	}
+	return err
+}
+
+func validateTimezone(name string) error {
+	if _, err := time.LoadLocation(name); err != nil || name == "" || name == "Local" {
+		return fmt.Errorf("%w: %s", ErrInvalidTimezone, name)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPlaceUnderMaintenance   = errors.New("место закрыто на обслуживание")
+	ErrMaintenanceNotFound     = errors.New("окно обслуживания не найдено")
+	ErrInvalidMaintenance      = errors.New("неверное окно обслуживания")
+	ErrBookingNotInMaintenance = errors.New("бронь не попадает в окно обслуживания")
+	ErrMaintenanceNotSettled   = errors.New("не все брони окна обслуживания удалось отменить")
+)
+
+type MaintenanceService interface {
+	// Create и Update возвращают вместе с окном брони, которые в него попали:
+	// их нужно перенести или отменить через CancelBookings
+	Create(adminID uint, req models.MaintenanceWindowDTO) (*models.MaintenanceWindow, []models.Booking, error)
+	List(adminID uint, filter *models.FilterMaintenance) ([]models.MaintenanceWindow, int64, error)
+	Get(adminID, id uint) (*models.MaintenanceWindow, error)
+	Update(adminID, id uint, req models.MaintenanceWindowUpdateDTO) (*models.MaintenanceWindow, []models.Booking, error)
+	Delete(adminID, id uint) error
+
+	AffectedBookings(adminID, id uint) ([]models.Booking, error)
+	// CancelBookings отменяет затронутые брони с возвратом денег; без списка — все
+	CancelBookings(adminID, id uint, bookingIDs []uint) (*models.MaintenanceCancelResult, error)
+}
+
+type maintenanceService struct {
+	repo      repository.MaintenanceRepository
+	placeRepo repository.PlaceRepository
+	locations LocationService
+	bookings  BookingService
+	logger    *slog.Logger
+}
+
+func NewMaintenanceService(repo repository.MaintenanceRepository, placeRepo repository.PlaceRepository, locations LocationService, bookings BookingService, logger *slog.Logger) MaintenanceService {
+	return &maintenanceService{repo: repo, placeRepo: placeRepo, locations: locations, bookings: bookings, logger: logger}
+}
+
+func (s *maintenanceService) Create(adminID uint, req models.MaintenanceWindowDTO) (*models.MaintenanceWindow, []models.Booking, error) {
+	if (req.PlaceID == nil) == (req.ZoneID == nil) {
+		return nil, nil, fmt.Errorf("%w: укажите либо place_id, либо zone_id", ErrInvalidMaintenance)
+	}
+
+	w := &models.MaintenanceWindow{
+		PlaceID:   req.PlaceID,
+		ZoneID:    req.ZoneID,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Reason:    strings.TrimSpace(req.Reason),
+		CreatedBy: adminID,
+	}
+	if err := s.checkAccess(adminID, w); err != nil {
+		return nil, nil, err
+	}
+	if err := validateWindow(w, time.Now()); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.repo.CreateWindow(w); err != nil {
+		return nil, nil, err
+	}
+
+	affected, err := s.repo.AffectedBookings(w, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	s.logger.Info("maintenance window scheduled", "window_id", w.ID, "admin_id", adminID, "affected_bookings", len(affected))
+	return w, affected, nil
+}
+
+func (s *maintenanceService) List(adminID uint, filter *models.FilterMaintenance) ([]models.MaintenanceWindow, int64, error) {
+	scope, err := s.locations.Scope(adminID)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter.LocationIDs = scope
+	return s.repo.ListWindows(filter)
+}
+
+func (s *maintenanceService) Get(adminID, id uint) (*models.MaintenanceWindow, error) {
+	w, err := s.repo.GetWindow(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMaintenanceNotFound
+		}
+		return nil, err
+	}
+	if err := s.checkAccess(adminID, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (s *maintenanceService) Update(adminID, id uint, req models.MaintenanceWindowUpdateDTO) (*models.MaintenanceWindow, []models.Booking, error) {
+	w, err := s.Get(adminID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if req.StartTime != nil {
+		w.StartTime = *req.StartTime
+	}
+	if req.EndTime != nil {
+		w.EndTime = *req.EndTime
+	}
+	if req.Reason != nil {
+		w.Reason = strings.TrimSpace(*req.Reason)
+	}
+	if err := validateWindow(w, time.Now()); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.repo.UpdateWindow(w); err != nil {
+		return nil, nil, err
+	}
+
+	affected, err := s.repo.AffectedBookings(w, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	return w, affected, nil
+}
+
+func (s *maintenanceService) Delete(adminID, id uint) error {
+	if _, err := s.Get(adminID, id); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteWindow(id); err != nil {
+		return err
+	}
+	s.logger.Info("maintenance window deleted", "window_id", id, "admin_id", adminID)
+	return nil
+}
+
+func (s *maintenanceService) AffectedBookings(adminID, id uint) ([]models.Booking, error) {
+	w, err := s.Get(adminID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.AffectedBookings(w, time.Now())
+}
+
+func (s *maintenanceService) CancelBookings(adminID, id uint, bookingIDs []uint) (*models.MaintenanceCancelResult, error) {
+	affected, err := s.AffectedBookings(adminID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := affected
+	if len(bookingIDs) > 0 {
+		targets = make([]models.Booking, 0, len(bookingIDs))
+		for _, bookingID := range bookingIDs {
+			i := slices.IndexFunc(affected, func(b models.Booking) bool { return b.ID == bookingID })
+			if i < 0 {
+				return nil, fmt.Errorf("%w: %d", ErrBookingNotInMaintenance, bookingID)
+			}
+			targets = append(targets, affected[i])
+		}
+	}
+
+	// Отменяем по одной через BookingService: он возвращает деньги и рассылает
+	// события. Каждая бронь отменяется в своей транзакции, поэтому ошибка по
+	// одной не останавливает остальные: она попадает в FailedBookings.
+	result := &models.MaintenanceCancelResult{WindowID: id, CancelledBookings: []uint{}}
+	for _, b := range targets {
+		refunded, err := s.bookings.UpdateBookingStatusWithBalance(b.ID, models.BookingCancelled)
+		if err != nil {
+			s.logger.Error("maintenance cancel failed", "window_id", id, "booking_id", b.ID, "error", err)
+			result.FailedBookings = append(result.FailedBookings, models.BookingFailure{BookingID: b.ID, Error: err.Error()})
+			continue
+		}
+		result.CancelledBookings = append(result.CancelledBookings, b.ID)
+		result.Refunded += refunded
+	}
+
+	s.logger.Info("bookings cancelled for maintenance", "window_id", id, "admin_id", adminID, "count", len(result.CancelledBookings), "failed", len(result.FailedBookings), "refunded", result.Refunded)
+	if len(result.FailedBookings) > 0 {
+		return result, fmt.Errorf("%w: %d из %d", ErrMaintenanceNotSettled, len(result.FailedBookings), len(targets))
+	}
+	return result, nil
+}
+
+// checkAccess проверяет, что место или зона окна существуют и относятся к
+// площадкам администратора
+func (s *maintenanceService) checkAccess(adminID uint, w *models.MaintenanceWindow) error {
+	if w.PlaceID != nil {
+		if err := s.locations.CheckPlace(adminID, *w.PlaceID); err != nil {
+			return err
+		}
+		if _, err := s.placeRepo.GetPlaceByID(*w.PlaceID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPlaceNotFound
+			}
+			return err
+		}
+		return nil
+	}
+
+	locationID, _, _, err := s.locations.ResolvePlacement(nil, nil, w.ZoneID)
+	if err != nil {
+		return err
+	}
+	return s.locations.CheckLocation(adminID, *locationID)
+}
+
+func validateWindow(w *models.MaintenanceWindow, now time.Time) error {
+	if !w.EndTime.After(w.StartTime) {
+		return fmt.Errorf("%w: время окончания должно быть позже времени начала", ErrInvalidMaintenance)
+	}
+	if !w.EndTime.After(now) {
+		return fmt.Errorf("%w: окно уже закончилось", ErrInvalidMaintenance)
+	}
+	if utf8.RuneCountInString(w.Reason) < 3 {
+		return fmt.Errorf("%w: укажите причину", ErrInvalidMaintenance)
+	}
+	return nil
+}
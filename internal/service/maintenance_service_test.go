@@ -0,0 +1,41 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+)
+
+func TestValidateWindow(t *testing.T) {
+	now := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		start   time.Time
+		end     time.Time
+		reason  string
+		wantErr bool
+	}{
+		{name: "корректное окно", start: now, end: now.Add(time.Hour), reason: "ремонт"},
+		{name: "три буквы кириллицей", start: now, end: now.Add(time.Hour), reason: "ТО!"},
+		{name: "две буквы кириллицей", start: now, end: now.Add(time.Hour), reason: "ТО", wantErr: true},
+		{name: "пустая причина", start: now, end: now.Add(time.Hour), wantErr: true},
+		{name: "конец раньше начала", start: now, end: now.Add(-time.Hour), reason: "ремонт", wantErr: true},
+		{name: "окно уже закончилось", start: now.Add(-2 * time.Hour), end: now.Add(-time.Hour), reason: "ремонт", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &models.MaintenanceWindow{StartTime: tt.start, EndTime: tt.end, Reason: tt.reason}
+			err := validateWindow(w, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateWindow = %v, ошибка ожидалась: %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidMaintenance) {
+				t.Fatalf("ошибка %v, ожидалась ErrInvalidMaintenance", err)
+			}
+		})
+	}
+}
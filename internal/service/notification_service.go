@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/notification"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+)
+
+type NotificationService interface {
+	HandleEvent(ctx context.Context, ev *models.OutboxEvent) error
+	NotifyBookingConfirmed(ctx context.Context, bookingID uint) error
+	NotifyBookingCancelled(ctx context.Context, bookingID uint, refund int) error
+	NotifyBookingRelocated(ctx context.Context, ev models.RelocationEvent) error
+	SendDueReminders(ctx context.Context) error
+	RunReminders(ctx context.Context, interval time.Duration)
+	GetPreferences(userID uint) ([]models.NotificationPreference, error)
+	UpdatePreference(userID uint, req models.NotificationPreferenceDTO) error
+}
+
+type notificationService struct {
+	repo           repository.NotificationRepository
+	bookingRepo    repository.BookingRepository
+	notifiers      []notification.Notifier
+	reminderBefore time.Duration
+	logger         *slog.Logger
+}
+
+func NewNotificationService(
+	repo repository.NotificationRepository,
+	bookingRepo repository.BookingRepository,
+	notifiers []notification.Notifier,
+	reminderBefore time.Duration,
+	logger *slog.Logger,
+) NotificationService {
+	return &notificationService{
+		repo:           repo,
+		bookingRepo:    bookingRepo,
+		notifiers:      notifiers,
+		reminderBefore: reminderBefore,
+		logger:         logger,
+	}
+}
+
+// HandleEvent превращает смену статуса брони в подтверждение или уведомление
+// об отмене, а перенос брони — в уведомление о новом месте
+func (s *notificationService) HandleEvent(ctx context.Context, ev *models.OutboxEvent) error {
+	if ev.EventType == models.EventBookingRelocated {
+		var payload models.RelocationEvent
+		if err := json.Unmarshal([]byte(ev.Payload), &payload); err != nil {
+			s.logger.Error("invalid relocation event payload", "event_id", ev.ID, "error", err)
+			return nil
+		}
+		return s.NotifyBookingRelocated(ctx, payload)
+	}
+	if ev.EventType != models.EventBookingStatusChanged {
+		return nil
+	}
+
+	var payload models.BookingEvent
+	if err := json.Unmarshal([]byte(ev.Payload), &payload); err != nil {
+		// битое событие повторять бессмысленно
+		s.logger.Error("invalid booking event payload", "event_id", ev.ID, "error", err)
+		return nil
+	}
+
+	switch payload.Status {
+	case models.BookingActive:
+		return s.NotifyBookingConfirmed(ctx, payload.BookingID)
+	case models.BookingCancelled:
+		return s.NotifyBookingCancelled(ctx, payload.BookingID, payload.Refunded)
+	}
+	return nil
+}
+
+func (s *notificationService) NotifyBookingConfirmed(ctx context.Context, bookingID uint) error {
+	booking, err := s.bookingRepo.GetBookingById(bookingID)
+	if err != nil {
+		return err
+	}
+	data := bookingTemplateData(booking)
+	data.Charged = booking.ChargedAmount
+	data.IncludedHours = booking.IncludedHours
+	return s.dispatch(ctx, booking, notification.KindBookingConfirmed, data)
+}
+
+func (s *notificationService) NotifyBookingCancelled(ctx context.Context, bookingID uint, refund int) error {
+	booking, err := s.bookingRepo.GetBookingById(bookingID)
+	if err != nil {
+		return err
+	}
+	data := bookingTemplateData(booking)
+	data.Refund = refund
+	return s.dispatch(ctx, booking, notification.KindBookingCancelled, data)
+}
+
+func (s *notificationService) NotifyBookingRelocated(ctx context.Context, ev models.RelocationEvent) error {
+	booking, err := s.bookingRepo.GetBookingById(ev.BookingID)
+	if err != nil {
+		return err
+	}
+	data := bookingTemplateData(booking)
+	data.FromPlaceName = ev.FromPlaceName
+	if ev.Difference > 0 {
+		data.Charged = ev.Difference
+	} else {
+		data.Refund = -ev.Difference
+	}
+	return s.dispatch(ctx, booking, notification.KindBookingRelocated, data)
+}
+
+func (s *notificationService) SendDueReminders(ctx context.Context) error {
+	now := time.Now()
+	bookings, err := s.repo.ListBookingsForReminder(now, now.Add(s.reminderBefore))
+	if err != nil {
+		return err
+	}
+
+	for i := range bookings {
+		booking := &bookings[i]
+
+		claimed, err := s.repo.ClaimReminder(booking.ID)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		data := bookingTemplateData(booking)
+		data.Minutes = int(time.Until(booking.StartTime).Minutes())
+		if err := s.dispatch(ctx, booking, notification.KindBookingReminder, data); err != nil {
+			s.logger.Error("failed to send booking reminder", "booking_id", booking.ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// RunReminders периодически рассылает напоминания до отмены контекста
+func (s *notificationService) RunReminders(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.SendDueReminders(ctx); err != nil {
+			s.logger.Error("SendDueReminders failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *notificationService) GetPreferences(userID uint) ([]models.NotificationPreference, error) {
+	stored, err := s.repo.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byChannel := make(map[models.NotificationChannel]models.NotificationPreference, len(stored))
+	for _, p := range stored {
+		byChannel[p.Channel] = p
+	}
+
+	// Каналы без сохраненной настройки показываем как включенные
+	prefs := make([]models.NotificationPreference, 0, 2)
+	for _, ch := range []models.NotificationChannel{models.NotificationEmail, models.NotificationLog} {
+		if p, ok := byChannel[ch]; ok {
+			prefs = append(prefs, p)
+			continue
+		}
+		prefs = append(prefs, models.NotificationPreference{UserID: userID, Channel: ch, Enabled: true})
+	}
+
+	return prefs, nil
+}
+
+func (s *notificationService) UpdatePreference(userID uint, req models.NotificationPreferenceDTO) error {
+	pref := &models.NotificationPreference{
+		UserID:  userID,
+		Channel: req.Channel,
+		Enabled: *req.Enabled,
+	}
+	return s.repo.UpsertPreference(pref)
+}
+
+func (s *notificationService) dispatch(ctx context.Context, booking *models.Booking, kind notification.Kind, data notification.TemplateData) error {
+	if booking.User == nil {
+		s.logger.Warn("notification skipped: booking without user", "booking_id", booking.ID, "kind", kind)
+		return nil
+	}
+
+	prefs, err := s.repo.GetPreferences(booking.UserID)
+	if err != nil {
+		return err
+	}
+	disabled := make(map[models.NotificationChannel]bool, len(prefs))
+	for _, p := range prefs {
+		disabled[p.Channel] = !p.Enabled
+	}
+
+	msg, err := notification.Render(kind, booking.User.Email, data)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range s.notifiers {
+		if disabled[n.Channel()] {
+			continue
+		}
+		if err := n.Send(ctx, msg); err != nil {
+			s.logger.Error("notification send failed",
+				"channel", n.Channel(),
+				"kind", kind,
+				"booking_id", booking.ID,
+				"error", err)
+			continue
+		}
+		s.logger.Info("notification sent", "channel", n.Channel(), "kind", kind, "booking_id", booking.ID)
+	}
+
+	return nil
+}
+
+func bookingTemplateData(booking *models.Booking) notification.TemplateData {
+	data := notification.TemplateData{
+		StartTime:  booking.StartTime,
+		EndTime:    booking.EndTime,
+		TotalPrice: booking.TotalPrice,
+	}
+	if booking.User != nil {
+		data.FirstName = booking.User.FirstName
+	}
+	if booking.Place != nil {
+		data.PlaceName = booking.Place.Name
+	}
+	return data
+}
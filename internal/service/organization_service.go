@@ -0,0 +1,451 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotOrgMember      = errors.New("вы не состоите в организации")
+	ErrOrgForbidden      = errors.New("недостаточно прав в организации")
+	ErrSpendingLimit     = errors.New("превышен месячный лимит расходов участника")
+	ErrInvitationInvalid = errors.New("приглашение недействительно или истекло")
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+type OrganizationService interface {
+	Create(userID uint, req models.OrganizationDTO) (*models.Organization, error)
+	GetMine(userID uint) (*models.OrganizationResDTO, error)
+	Update(userID uint, req models.OrganizationUpdateDTO) (*models.Organization, error)
+
+	ListMembers(userID uint) ([]models.OrganizationMember, error)
+	UpdateMember(userID, memberUserID uint, req models.MemberUpdateDTO) (*models.OrganizationMember, error)
+	RemoveMember(userID, memberUserID uint) error
+
+	Invite(userID uint, req models.InvitationDTO) (*models.OrganizationInvitation, error)
+	ListInvitations(userID uint) ([]models.OrganizationInvitation, error)
+	AcceptInvitation(userID uint, token string) (*models.OrganizationMember, error)
+
+	ListMemberBookings(userID uint, filter models.FilterOrgBookings) ([]models.Booking, error)
+	CanBookFor(bookerID, userID uint) error
+	// ManagedOrganization возвращает организацию, которой владеет userID
+	ManagedOrganization(userID uint) (uint, error)
+	// BillingWallet — организация, с кошелька которой сейчас платит userID; nil — личный баланс
+	BillingWallet(userID uint) (*uint, error)
+
+	// ResolveWallet вызывается внутри транзакции оплаты брони и возвращает
+	// организацию, с кошелька которой надо списать amount; nil — личный баланс
+	ResolveWallet(tx *gorm.DB, userID uint, amount int) (*uint, error)
+
+	ListOrganizations() ([]models.Organization, error)
+	UpdateBillingSettings(orgID uint, req models.BillingSettingsDTO) (*models.Organization, error)
+}
+
+type organizationService struct {
+	repo     repository.OrganizationRepository
+	userRepo repository.UserRepository
+	db       *gorm.DB
+	logger   *slog.Logger
+}
+
+func NewOrganizationService(repo repository.OrganizationRepository, userRepo repository.UserRepository, db *gorm.DB, logger *slog.Logger) OrganizationService {
+	return &organizationService{repo: repo, userRepo: userRepo, db: db, logger: logger}
+}
+
+func (s *organizationService) Create(userID uint, req models.OrganizationDTO) (*models.Organization, error) {
+	if _, err := s.repo.GetMembership(userID); err == nil {
+		return nil, errors.New("вы уже состоите в организации")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	org := &models.Organization{
+		Name:          strings.TrimSpace(req.Name),
+		TaxID:         req.TaxID,
+		Address:       req.Address,
+		BillingPolicy: req.BillingPolicy,
+	}
+	if org.BillingPolicy == "" {
+		org.BillingPolicy = models.BillingOrganization
+	}
+
+	owner := &models.OrganizationMember{UserID: userID, Role: models.OrgOwner}
+	if err := s.repo.CreateOrganization(org, owner); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (s *organizationService) GetMine(userID uint) (*models.OrganizationResDTO, error) {
+	member, err := s.membership(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := s.repo.GetOrganization(member.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	spent, err := s.repo.MemberSpent(s.db, org.ID, userID, monthStart(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.OrganizationResDTO{
+		Organization: *org,
+		Role:         member.Role,
+		MonthlyLimit: member.MonthlyLimit,
+		SpentMonth:   spent,
+	}, nil
+}
+
+func (s *organizationService) Update(userID uint, req models.OrganizationUpdateDTO) (*models.Organization, error) {
+	member, err := s.requireRole(userID, models.OrgOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := s.repo.GetOrganization(member.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		org.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.TaxID != nil {
+		org.TaxID = *req.TaxID
+	}
+	if req.Address != nil {
+		org.Address = *req.Address
+	}
+	if req.BillingPolicy != nil {
+		org.BillingPolicy = *req.BillingPolicy
+	}
+
+	if err := s.repo.UpdateOrganization(org); err != nil {
+		return nil, err
+	}
+	s.logger.Info("organization updated", "organization_id", org.ID, "by", userID)
+	return org, nil
+}
+
+func (s *organizationService) ListMembers(userID uint) ([]models.OrganizationMember, error) {
+	member, err := s.requireRole(userID, models.OrgOwner, models.OrgBooker)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListMembers(member.OrganizationID)
+}
+
+func (s *organizationService) UpdateMember(userID, memberUserID uint, req models.MemberUpdateDTO) (*models.OrganizationMember, error) {
+	owner, err := s.requireRole(userID, models.OrgOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := s.repo.GetMembership(memberUserID)
+	if err != nil {
+		return nil, err
+	}
+	if member.OrganizationID != owner.OrganizationID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	if req.Role != nil && *req.Role != member.Role {
+		if member.Role == models.OrgOwner {
+			if err := s.ensureAnotherOwner(member.OrganizationID); err != nil {
+				return nil, err
+			}
+		}
+		member.Role = *req.Role
+	}
+	if req.MonthlyLimit != nil {
+		member.MonthlyLimit = *req.MonthlyLimit
+	}
+
+	if err := s.repo.UpdateMember(member); err != nil {
+		return nil, err
+	}
+	s.logger.Info("organization member updated", "organization_id", member.OrganizationID, "user_id", memberUserID, "role", member.Role, "monthly_limit", member.MonthlyLimit)
+	return member, nil
+}
+
+// RemoveMember исключает участника; владелец может исключить любого,
+// остальные — только выйти сами
+func (s *organizationService) RemoveMember(userID, memberUserID uint) error {
+	actor, err := s.membership(userID)
+	if err != nil {
+		return err
+	}
+	if userID != memberUserID && actor.Role != models.OrgOwner {
+		return ErrOrgForbidden
+	}
+
+	member, err := s.repo.GetMembership(memberUserID)
+	if err != nil {
+		return err
+	}
+	if member.OrganizationID != actor.OrganizationID {
+		return gorm.ErrRecordNotFound
+	}
+	if member.Role == models.OrgOwner {
+		if err := s.ensureAnotherOwner(member.OrganizationID); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.RemoveMember(member.OrganizationID, memberUserID)
+}
+
+// Invite создает приглашение; токен возвращается владельцу, чтобы он передал
+// ссылку сотруднику
+func (s *organizationService) Invite(userID uint, req models.InvitationDTO) (*models.OrganizationInvitation, error) {
+	owner, err := s.requireRole(userID, models.OrgOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := generateInvitationToken()
+	if err != nil {
+		return nil, err
+	}
+
+	inv := &models.OrganizationInvitation{
+		OrganizationID: owner.OrganizationID,
+		Email:          strings.ToLower(strings.TrimSpace(req.Email)),
+		Role:           req.Role,
+		Token:          token,
+		InvitedBy:      userID,
+		ExpiresAt:      time.Now().Add(invitationTTL),
+	}
+	if err := s.repo.CreateInvitation(inv); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+func (s *organizationService) ListInvitations(userID uint) ([]models.OrganizationInvitation, error) {
+	owner, err := s.requireRole(userID, models.OrgOwner)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListInvitations(owner.OrganizationID)
+}
+
+func (s *organizationService) AcceptInvitation(userID uint, token string) (*models.OrganizationMember, error) {
+	inv, err := s.repo.GetInvitationByToken(token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvitationInvalid
+		}
+		return nil, err
+	}
+	if inv.AcceptedAt != nil || time.Now().After(inv.ExpiresAt) {
+		return nil, ErrInvitationInvalid
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(user.Email, inv.Email) {
+		return nil, errors.New("приглашение выписано на другой email")
+	}
+
+	if _, err := s.repo.GetMembership(userID); err == nil {
+		return nil, errors.New("вы уже состоите в организации")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	member := &models.OrganizationMember{
+		OrganizationID: inv.OrganizationID,
+		UserID:         userID,
+		Role:           inv.Role,
+	}
+	if err := s.repo.AcceptInvitation(inv, member); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvitationInvalid
+		}
+		return nil, err
+	}
+	return member, nil
+}
+
+func (s *organizationService) ListMemberBookings(userID uint, filter models.FilterOrgBookings) ([]models.Booking, error) {
+	member, err := s.requireRole(userID, models.OrgOwner, models.OrgBooker)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	filter.Offset = max(filter.Offset, 0)
+
+	return s.repo.ListMemberBookings(member.OrganizationID, filter)
+}
+
+// CanBookFor проверяет, что bookerID может бронировать от имени userID:
+// оба в одной организации, и у bookerID роль owner или booker
+func (s *organizationService) CanBookFor(bookerID, userID uint) error {
+	booker, err := s.requireRole(bookerID, models.OrgOwner, models.OrgBooker)
+	if err != nil {
+		return err
+	}
+
+	member, err := s.repo.GetMembership(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrOrgForbidden
+		}
+		return err
+	}
+	if member.OrganizationID != booker.OrganizationID {
+		return ErrOrgForbidden
+	}
+	return nil
+}
+
+func (s *organizationService) ManagedOrganization(userID uint) (uint, error) {
+	member, err := s.requireRole(userID, models.OrgOwner)
+	if err != nil {
+		return 0, err
+	}
+	return member.OrganizationID, nil
+}
+
+func (s *organizationService) BillingWallet(userID uint) (*uint, error) {
+	member, err := s.repo.GetMembership(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	org, err := s.repo.GetOrganization(member.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	if org.BillingPolicy == models.BillingPersonal {
+		return nil, nil
+	}
+	return &org.ID, nil
+}
+
+func (s *organizationService) ResolveWallet(tx *gorm.DB, userID uint, amount int) (*uint, error) {
+	// Строка участника блокируется, чтобы параллельные оплаты не обошли лимит
+	member, err := s.repo.LockMembership(tx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var org models.Organization
+	if err := tx.Select("id", "billing_policy").First(&org, member.OrganizationID).Error; err != nil {
+		return nil, err
+	}
+	if org.BillingPolicy == models.BillingPersonal {
+		return nil, nil
+	}
+
+	if member.MonthlyLimit > 0 && amount > 0 {
+		spent, err := s.repo.MemberSpent(tx, org.ID, userID, monthStart(time.Now()))
+		if err != nil {
+			return nil, err
+		}
+		if spent+amount > member.MonthlyLimit {
+			s.logger.Warn("member spending limit exceeded", "organization_id", org.ID, "user_id", userID, "spent", spent, "amount", amount, "limit", member.MonthlyLimit)
+			return nil, ErrSpendingLimit
+		}
+	}
+
+	return &org.ID, nil
+}
+
+func (s *organizationService) ListOrganizations() ([]models.Organization, error) {
+	return s.repo.ListOrganizations()
+}
+
+// UpdateBillingSettings переключает организацию между предоплатой и постоплатой.
+// Снижение лимита ниже текущего долга не списывает долг, а лишь блокирует новые списания.
+func (s *organizationService) UpdateBillingSettings(orgID uint, req models.BillingSettingsDTO) (*models.Organization, error) {
+	org, err := s.repo.GetOrganization(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Postpaid != nil {
+		org.Postpaid = *req.Postpaid
+	}
+	if req.CreditLimit != nil {
+		org.CreditLimit = *req.CreditLimit
+	}
+
+	if err := s.repo.UpdateBillingSettings(org); err != nil {
+		return nil, err
+	}
+	s.logger.Info("organization billing settings updated", "organization_id", orgID, "postpaid", org.Postpaid, "credit_limit", org.CreditLimit)
+	return org, nil
+}
+
+func (s *organizationService) membership(userID uint) (*models.OrganizationMember, error) {
+	member, err := s.repo.GetMembership(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotOrgMember
+		}
+		return nil, err
+	}
+	return member, nil
+}
+
+func (s *organizationService) requireRole(userID uint, roles ...models.OrgRole) (*models.OrganizationMember, error) {
+	member, err := s.membership(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		if member.Role == role {
+			return member, nil
+		}
+	}
+	return nil, ErrOrgForbidden
+}
+
+func (s *organizationService) ensureAnotherOwner(orgID uint) error {
+	owners, err := s.repo.CountOwners(orgID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return errors.New("в организации должен остаться хотя бы один владелец")
+	}
+	return nil
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+func generateInvitationToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/payment"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPaymentNotFound  = errors.New("платеж не найден")
+	ErrPaymentsDisabled = errors.New("онлайн-оплата не подключена")
+	// ErrRefundNotConfirmed — сумма уже списана с баланса, возврат нужно запросить повторно
+	ErrRefundNotConfirmed = errors.New("провайдер не подтвердил возврат, повторите запрос")
+)
+
+type PaymentService interface {
+	// CreateTopup создает намерение пополнить баланс. Повторный вызов с тем же
+	// ключом идемпотентности возвращает уже созданное намерение.
+	CreateTopup(ctx context.Context, userID uint, req models.TopupDTO, idempotencyKey string) (*models.PaymentIntent, error)
+	GetTopup(userID, id uint) (*models.PaymentIntent, error)
+	ListTopups(userID uint, filter models.FilterTransactions) ([]models.PaymentIntent, int64, error)
+
+	HandleWebhook(ctx context.Context, header http.Header, body []byte) error
+	Refund(ctx context.Context, adminID, intentID uint) (*models.PaymentIntent, error)
+
+	ExpireStale(ctx context.Context) error
+	RunExpirer(ctx context.Context, interval time.Duration)
+}
+
+type paymentService struct {
+	repo     repository.PaymentRepository
+	provider payment.PaymentProvider
+	db       *gorm.DB
+	ttl      time.Duration
+	logger   *slog.Logger
+}
+
+// NewPaymentService: provider может быть nil, тогда пополнение, вебхуки и
+// возвраты отвечают ErrPaymentsDisabled
+func NewPaymentService(repo repository.PaymentRepository, provider payment.PaymentProvider, db *gorm.DB, ttl time.Duration, logger *slog.Logger) PaymentService {
+	if ttl <= 0 {
+		ttl = 30 * time.Minute
+	}
+	return &paymentService{repo: repo, provider: provider, db: db, ttl: ttl, logger: logger}
+}
+
+func (s *paymentService) CreateTopup(ctx context.Context, userID uint, req models.TopupDTO, idempotencyKey string) (*models.PaymentIntent, error) {
+	if s.provider == nil {
+		return nil, ErrPaymentsDisabled
+	}
+	var key *string
+	if idempotencyKey != "" {
+		key = &idempotencyKey
+		if existing, err := s.repo.GetIntentByIdempotencyKey(userID, idempotencyKey); err == nil {
+			return existing, nil
+		}
+	}
+
+	intent := &models.PaymentIntent{
+		UserID:         userID,
+		Amount:         req.Amount,
+		Currency:       "RUB",
+		Provider:       s.provider.Name(),
+		Status:         models.PaymentPending,
+		IdempotencyKey: key,
+		ExpiresAt:      time.Now().Add(s.ttl),
+	}
+	if err := s.repo.CreateIntent(intent); err != nil {
+		// Параллельный запрос с тем же ключом мог успеть раньше
+		if key != nil {
+			if existing, getErr := s.repo.GetIntentByIdempotencyKey(userID, idempotencyKey); getErr == nil {
+				return existing, nil
+			}
+		}
+		return nil, err
+	}
+
+	created, err := s.provider.CreatePayment(ctx, payment.CreatePaymentRequest{
+		Amount:         intent.Amount,
+		Currency:       intent.Currency,
+		Description:    fmt.Sprintf("Пополнение баланса #%d", intent.ID),
+		IdempotencyKey: fmt.Sprintf("topup-%d", intent.ID),
+		ReturnURL:      req.ReturnURL,
+	})
+	if err != nil {
+		s.logger.Error("provider CreatePayment failed", "intent_id", intent.ID, "error", err)
+		intent.Status = models.PaymentFailed
+		intent.FailureReason = "провайдер недоступен"
+		if updErr := s.repo.UpdateIntent(intent); updErr != nil {
+			return nil, updErr
+		}
+		return nil, errors.New("не удалось создать платеж, попробуйте позже")
+	}
+
+	intent.ProviderPaymentID = &created.ID
+	intent.ConfirmationURL = created.ConfirmationURL
+	if err := s.repo.UpdateIntent(intent); err != nil {
+		return nil, err
+	}
+
+	return intent, nil
+}
+
+func (s *paymentService) GetTopup(userID, id uint) (*models.PaymentIntent, error) {
+	intent, err := s.repo.GetUserIntent(userID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	return intent, nil
+}
+
+func (s *paymentService) ListTopups(userID uint, filter models.FilterTransactions) ([]models.PaymentIntent, int64, error) {
+	limit := filter.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.repo.ListUserIntents(userID, limit, max(filter.Offset, 0))
+}
+
+// HandleWebhook обрабатывает уведомление провайдера. Событие с уже виденным
+// id игнорируется, поэтому повторная доставка безопасна.
+func (s *paymentService) HandleWebhook(ctx context.Context, header http.Header, body []byte) error {
+	if s.provider == nil {
+		return ErrPaymentsDisabled
+	}
+	ev, err := s.provider.ParseWebhook(header, body)
+	if err != nil {
+		s.logger.Warn("payment webhook rejected", "provider", s.provider.Name(), "error", err)
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		intent, err := s.repo.LockIntentByProviderID(tx, s.provider.Name(), ev.PaymentID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				s.logger.Warn("payment webhook for unknown payment", "payment_id", ev.PaymentID)
+				return ErrPaymentNotFound
+			}
+			return err
+		}
+
+		fresh, err := s.repo.RecordWebhookEvent(tx, &models.PaymentWebhookEvent{
+			Provider:        s.provider.Name(),
+			EventID:         ev.EventID,
+			PaymentIntentID: &intent.ID,
+			Status:          string(ev.Status),
+			Payload:         string(body),
+		})
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			s.logger.Info("duplicate payment webhook ignored", "event_id", ev.EventID)
+			return nil
+		}
+
+		if ev.Status == payment.StatusSucceeded && ev.Amount != intent.Amount {
+			s.logger.Error("payment amount mismatch", "intent_id", intent.ID, "expected", intent.Amount, "got", ev.Amount)
+			return errors.New("сумма платежа не совпадает")
+		}
+
+		return s.applyStatus(tx, intent, ev.Status, nil)
+	})
+}
+
+// Refund возвращает успешное пополнение через провайдера. Сначала отдельной
+// транзакцией сумма списывается с баланса и намерение переходит в refunding,
+// и только потом, уже без блокировки, вызывается провайдер. Если вызов или
+// последний шаг не пройдут, возврат завершит повторный Refund или вебхук refunded:
+// деньги не окажутся одновременно и у пользователя, и на балансе.
+func (s *paymentService) Refund(ctx context.Context, adminID, intentID uint) (*models.PaymentIntent, error) {
+	if s.provider == nil {
+		return nil, ErrPaymentsDisabled
+	}
+	var intent *models.PaymentIntent
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		intent, err = s.repo.LockIntent(tx, intentID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPaymentNotFound
+			}
+			return err
+		}
+		if intent.ProviderPaymentID == nil {
+			return errors.New("вернуть можно только успешное пополнение")
+		}
+		switch intent.Status {
+		case models.PaymentRefunding:
+			// Повтор после сбоя: баланс уже списан, осталось подтвердить у провайдера
+			return nil
+		case models.PaymentSucceeded:
+		default:
+			return errors.New("вернуть можно только успешное пополнение")
+		}
+
+		if err := s.postTopupRefund(tx, intent, &adminID); err != nil {
+			return err
+		}
+		intent.Status = models.PaymentRefunding
+		return tx.Save(intent).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.provider.Refund(ctx, *intent.ProviderPaymentID, intent.Amount); err != nil {
+		s.logger.Error("provider Refund failed", "intent_id", intent.ID, "error", err)
+		return nil, fmt.Errorf("%w: %v", ErrRefundNotConfirmed, err)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if intent, err = s.repo.LockIntent(tx, intentID); err != nil {
+			return err
+		}
+		return s.applyStatus(tx, intent, payment.StatusRefunded, &adminID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return intent, nil
+}
+
+// postTopupRefund списывает сумму пополнения с баланса. Возврат уже решен
+// администратором или провайдером, поэтому баланс может уйти в минус.
+func (s *paymentService) postTopupRefund(tx *gorm.DB, intent *models.PaymentIntent, adminID *uint) error {
+	intentID := intent.ID
+	_, err := repository.PostLedger(tx, models.LedgerPosting{
+		UserID:        intent.UserID,
+		Type:          models.LedgerTopupRefund,
+		Amount:        -intent.Amount,
+		Reason:        "topup_refund",
+		PaymentID:     &intentID,
+		AdminID:       adminID,
+		AllowNegative: true,
+	})
+	return err
+}
+
+// applyStatus переводит намерение в новый статус и проводит деньги по журналу
+func (s *paymentService) applyStatus(tx *gorm.DB, intent *models.PaymentIntent, status payment.Status, adminID *uint) error {
+	now := time.Now()
+	intentID := intent.ID
+
+	switch status {
+	case payment.StatusSucceeded:
+		// Просроченное намерение тоже зачисляем: деньги у провайдера уже списаны
+		if intent.Status != models.PaymentPending && intent.Status != models.PaymentExpired {
+			s.logger.Info("payment already finalized", "intent_id", intent.ID, "status", intent.Status)
+			return nil
+		}
+		if _, err := repository.PostLedger(tx, models.LedgerPosting{
+			UserID:    intent.UserID,
+			Type:      models.LedgerTopup,
+			Amount:    intent.Amount,
+			Reason:    "topup",
+			PaymentID: &intentID,
+		}); err != nil {
+			return err
+		}
+		intent.Status = models.PaymentSucceeded
+		intent.PaidAt = &now
+
+	case payment.StatusFailed, payment.StatusCanceled:
+		if intent.Status != models.PaymentPending && intent.Status != models.PaymentExpired {
+			return nil
+		}
+		intent.Status = models.PaymentFailed
+		intent.FailureReason = string(status)
+
+	case payment.StatusRefunded:
+		switch intent.Status {
+		case models.PaymentRefunding:
+			// Баланс списан в Refund, остается отметить возврат завершенным
+		case models.PaymentSucceeded:
+			// Возврат оформлен у провайдера в обход Refund
+			if err := s.postTopupRefund(tx, intent, adminID); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+		intent.Status = models.PaymentRefunded
+		intent.RefundedAt = &now
+
+	default:
+		s.logger.Warn("unknown payment status ignored", "intent_id", intent.ID, "status", status)
+		return nil
+	}
+
+	if err := tx.Save(intent).Error; err != nil {
+		return err
+	}
+
+	s.logger.Info("payment intent updated", "intent_id", intent.ID, "user_id", intent.UserID, "status", intent.Status)
+	return nil
+}
+
+func (s *paymentService) ExpireStale(ctx context.Context) error {
+	n, err := s.repo.ExpirePending(time.Now())
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		s.logger.Info("payment intents expired", "count", n)
+	}
+	return nil
+}
+
+// RunExpirer периодически закрывает просроченные намерения до отмены контекста
+func (s *paymentService) RunExpirer(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.ExpireStale(ctx); err != nil {
+			s.logger.Error("ExpireStale failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
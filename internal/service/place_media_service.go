@@ -0,0 +1,304 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/media"
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+	"github.com/IslamCHup/coworking-manager-project/internal/storage"
+	"gorm.io/gorm"
+)
+
+const (
+	MaxPlaceImageSize = 10 << 20
+	maxPlaceImages    = 20
+	thumbnailSide     = 320
+	// ограничение на размер в пикселях, чтобы не раскодировать «бомбы»
+	maxImagePixels = 50_000_000
+)
+
+var placeImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+var (
+	ErrPlaceImageNotFound = errors.New("фотография не найдена")
+	ErrInvalidPlaceImage  = errors.New("фотография должна быть в формате JPEG, PNG или GIF")
+	ErrTooManyPlaceImages = errors.New("у места уже максимальное число фотографий")
+	ErrInvalidImageOrder  = errors.New("порядок должен содержать все фотографии места ровно по одному разу")
+	ErrInvalidMediaLink   = storage.ErrInvalidSignature
+)
+
+type PlaceMediaService interface {
+	// Gallery возвращает фотографии места по порядку с подписанными ссылками
+	Gallery(placeID uint) ([]models.PlaceImageView, error)
+
+	Upload(adminID, placeID uint, data []byte, caption string) (*models.PlaceImageView, error)
+	UpdateImage(adminID, placeID, id uint, req models.PlaceImageUpdateDTO) (*models.PlaceImageView, error)
+	Reorder(adminID, placeID uint, ids []uint) ([]models.PlaceImageView, error)
+	DeleteImage(adminID, placeID, id uint) error
+
+	// Open отдает файл по подписанной ссылке
+	Open(key, expires, sig string) (io.ReadCloser, string, error)
+}
+
+type placeMediaService struct {
+	repo      repository.PlaceImageRepository
+	placeRepo repository.PlaceRepository
+	blobs     storage.BlobStore
+	signer    *storage.URLSigner
+	locations LocationService
+	logger    *slog.Logger
+}
+
+func NewPlaceMediaService(repo repository.PlaceImageRepository, placeRepo repository.PlaceRepository, blobs storage.BlobStore, signer *storage.URLSigner, locations LocationService, logger *slog.Logger) PlaceMediaService {
+	return &placeMediaService{repo: repo, placeRepo: placeRepo, blobs: blobs, signer: signer, locations: locations, logger: logger}
+}
+
+func (s *placeMediaService) Gallery(placeID uint) ([]models.PlaceImageView, error) {
+	images, err := s.repo.ListImages(placeID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	views := make([]models.PlaceImageView, 0, len(images))
+	for i := range images {
+		views = append(views, s.view(&images[i], now))
+	}
+	return views, nil
+}
+
+func (s *placeMediaService) Upload(adminID, placeID uint, data []byte, caption string) (*models.PlaceImageView, error) {
+	if err := s.checkPlace(adminID, placeID); err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: файл пустой", ErrInvalidPlaceImage)
+	}
+	if len(data) > MaxPlaceImageSize {
+		return nil, fmt.Errorf("%w: размер больше %d МБ", ErrInvalidPlaceImage, MaxPlaceImageSize>>20)
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := placeImageTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: получен %s", ErrInvalidPlaceImage, contentType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidPlaceImage
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, fmt.Errorf("%w: слишком большое разрешение %dx%d", ErrInvalidPlaceImage, cfg.Width, cfg.Height)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidPlaceImage
+	}
+
+	existing, err := s.repo.ListImages(placeID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxPlaceImages {
+		return nil, fmt.Errorf("%w: %d", ErrTooManyPlaceImages, maxPlaceImages)
+	}
+
+	var thumb bytes.Buffer
+	if err := media.EncodeJPEG(&thumb, media.Thumbnail(src, thumbnailSide)); err != nil {
+		return nil, err
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+	img := &models.PlaceImage{
+		PlaceID:     placeID,
+		Caption:     strings.TrimSpace(caption),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		Key:         fmt.Sprintf("places/%d/%s%s", placeID, name, ext),
+		ThumbKey:    fmt.Sprintf("places/%d/%s_thumb.jpg", placeID, name),
+	}
+
+	if err := s.blobs.Put(img.Key, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	if err := s.blobs.Put(img.ThumbKey, &thumb); err != nil {
+		s.removeBlobs(img)
+		return nil, err
+	}
+	if err := s.repo.CreateImage(img); err != nil {
+		s.removeBlobs(img)
+		return nil, err
+	}
+
+	s.logger.Info("place image uploaded", "place_id", placeID, "image_id", img.ID, "admin_id", adminID, "size", img.Size)
+	view := s.view(img, time.Now())
+	return &view, nil
+}
+
+func (s *placeMediaService) UpdateImage(adminID, placeID, id uint, req models.PlaceImageUpdateDTO) (*models.PlaceImageView, error) {
+	img, err := s.image(adminID, placeID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Caption != nil {
+		img.Caption = strings.TrimSpace(*req.Caption)
+	}
+	if err := s.repo.UpdateImage(img); err != nil {
+		return nil, err
+	}
+
+	view := s.view(img, time.Now())
+	return &view, nil
+}
+
+func (s *placeMediaService) Reorder(adminID, placeID uint, ids []uint) ([]models.PlaceImageView, error) {
+	if err := s.checkPlace(adminID, placeID); err != nil {
+		return nil, err
+	}
+
+	images, err := s.repo.ListImages(placeID)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make([]uint, 0, len(images))
+	for _, img := range images {
+		current = append(current, img.ID)
+	}
+	requested := slices.Clone(ids)
+	slices.Sort(current)
+	slices.Sort(requested)
+	if !slices.Equal(current, requested) {
+		return nil, ErrInvalidImageOrder
+	}
+
+	if err := s.repo.Reorder(placeID, ids); err != nil {
+		return nil, err
+	}
+	return s.Gallery(placeID)
+}
+
+func (s *placeMediaService) DeleteImage(adminID, placeID, id uint) error {
+	img, err := s.image(adminID, placeID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteImage(placeID, id); err != nil {
+		return err
+	}
+	s.removeBlobs(img)
+
+	s.logger.Info("place image deleted", "place_id", placeID, "image_id", id, "admin_id", adminID)
+	return nil
+}
+
+func (s *placeMediaService) Open(key, expires, sig string) (io.ReadCloser, string, error) {
+	if err := s.signer.Verify(key, expires, sig, time.Now()); err != nil {
+		return nil, "", err
+	}
+
+	rc, err := s.blobs.Open(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrBlobNotFound) {
+			return nil, "", ErrPlaceImageNotFound
+		}
+		return nil, "", err
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return rc, contentType, nil
+}
+
+func (s *placeMediaService) view(img *models.PlaceImage, now time.Time) models.PlaceImageView {
+	url, expires := s.signer.Sign(img.Key, now)
+	thumbURL, _ := s.signer.Sign(img.ThumbKey, now)
+	return models.PlaceImageView{
+		ID:           img.ID,
+		Position:     img.Position,
+		Caption:      img.Caption,
+		ContentType:  img.ContentType,
+		Width:        img.Width,
+		Height:       img.Height,
+		URL:          url,
+		ThumbnailURL: thumbURL,
+		ExpiresAt:    expires,
+	}
+}
+
+func (s *placeMediaService) checkPlace(adminID, placeID uint) error {
+	if err := s.locations.CheckPlace(adminID, placeID); err != nil {
+		return err
+	}
+	if _, err := s.placeRepo.GetPlaceByID(placeID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPlaceNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *placeMediaService) image(adminID, placeID, id uint) (*models.PlaceImage, error) {
+	if err := s.checkPlace(adminID, placeID); err != nil {
+		return nil, err
+	}
+	img, err := s.repo.GetImage(placeID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlaceImageNotFound
+		}
+		return nil, err
+	}
+	return img, nil
+}
+
+// removeBlobs удаляет файлы снимка; ошибки только логируются, чтобы не
+// откатывать уже выполненное изменение в базе
+func (s *placeMediaService) removeBlobs(img *models.PlaceImage) {
+	for _, key := range []string{img.Key, img.ThumbKey} {
+		if err := s.blobs.Delete(key); err != nil {
+			s.logger.Warn("failed to delete blob", "key", key, "error", err)
+		}
+	}
+}
+
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
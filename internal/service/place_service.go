@@ -2,27 +2,51 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/IslamCHup/coworking-manager-project/internal/models"
 	"github.com/IslamCHup/coworking-manager-project/internal/repository"
 	"gorm.io/gorm"
 )
 
-var ErrPlaceNotFound = errors.New("place not found")
+var (
+	ErrPlaceNotFound          = errors.New("place not found")
+	ErrPlaceHasFutureBookings = errors.New("у места есть будущие брони")
+	ErrBookingsNotSettled     = errors.New("не все брони места удалось отменить")
+	ErrInvalidPlaceSearch     = errors.New("неверные параметры поиска")
+)
 
 type PlaceService interface {
 	ListPlaces(filter *models.FilterPlace) (*[]models.Place, error)
 	GetPlaceByID(id uint) (*models.Place, error)
 	ListFreePlaces(filter *models.FilterPlace) (*[]models.Place, error)
+	Search(filter *models.FilterPlaceSearch) ([]models.PlaceSearchHit, int64, error)
+
+	// Изменять места администратор может только на своих площадках
+	Create(adminID uint, req models.PlaceCreateDTO) (*models.Place, error)
+	// Update, Deactivate и Delete отказывают, если у места есть будущие брони;
+	// в режиме cascade такие брони отменяются с возвратом денег, в режиме
+	// relocate переносятся на равноценные места
+	Update(adminID, id uint, req models.PlaceUpdateDTO, mode models.PlaceRemovalMode) (*models.Place, *models.PlaceRemovalResult, error)
+	Deactivate(adminID, id uint, mode models.PlaceRemovalMode) (*models.PlaceRemovalResult, error)
+	Delete(adminID, id uint, mode models.PlaceRemovalMode) (*models.PlaceRemovalResult, error)
 }
 
 type placeService struct {
-	placeRepo repository.PlaceRepository
-	db        *gorm.DB
+	placeRepo   repository.PlaceRepository
+	db          *gorm.DB
+	bookings    BookingService
+	locations   LocationService
+	relocations RelocationService
+	logger      *slog.Logger
 }
 
-func NewPlaceService(placeRepo repository.PlaceRepository, db *gorm.DB) PlaceService {
-	return &placeService{placeRepo: placeRepo, db: db}
+func NewPlaceService(placeRepo repository.PlaceRepository, db *gorm.DB, bookings BookingService, locations LocationService, relocations RelocationService, logger *slog.Logger) PlaceService {
+	return &placeService{placeRepo: placeRepo, db: db, bookings: bookings, locations: locations, relocations: relocations, logger: logger}
 }
 
 func (s *placeService) ListPlaces(filter *models.FilterPlace) (*[]models.Place, error) {
@@ -50,4 +74,269 @@ func (s *placeService) ListFreePlaces(filter *models.FilterPlace) (*[]models.Pla
 		return nil, err
 	}
 	return places, nil
-}
\ No newline at end of file
+}
+
+func (s *placeService) Search(filter *models.FilterPlaceSearch) ([]models.PlaceSearchHit, int64, error) {
+	filter.Query = strings.TrimSpace(filter.Query)
+	if len([]rune(filter.Query)) < 2 {
+		return nil, 0, fmt.Errorf("%w: запрос слишком короткий", ErrInvalidPlaceSearch)
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return nil, 0, fmt.Errorf("%w: min_price больше max_price", ErrInvalidPlaceSearch)
+	}
+	if (filter.StartTime == nil) != (filter.EndTime == nil) {
+		return nil, 0, fmt.Errorf("%w: start_time и end_time задаются вместе", ErrInvalidPlaceSearch)
+	}
+	if filter.StartTime != nil && !filter.EndTime.After(*filter.StartTime) {
+		return nil, 0, fmt.Errorf("%w: время окончания должно быть позже времени начала", ErrInvalidPlaceSearch)
+	}
+
+	return s.placeRepo.SearchPlaces(filter)
+}
+
+func (s *placeService) Create(adminID uint, req models.PlaceCreateDTO) (*models.Place, error) {
+	locationID, floorID, zoneID, err := s.placement(adminID, req.LocationID, req.FloorID, req.ZoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	place := &models.Place{
+		Name:         strings.TrimSpace(req.Name),
+		Type:         req.Type,
+		Description:  req.Description,
+		PricePerHour: req.PricePerHour,
+		IsActive:     true,
+		Capacity:     max(req.Capacity, 1),
+		Amenities:    normalizeAmenities(req.Amenities),
+		LocationID:   locationID,
+		FloorID:      floorID,
+		ZoneID:       zoneID,
+	}
+	if req.IsActive != nil {
+		place.IsActive = *req.IsActive
+	}
+
+	if err := s.placeRepo.CreatePlace(place); err != nil {
+		return nil, err
+	}
+	return place, nil
+}
+
+func (s *placeService) Update(adminID, id uint, req models.PlaceUpdateDTO, mode models.PlaceRemovalMode) (*models.Place, *models.PlaceRemovalResult, error) {
+	if err := s.locations.CheckPlace(adminID, id); err != nil {
+		return nil, nil, err
+	}
+	place, err := s.GetPlaceByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Размещение меняется целиком: новая зона переносит место и на ее этаж
+	if req.LocationID != nil || req.FloorID != nil || req.ZoneID != nil {
+		if place.LocationID, place.FloorID, place.ZoneID, err = s.placement(adminID, req.LocationID, req.FloorID, req.ZoneID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Отключение проходит ту же проверку будущих броней, что и Deactivate.
+	// Повторный запрос с cascade или relocate для уже отключенного места
+	// разбирает брони, которые не удалось обработать в прошлый раз.
+	deactivating := req.IsActive != nil && !*req.IsActive && (place.IsActive || mode != models.RemovalRefuse)
+
+	if req.Name != nil {
+		place.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Type != nil {
+		place.Type = *req.Type
+	}
+	if req.Description != nil {
+		place.Description = *req.Description
+	}
+	if req.PricePerHour != nil {
+		place.PricePerHour = *req.PricePerHour
+	}
+	if req.IsActive != nil {
+		place.IsActive = *req.IsActive
+	}
+	if req.Capacity != nil {
+		place.Capacity = *req.Capacity
+	}
+	if req.Amenities != nil {
+		place.Amenities = normalizeAmenities(*req.Amenities)
+	}
+
+	if !deactivating {
+		if err := s.placeRepo.UpdatePlace(place); err != nil {
+			return nil, nil, err
+		}
+		return place, nil, nil
+	}
+
+	// Сначала место отключается, и только потом выбираются его брони: новых
+	// после отключения уже не появится. Без cascade или relocate отключение
+	// с будущими бронями откатывается.
+	count, err := s.placeRepo.DeactivatePlace(place, time.Now(), mode != models.RemovalRefuse)
+	if err != nil {
+		if errors.Is(err, repository.ErrPlaceHasBookings) {
+			return nil, nil, fmt.Errorf("%w: %d", ErrPlaceHasFutureBookings, count)
+		}
+		return nil, nil, err
+	}
+	future, err := s.placeRepo.ListFutureBookings(id, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := s.settleBookings(adminID, id, future, mode)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(result.FailedBookings) > 0 {
+		return place, result, fmt.Errorf("%w: %d из %d", ErrBookingsNotSettled, len(result.FailedBookings), len(future))
+	}
+	return place, result, nil
+}
+
+func (s *placeService) Deactivate(adminID, id uint, mode models.PlaceRemovalMode) (*models.PlaceRemovalResult, error) {
+	inactive := false
+	_, result, err := s.Update(adminID, id, models.PlaceUpdateDTO{IsActive: &inactive}, mode)
+	if err != nil {
+		return result, err
+	}
+	if result == nil {
+		result = &models.PlaceRemovalResult{PlaceID: id, CancelledBookings: []uint{}}
+	}
+	return result, nil
+}
+
+func (s *placeService) Delete(adminID, id uint, mode models.PlaceRemovalMode) (*models.PlaceRemovalResult, error) {
+	if err := s.locations.CheckPlace(adminID, id); err != nil {
+		return nil, err
+	}
+	place, err := s.GetPlaceByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *models.PlaceRemovalResult
+	if place.IsActive {
+		result, err = s.Deactivate(adminID, id, mode)
+	} else {
+		// У отключенного раньше места могли остаться брони
+		var future []models.Booking
+		if future, err = s.futureBookings(id, mode); err == nil {
+			result, err = s.settleBookings(adminID, id, future, mode)
+		}
+		if err == nil && len(result.FailedBookings) > 0 {
+			err = fmt.Errorf("%w: %d из %d", ErrBookingsNotSettled, len(result.FailedBookings), len(future))
+		}
+	}
+	if err != nil {
+		// место с неотмененными бронями не удаляется, чтобы их можно было разобрать
+		return result, err
+	}
+
+	if err := s.placeRepo.DeletePlace(id); err != nil {
+		return nil, err
+	}
+	s.logger.Info("place deleted", "place_id", id, "cancelled_bookings", len(result.CancelledBookings), "relocated_bookings", len(result.RelocatedBookings))
+	return result, nil
+}
+
+// futureBookings возвращает брони, мешающие отключить место; без cascade или
+// relocate их наличие — ошибка
+func (s *placeService) futureBookings(id uint, mode models.PlaceRemovalMode) ([]models.Booking, error) {
+	bookings, err := s.placeRepo.ListFutureBookings(id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(bookings) > 0 && mode == models.RemovalRefuse {
+		return nil, fmt.Errorf("%w: %d", ErrPlaceHasFutureBookings, len(bookings))
+	}
+	return bookings, nil
+}
+
+// settleBookings отменяет или переносит будущие брони отключенного места.
+// Брони, которые не удалось перенести, остаются на месте в очереди на перенос.
+func (s *placeService) settleBookings(adminID, id uint, bookings []models.Booking, mode models.PlaceRemovalMode) (*models.PlaceRemovalResult, error) {
+	if mode != models.RemovalRelocate {
+		return s.cancelBookings(id, bookings)
+	}
+
+	batch, err := s.relocations.RelocateBookings(adminID, bookings, models.RelocationDeactivation, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.PlaceRemovalResult{
+		PlaceID:           id,
+		CancelledBookings: []uint{},
+		RelocatedBookings: []uint{},
+		QueuedBookings:    []uint{},
+	}
+	for _, r := range batch.Relocated {
+		result.RelocatedBookings = append(result.RelocatedBookings, r.BookingID)
+	}
+	for _, r := range batch.Queued {
+		result.QueuedBookings = append(result.QueuedBookings, r.BookingID)
+	}
+	return result, nil
+}
+
+// cancelBookings отменяет брони через BookingService, который возвращает деньги
+// и рассылает события. Каждая бронь отменяется в своей транзакции, поэтому
+// ошибка по одной не останавливает остальные: она попадает в FailedBookings.
+func (s *placeService) cancelBookings(id uint, bookings []models.Booking) (*models.PlaceRemovalResult, error) {
+	result := &models.PlaceRemovalResult{PlaceID: id, CancelledBookings: []uint{}}
+	for _, b := range bookings {
+		refunded, err := s.bookings.UpdateBookingStatusWithBalance(b.ID, models.BookingCancelled)
+		if err != nil {
+			s.logger.Error("cascade cancel failed", "place_id", id, "booking_id", b.ID, "error", err)
+			result.FailedBookings = append(result.FailedBookings, models.BookingFailure{BookingID: b.ID, Error: err.Error()})
+			continue
+		}
+		result.CancelledBookings = append(result.CancelledBookings, b.ID)
+		result.Refunded += refunded
+	}
+
+	if len(bookings) > 0 {
+		s.logger.Info("future bookings cancelled for place", "place_id", id, "count", len(result.CancelledBookings), "failed", len(result.FailedBookings), "refunded", result.Refunded)
+	}
+	return result, nil
+}
+
+// placement проверяет размещение и то, что площадка закреплена за администратором.
+// Место без площадки может завести только администратор без ограничений.
+func (s *placeService) placement(adminID uint, locationID, floorID, zoneID *uint) (*uint, *uint, *uint, error) {
+	locationID, floorID, zoneID, err := s.locations.ResolvePlacement(locationID, floorID, zoneID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if locationID == nil {
+		scope, err := s.locations.Scope(adminID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if scope != nil {
+			return nil, nil, nil, ErrLocationForbidden
+		}
+	} else if err := s.locations.CheckLocation(adminID, *locationID); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return locationID, floorID, zoneID, nil
+}
+
+// normalizeAmenities приводит удобства к нижнему регистру и убирает повторы,
+// чтобы подбор замены сравнивал их как множества
+func normalizeAmenities(items []string) models.StringList {
+	result := models.StringList{}
+	for _, item := range items {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item != "" && !slices.Contains(result, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+	"gorm.io/gorm"
+)
+
+// maxQuoteDuration ограничивает длину брони: цена считается по часам, и запрос
+// на годы вперед надолго занял бы процессор
+const maxQuoteDuration = 31 * 24 * time.Hour
+
+var ErrQuoteRangeTooLong = errors.New("бронь не может быть длиннее 31 дня")
+
+type PricingService interface {
+	Quote(placeID uint, req models.QuoteRequestDTO) (*models.PriceQuote, error)
+	QuotePlace(place *models.Place, start, end time.Time) (*models.PriceQuote, error)
+
+	CreateRule(req models.PriceRuleDTO) (*models.PriceRule, error)
+	ListRules() ([]models.PriceRule, error)
+	UpdateRule(id uint, req models.PriceRuleDTO) (*models.PriceRule, error)
+	DeleteRule(id uint) error
+}
+
+type pricingService struct {
+	repo      repository.PriceRuleRepository
+	placeRepo repository.PlaceRepository
+	logger    *slog.Logger
+}
+
+func NewPricingService(repo repository.PriceRuleRepository, placeRepo repository.PlaceRepository, logger *slog.Logger) PricingService {
+	return &pricingService{repo: repo, placeRepo: placeRepo, logger: logger}
+}
+
+func (s *pricingService) Quote(placeID uint, req models.QuoteRequestDTO) (*models.PriceQuote, error) {
+	start, err := time.Parse("2006-01-02 15", req.StartTime)
+	if err != nil {
+		return nil, errors.New("неправильный формат времени, нужен YYYY-MM-DD HH")
+	}
+	end, err := time.Parse("2006-01-02 15", req.EndTime)
+	if err != nil {
+		return nil, errors.New("неправильный формат времени, нужен YYYY-MM-DD HH")
+	}
+	if err := checkQuoteRange(start, end); err != nil {
+		return nil, err
+	}
+
+	place, err := s.placeRepo.GetPlaceByID(placeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlaceNotFound
+		}
+		return nil, err
+	}
+
+	return s.QuotePlace(place, start, end)
+}
+
+// QuotePlace считает цену брони по часам: к каждому часу применяется самое
+// приоритетное подходящее hourly-правило, затем к сумме — скидка за длительность
+// (см. matchDurationRule)
+func (s *pricingService) QuotePlace(place *models.Place, start, end time.Time) (*models.PriceQuote, error) {
+	if err := checkQuoteRange(start, end); err != nil {
+		return nil, err
+	}
+
+	rules, err := s.repo.ListActiveRulesForPlace(place)
+	if err != nil {
+		return nil, err
+	}
+	sortRules(rules)
+
+	quote := &models.PriceQuote{
+		PlaceID:   place.ID,
+		StartTime: start,
+		EndTime:   end,
+		Hours:     int(math.Ceil(end.Sub(start).Hours())),
+	}
+
+	for segStart := start; segStart.Before(end); segStart = segStart.Add(time.Hour) {
+		segEnd := segStart.Add(time.Hour)
+		if segEnd.After(end) {
+			segEnd = end
+		}
+
+		base := int(math.Round(float64(place.PricePerHour) * segEnd.Sub(segStart).Hours()))
+		segment := models.PriceSegment{
+			StartTime:  segStart,
+			EndTime:    segEnd,
+			BasePrice:  base,
+			Multiplier: 1,
+			Price:      base,
+		}
+
+		if rule := matchHourlyRule(rules, segStart); rule != nil {
+			segment.Multiplier = rule.Multiplier
+			segment.Price = int(math.Round(float64(base) * rule.Multiplier))
+			segment.Rule = rule.Name
+		}
+
+		quote.Segments = append(quote.Segments, segment)
+		quote.Subtotal += segment.Price
+	}
+
+	if rule := matchDurationRule(rules, end.Sub(start).Hours()); rule != nil {
+		quote.DurationDiscount = quote.Subtotal * rule.DiscountPercent / 100
+		quote.DiscountRule = rule.Name
+	}
+
+	quote.Total = quote.Subtotal - quote.DurationDiscount
+	return quote, nil
+}
+
+func checkQuoteRange(start, end time.Time) error {
+	if !end.After(start) {
+		return errors.New("неверный диапазон времени: время окончания должно быть позже времени начала")
+	}
+	if end.Sub(start) > maxQuoteDuration {
+		return ErrQuoteRangeTooLong
+	}
+	return nil
+}
+
+func (s *pricingService) CreateRule(req models.PriceRuleDTO) (*models.PriceRule, error) {
+	rule := &models.PriceRule{IsActive: true}
+	if err := applyPriceRuleDTO(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *pricingService) ListRules() ([]models.PriceRule, error) {
+	return s.repo.ListRules()
+}
+
+func (s *pricingService) UpdateRule(id uint, req models.PriceRuleDTO) (*models.PriceRule, error) {
+	rule, err := s.repo.GetRule(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyPriceRuleDTO(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *pricingService) DeleteRule(id uint) error {
+	return s.repo.DeleteRule(id)
+}
+
+func applyPriceRuleDTO(rule *models.PriceRule, req models.PriceRuleDTO) error {
+	switch req.Kind {
+	case models.PriceRuleHourly:
+		if req.Multiplier <= 0 {
+			return errors.New("для почасового правила нужен multiplier больше 0")
+		}
+		if req.EndHour <= req.StartHour {
+			return errors.New("end_hour должен быть больше start_hour")
+		}
+	case models.PriceRuleDuration:
+		if req.MinHours <= 0 || req.DiscountPercent <= 0 {
+			return errors.New("для скидки за длительность нужны min_hours и discount_percent")
+		}
+	default:
+		return errors.New("неверный тип правила")
+	}
+
+	weekdays := make([]string, 0, len(req.Weekdays))
+	for _, d := range req.Weekdays {
+		weekdays = append(weekdays, strconv.Itoa(d))
+	}
+
+	rule.Name = req.Name
+	rule.Kind = req.Kind
+	rule.PlaceID = req.PlaceID
+	rule.PlaceType = req.PlaceType
+	rule.Weekdays = strings.Join(weekdays, ",")
+	rule.StartHour = req.StartHour
+	rule.EndHour = req.EndHour
+	rule.Multiplier = req.Multiplier
+	rule.MinHours = req.MinHours
+	rule.DiscountPercent = req.DiscountPercent
+	rule.Priority = req.Priority
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// sortRules упорядочивает правила: сначала по приоритету, при равном —
+// более конкретные (место, затем тип места) раньше общих
+func sortRules(rules []models.PriceRule) {
+	specificity := func(r models.PriceRule) int {
+		switch {
+		case r.PlaceID != nil:
+			return 2
+		case r.PlaceType != nil:
+			return 1
+		}
+		return 0
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority > rules[j].Priority
+		}
+		return specificity(rules[i]) > specificity(rules[j])
+	})
+}
+
+func matchHourlyRule(rules []models.PriceRule, at time.Time) *models.PriceRule {
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Kind != models.PriceRuleHourly {
+			continue
+		}
+		if rule.Weekdays != "" && !slices.Contains(strings.Split(rule.Weekdays, ","), strconv.Itoa(int(at.Weekday()))) {
+			continue
+		}
+		if at.Hour() >= rule.StartHour && at.Hour() < rule.EndHour {
+			return rule
+		}
+	}
+	return nil
+}
+
+// matchDurationRule выбирает скидку за длительность: из подходящих правил —
+// с наибольшим порогом min_hours, при равном пороге — с большей скидкой, а при
+// полном равенстве — первое в порядке sortRules
+func matchDurationRule(rules []models.PriceRule, hours float64) *models.PriceRule {
+	var best *models.PriceRule
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Kind != models.PriceRuleDuration || hours < float64(rule.MinHours) {
+			continue
+		}
+		if best == nil || rule.MinHours > best.MinHours ||
+			rule.MinHours == best.MinHours && rule.DiscountPercent > best.DiscountPercent {
+			best = rule
+		}
+	}
+	return best
+}
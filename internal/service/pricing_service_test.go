@@ -0,0 +1,154 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+)
+
+func TestSortRules(t *testing.T) {
+	placeID := uint(7)
+	workspace := models.PlaceWorkspace
+
+	rules := []models.PriceRule{
+		{Name: "общее", Priority: 0},
+		{Name: "тип места", Priority: 0, PlaceType: &workspace},
+		{Name: "место", Priority: 0, PlaceID: &placeID},
+		{Name: "приоритет", Priority: 5},
+		{Name: "общее 2", Priority: 0},
+	}
+	sortRules(rules)
+
+	want := []string{"приоритет", "место", "тип места", "общее", "общее 2"}
+	for i, name := range want {
+		if rules[i].Name != name {
+			t.Fatalf("позиция %d: %q, ожидалось %q", i, rules[i].Name, name)
+		}
+	}
+}
+
+func TestMatchHourlyRule(t *testing.T) {
+	rules := []models.PriceRule{
+		{Name: "утро выходных", Kind: models.PriceRuleHourly, Weekdays: "0,6", StartHour: 9, EndHour: 12},
+		{Name: "скидка за длительность", Kind: models.PriceRuleDuration, MinHours: 1},
+		{Name: "вечер", Kind: models.PriceRuleHourly, StartHour: 17, EndHour: 24},
+		{Name: "весь день", Kind: models.PriceRuleHourly, StartHour: 0, EndHour: 24},
+	}
+
+	// 2026-03-09 — понедельник, 2026-03-14 — суббота
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{name: "будний день утром", at: time.Date(2026, 3, 9, 10, 0, 0, 0, time.UTC), want: "весь день"},
+		{name: "выходной утром", at: time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC), want: "утро выходных"},
+		{name: "конец интервала не включается", at: time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC), want: "весь день"},
+		{name: "вечер", at: time.Date(2026, 3, 9, 17, 0, 0, 0, time.UTC), want: "вечер"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchHourlyRule(rules, tt.at)
+			if got == nil || got.Name != tt.want {
+				t.Fatalf("matchHourlyRule = %v, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := matchHourlyRule(rules[:2], time.Date(2026, 3, 9, 10, 0, 0, 0, time.UTC)); got != nil {
+		t.Fatalf("без подходящего правила вернулось %q", got.Name)
+	}
+}
+
+func TestMatchDurationRule(t *testing.T) {
+	duration := func(name string, minHours, percent int) models.PriceRule {
+		return models.PriceRule{Name: name, Kind: models.PriceRuleDuration, MinHours: minHours, DiscountPercent: percent}
+	}
+
+	tests := []struct {
+		name  string
+		rules []models.PriceRule
+		hours float64
+		want  string // пусто — скидки нет
+	}{
+		{
+			name:  "порог не достигнут",
+			rules: []models.PriceRule{duration("от 4 часов", 4, 10)},
+			hours: 3.5,
+		},
+		{
+			name:  "порог включительно",
+			rules: []models.PriceRule{duration("от 4 часов", 4, 10)},
+			hours: 4,
+			want:  "от 4 часов",
+		},
+		{
+			name:  "наибольший подходящий порог, а не первый",
+			rules: []models.PriceRule{duration("от 2 часов", 2, 5), duration("от 8 часов", 8, 20), duration("от 4 часов", 4, 10)},
+			hours: 6,
+			want:  "от 4 часов",
+		},
+		{
+			name:  "больший порог важнее большей скидки",
+			rules: []models.PriceRule{duration("акция", 2, 30), duration("от 4 часов", 4, 10)},
+			hours: 5,
+			want:  "от 4 часов",
+		},
+		{
+			name:  "при равном пороге — большая скидка",
+			rules: []models.PriceRule{duration("10%", 4, 10), duration("15%", 4, 15)},
+			hours: 4,
+			want:  "15%",
+		},
+		{
+			name:  "при полном равенстве — первое",
+			rules: []models.PriceRule{duration("первое", 4, 10), duration("второе", 4, 10)},
+			hours: 4,
+			want:  "первое",
+		},
+		{
+			name:  "почасовые правила не учитываются",
+			rules: []models.PriceRule{{Name: "вечер", Kind: models.PriceRuleHourly, StartHour: 17, EndHour: 24}},
+			hours: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchDurationRule(tt.rules, tt.hours)
+			switch {
+			case tt.want == "" && got != nil:
+				t.Fatalf("matchDurationRule = %q, ожидалось без скидки", got.Name)
+			case tt.want != "" && (got == nil || got.Name != tt.want):
+				t.Fatalf("matchDurationRule = %v, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckQuoteRange(t *testing.T) {
+	start := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		end     time.Time
+		wantErr bool
+	}{
+		{name: "час", end: start.Add(time.Hour)},
+		{name: "ровно 31 день", end: start.Add(maxQuoteDuration)},
+		{name: "длиннее 31 дня", end: start.Add(maxQuoteDuration + time.Hour), wantErr: true},
+		{name: "тысячи лет", end: time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC), wantErr: true},
+		{name: "конец раньше начала", end: start.Add(-time.Hour), wantErr: true},
+		{name: "пустой интервал", end: start, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkQuoteRange(start, tt.end); (err != nil) != tt.wantErr {
+				t.Fatalf("checkQuoteRange = %v, ошибка ожидалась: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
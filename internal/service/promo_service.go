@@ -0,0 +1,257 @@
+package service
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+	"gorm.io/gorm"
+)
+
+var ErrPromoUnavailable = errors.New("промокод больше недоступен")
+
+type PromoService interface {
+	// Check проверяет, что пользователь может применить код к брони места с началом в start
+	Check(userID uint, code string, place *models.Place, start time.Time) (*models.PromoCode, error)
+	GetPromo(id uint) (*models.PromoCode, error)
+
+	// Redeem и Release вызываются внутри транзакции оплаты/возврата брони
+	Redeem(tx *gorm.DB, booking *models.Booking) error
+	Release(tx *gorm.DB, booking *models.Booking) error
+
+	CreatePromo(req models.PromoCodeDTO) (*models.PromoCode, error)
+	ListPromos() ([]models.PromoCode, error)
+	UpdatePromo(id uint, req models.PromoCodeDTO) (*models.PromoCode, error)
+	DeletePromo(id uint) error
+}
+
+type promoService struct {
+	repo   repository.PromoRepository
+	logger *slog.Logger
+}
+
+func NewPromoService(repo repository.PromoRepository, logger *slog.Logger) PromoService {
+	return &promoService{repo: repo, logger: logger}
+}
+
+func (s *promoService) Check(userID uint, code string, place *models.Place, start time.Time) (*models.PromoCode, error) {
+	promo, err := s.repo.GetPromoByCode(strings.TrimSpace(code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("промокод не найден")
+		}
+		return nil, err
+	}
+
+	if !promo.IsActive {
+		return nil, errors.New("промокод не активен")
+	}
+	if promo.ValidFrom != nil && start.Before(*promo.ValidFrom) {
+		return nil, errors.New("промокод еще не действует на это время")
+	}
+	if promo.ValidTo != nil && !start.Before(*promo.ValidTo) {
+		return nil, errors.New("срок действия промокода истек")
+	}
+	if promo.PlaceType != nil && *promo.PlaceType != place.Type {
+		return nil, errors.New("промокод не действует для этого типа места")
+	}
+	if promo.MaxRedemptions > 0 && promo.RedemptionsCount >= promo.MaxRedemptions {
+		return nil, ErrPromoUnavailable
+	}
+	if promo.PerUserLimit > 0 {
+		used, err := s.repo.CountUserRedemptions(promo.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= int64(promo.PerUserLimit) {
+			return nil, errors.New("вы уже использовали этот промокод")
+		}
+	}
+
+	return promo, nil
+}
+
+func (s *promoService) GetPromo(id uint) (*models.PromoCode, error) {
+	return s.repo.GetPromo(id)
+}
+
+// Redeem повторно проверяет лимиты под блокировкой строки промокода:
+// между созданием брони и оплатой код могли исчерпать другие пользователи
+func (s *promoService) Redeem(tx *gorm.DB, booking *models.Booking) error {
+	if booking.PromoCodeID == nil {
+		return nil
+	}
+
+	promo, err := s.repo.LockPromo(tx, *booking.PromoCodeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPromoUnavailable
+		}
+		return err
+	}
+
+	redemption, err := s.repo.GetRedemptionByBooking(tx, booking.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if redemption != nil && redemption.Status == models.PromoRedemptionApplied {
+		return nil
+	}
+
+	if !promo.IsActive {
+		return ErrPromoUnavailable
+	}
+	if promo.MaxRedemptions > 0 && promo.RedemptionsCount >= promo.MaxRedemptions {
+		return ErrPromoUnavailable
+	}
+	if promo.PerUserLimit > 0 {
+		used, err := s.repo.CountUserRedemptionsTx(tx, promo.ID, booking.UserID)
+		if err != nil {
+			return err
+		}
+		if used >= int64(promo.PerUserLimit) {
+			return errors.New("вы уже использовали этот промокод")
+		}
+	}
+
+	if redemption == nil {
+		redemption = &models.PromoRedemption{
+			PromoCodeID: promo.ID,
+			UserID:      booking.UserID,
+			BookingID:   booking.ID,
+		}
+	}
+	redemption.Discount = booking.Discount
+	redemption.Status = models.PromoRedemptionApplied
+
+	if err := s.repo.SaveRedemption(tx, redemption); err != nil {
+		s.logger.Error("failed to save promo redemption", "promo_id", promo.ID, "booking_id", booking.ID, "error", err)
+		return err
+	}
+	if err := s.repo.AddRedemptionsCount(tx, promo.ID, 1); err != nil {
+		return err
+	}
+
+	s.logger.Info("promo code redeemed", "promo_id", promo.ID, "booking_id", booking.ID, "discount", booking.Discount)
+	return nil
+}
+
+// Release возвращает погашение при отмене оплаченной брони
+func (s *promoService) Release(tx *gorm.DB, booking *models.Booking) error {
+	if booking.PromoCodeID == nil {
+		return nil
+	}
+
+	// Блокируем код в том же порядке, что и Redeem, чтобы счетчик не разъехался
+	if _, err := s.repo.LockPromo(tx, *booking.PromoCodeID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	redemption, err := s.repo.GetRedemptionByBooking(tx, booking.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if redemption.Status != models.PromoRedemptionApplied {
+		return nil
+	}
+
+	redemption.Status = models.PromoRedemptionReleased
+	if err := s.repo.SaveRedemption(tx, redemption); err != nil {
+		return err
+	}
+	if err := s.repo.AddRedemptionsCount(tx, redemption.PromoCodeID, -1); err != nil {
+		return err
+	}
+
+	s.logger.Info("promo code released", "promo_id", redemption.PromoCodeID, "booking_id", booking.ID)
+	return nil
+}
+
+func (s *promoService) CreatePromo(req models.PromoCodeDTO) (*models.PromoCode, error) {
+	promo := &models.PromoCode{IsActive: true}
+	if err := applyPromoDTO(promo, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreatePromo(promo); err != nil {
+		return nil, err
+	}
+	return promo, nil
+}
+
+func (s *promoService) ListPromos() ([]models.PromoCode, error) {
+	return s.repo.ListPromos()
+}
+
+func (s *promoService) UpdatePromo(id uint, req models.PromoCodeDTO) (*models.PromoCode, error) {
+	promo, err := s.repo.GetPromo(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyPromoDTO(promo, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdatePromo(promo); err != nil {
+		return nil, err
+	}
+	return promo, nil
+}
+
+func (s *promoService) DeletePromo(id uint) error {
+	return s.repo.DeletePromo(id)
+}
+
+func applyPromoDTO(promo *models.PromoCode, req models.PromoCodeDTO) error {
+	if req.DiscountType == models.PromoPercent && req.Value > 100 {
+		return errors.New("процент скидки не может быть больше 100")
+	}
+	if req.ValidFrom != nil && req.ValidTo != nil && !req.ValidTo.After(*req.ValidFrom) {
+		return errors.New("valid_to должен быть позже valid_from")
+	}
+
+	promo.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	promo.Description = req.Description
+	promo.DiscountType = req.DiscountType
+	promo.Value = req.Value
+	promo.MaxHours = req.MaxHours
+	promo.PlaceType = req.PlaceType
+	promo.ValidFrom = req.ValidFrom
+	promo.ValidTo = req.ValidTo
+	promo.MaxRedemptions = req.MaxRedemptions
+	promo.PerUserLimit = req.PerUserLimit
+	if req.IsActive != nil {
+		promo.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// promoDiscount считает скидку по уже посчитанным сегментам цены. При MaxHours
+// скидка действует только на первые часы брони, например «первые 4 часа бесплатно».
+func promoDiscount(promo *models.PromoCode, quote *models.PriceQuote) int {
+	base := quote.Total
+	if promo.MaxHours > 0 && promo.MaxHours < len(quote.Segments) {
+		base = 0
+		for _, seg := range quote.Segments[:promo.MaxHours] {
+			base += seg.Price
+		}
+		// скидка за длительность уже уменьшила итог — пропорционально уменьшаем и базу
+		if quote.Subtotal > 0 {
+			base = base * quote.Total / quote.Subtotal
+		}
+	}
+
+	var discount int
+	switch promo.DiscountType {
+	case models.PromoPercent:
+		discount = base * promo.Value / 100
+	case models.PromoFixed:
+		discount = promo.Value
+	}
+
+	return min(discount, base)
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+)
+
+// fakePromoRepo отдает один промокод и число погашений пользователя;
+// остальные методы репозитория в этих тестах не вызываются
+type fakePromoRepo struct {
+	repository.PromoRepository
+	promo *models.PromoCode
+	used  int64
+}
+
+func (r *fakePromoRepo) GetPromoByCode(code string) (*models.PromoCode, error) {
+	return r.promo, nil
+}
+
+func (r *fakePromoRepo) CountUserRedemptions(promoID, userID uint) (int64, error) {
+	return r.used, nil
+}
+
+func TestPromoCheckLimits(t *testing.T) {
+	start := time.Date(2026, 3, 10, 10, 0, 0, 0, time.UTC)
+	before := start.Add(-time.Hour)
+	after := start.Add(time.Hour)
+	meetingRoom := models.PlaceMeetingRoom
+
+	tests := []struct {
+		name    string
+		promo   models.PromoCode
+		used    int64
+		wantErr error // nil — код применим
+		anyErr  bool
+	}{
+		{name: "без ограничений", promo: models.PromoCode{IsActive: true}},
+		{name: "неактивен", promo: models.PromoCode{IsActive: false}, anyErr: true},
+		{name: "еще не действует", promo: models.PromoCode{IsActive: true, ValidFrom: &after}, anyErr: true},
+		{name: "начало окна включительно", promo: models.PromoCode{IsActive: true, ValidFrom: &start}},
+		{name: "окно закончилось", promo: models.PromoCode{IsActive: true, ValidTo: &start}, anyErr: true},
+		{name: "внутри окна", promo: models.PromoCode{IsActive: true, ValidFrom: &before, ValidTo: &after}},
+		{name: "другой тип места", promo: models.PromoCode{IsActive: true, PlaceType: &meetingRoom}, anyErr: true},
+		{name: "общий лимит исчерпан", promo: models.PromoCode{IsActive: true, MaxRedemptions: 5, RedemptionsCount: 5}, wantErr: ErrPromoUnavailable},
+		{name: "общий лимит не исчерпан", promo: models.PromoCode{IsActive: true, MaxRedemptions: 5, RedemptionsCount: 4}},
+		{name: "лимит на пользователя исчерпан", promo: models.PromoCode{IsActive: true, PerUserLimit: 2}, used: 2, anyErr: true},
+		{name: "лимит на пользователя не исчерпан", promo: models.PromoCode{IsActive: true, PerUserLimit: 2}, used: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promo := tt.promo
+			svc := NewPromoService(&fakePromoRepo{promo: &promo, used: tt.used}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			place := &models.Place{Type: models.PlaceWorkspace}
+
+			_, err := svc.Check(1, "SPRING", place, start)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ошибка %v, ожидалась %v", err, tt.wantErr)
+				}
+			case tt.anyErr:
+				if err == nil {
+					t.Fatal("ожидалась ошибка")
+				}
+			case err != nil:
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+		})
+	}
+}
+
+func TestPromoDiscount(t *testing.T) {
+	// Четыре часа по 500 ₽; total меньше subtotal, если есть скидка за длительность
+	quote := func(subtotal, total int) *models.PriceQuote {
+		q := &models.PriceQuote{Subtotal: subtotal, Total: total}
+		for range 4 {
+			q.Segments = append(q.Segments, models.PriceSegment{Price: 50000})
+		}
+		return q
+	}
+
+	tests := []struct {
+		name  string
+		promo models.PromoCode
+		quote *models.PriceQuote
+		want  int
+	}{
+		{
+			name:  "процент от всей брони",
+			promo: models.PromoCode{DiscountType: models.PromoPercent, Value: 25},
+			quote: quote(200000, 200000),
+			want:  50000,
+		},
+		{
+			name:  "фиксированная скидка",
+			promo: models.PromoCode{DiscountType: models.PromoFixed, Value: 30000},
+			quote: quote(200000, 200000),
+			want:  30000,
+		},
+		{
+			name:  "фиксированная скидка не больше суммы",
+			promo: models.PromoCode{DiscountType: models.PromoFixed, Value: 500000},
+			quote: quote(200000, 200000),
+			want:  200000,
+		},
+		{
+			name:  "первые часы бесплатно",
+			promo: models.PromoCode{DiscountType: models.PromoPercent, Value: 100, MaxHours: 2},
+			quote: quote(200000, 200000),
+			want:  100000,
+		},
+		{
+			name:  "база первых часов уменьшается на скидку за длительность",
+			promo: models.PromoCode{DiscountType: models.PromoPercent, Value: 100, MaxHours: 2},
+			quote: quote(200000, 180000),
+			want:  90000,
+		},
+		{
+			name:  "фиксированная скидка ограничена базой первых часов",
+			promo: models.PromoCode{DiscountType: models.PromoFixed, Value: 150000, MaxHours: 2},
+			quote: quote(200000, 180000),
+			want:  90000,
+		},
+		{
+			name:  "MaxHours не меньше длины брони — вся бронь",
+			promo: models.PromoCode{DiscountType: models.PromoPercent, Value: 50, MaxHours: 4},
+			quote: quote(200000, 180000),
+			want:  90000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := promoDiscount(&tt.promo, tt.quote); got != tt.want {
+				t.Fatalf("promoDiscount = %d, ожидалось %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Сколько кандидатов пробовать на одну бронь: первый может оказаться занят
+// параллельной бронью, которая появилась после подбора
+const relocationCandidates = 5
+
+var (
+	ErrRelocationNotAllowed = errors.New("перенести можно только действующую бронь, которая еще не закончилась")
+	ErrPlaceOccupied        = errors.New("место занято в это время")
+	ErrNoEquivalentPlace    = errors.New("нет свободного равноценного места")
+	ErrRelocationNotFound   = errors.New("запись о переносе не найдена")
+	ErrRelocationResolved   = errors.New("перенос уже обработан")
+)
+
+type RelocationService interface {
+	// RelocateBookings переносит брони на равноценные свободные места; брони,
+	// для которых замена не нашлась, ставятся в очередь администраторов
+	RelocateBookings(adminID uint, bookings []models.Booking, source models.RelocationSource, windowID *uint) (*models.RelocationBatchResult, error)
+	RelocateMaintenance(adminID, windowID uint, bookingIDs []uint) (*models.RelocationBatchResult, error)
+
+	ListQueue(adminID uint, filter *models.FilterRelocation) ([]models.BookingRelocation, int64, error)
+	// Assign переносит бронь из очереди на место, выбранное администратором
+	Assign(adminID, id, placeID uint) (*models.BookingRelocation, error)
+	// Cancel отменяет бронь из очереди с возвратом денег
+	Cancel(adminID, id uint) (*models.BookingRelocation, error)
+}
+
+type relocationService struct {
+	db          *gorm.DB
+	repo        repository.RelocationRepository
+	placeRepo   repository.PlaceRepository
+	bookings    BookingService
+	maintenance MaintenanceService
+	locations   LocationService
+	logger      *slog.Logger
+}
+
+func NewRelocationService(db *gorm.DB, repo repository.RelocationRepository, placeRepo repository.PlaceRepository, bookings BookingService, maintenance MaintenanceService, locations LocationService, logger *slog.Logger) RelocationService {
+	return &relocationService{
+		db:          db,
+		repo:        repo,
+		placeRepo:   placeRepo,
+		bookings:    bookings,
+		maintenance: maintenance,
+		locations:   locations,
+		logger:      logger,
+	}
+}
+
+func (s *relocationService) RelocateBookings(adminID uint, bookings []models.Booking, source models.RelocationSource, windowID *uint) (*models.RelocationBatchResult, error) {
+	result := &models.RelocationBatchResult{
+		Relocated: []models.BookingRelocation{},
+		Queued:    []models.BookingRelocation{},
+	}
+
+	places := map[uint]*models.Place{}
+	for _, b := range bookings {
+		rel := &models.BookingRelocation{
+			BookingID:           b.ID,
+			UserID:              b.UserID,
+			FromPlaceID:         b.PlaceID,
+			Source:              source,
+			MaintenanceWindowID: windowID,
+			Status:              models.RelocationPending,
+			OldPrice:            b.TotalPrice,
+			RequestedBy:         adminID,
+		}
+
+		place, ok := places[b.PlaceID]
+		if !ok {
+			var err error
+			if place, err = s.placeRepo.GetPlaceByID(b.PlaceID); err != nil {
+				return nil, err
+			}
+			places[b.PlaceID] = place
+		}
+
+		outcome, err := s.relocate(&b, place)
+		switch {
+		case err == nil:
+			s.apply(rel, outcome, adminID)
+		case isRelocationFailure(err):
+			rel.Error = err.Error()
+		default:
+			return nil, err
+		}
+
+		if err := s.repo.SaveRelocation(rel); err != nil {
+			return nil, err
+		}
+		if rel.Status == models.RelocationDone {
+			result.Relocated = append(result.Relocated, *rel)
+		} else {
+			result.Queued = append(result.Queued, *rel)
+		}
+	}
+
+	s.logger.Info("bookings relocation finished", "admin_id", adminID, "source", source, "relocated", len(result.Relocated), "queued", len(result.Queued))
+	return result, nil
+}
+
+func (s *relocationService) RelocateMaintenance(adminID, windowID uint, bookingIDs []uint) (*models.RelocationBatchResult, error) {
+	affected, err := s.maintenance.AffectedBookings(adminID, windowID)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := affected
+	if len(bookingIDs) > 0 {
+		targets = make([]models.Booking, 0, len(bookingIDs))
+		for _, id := range bookingIDs {
+			i := slices.IndexFunc(affected, func(b models.Booking) bool { return b.ID == id })
+			if i < 0 {
+				return nil, fmt.Errorf("%w: %d", ErrBookingNotInMaintenance, id)
+			}
+			targets = append(targets, affected[i])
+		}
+	}
+
+	return s.RelocateBookings(adminID, targets, models.RelocationMaintenance, &windowID)
+}
+
+func (s *relocationService) ListQueue(adminID uint, filter *models.FilterRelocation) ([]models.BookingRelocation, int64, error) {
+	scope, err := s.locations.Scope(adminID)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter.LocationIDs = scope
+	return s.repo.ListRelocations(filter)
+}
+
+// Assign и Cancel держат блокировку записи очереди на время переноса или
+// отмены брони: второй параллельный запрос дождется коммита и получит
+// ErrRelocationResolved, а не перенесет бронь повторно
+func (s *relocationService) Assign(adminID, id, placeID uint) (*models.BookingRelocation, error) {
+	if err := s.locations.CheckPlace(adminID, placeID); err != nil {
+		return nil, err
+	}
+
+	var bookingID uint
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		rel, err := s.pending(tx, adminID, id)
+		if err != nil {
+			return err
+		}
+		bookingID = rel.BookingID
+
+		outcome, err := s.bookings.RelocateTx(tx, rel.BookingID, placeID)
+		if err != nil {
+			return err
+		}
+
+		s.apply(rel, outcome, adminID)
+		return s.repo.ResolveRelocation(tx, rel)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("queued booking relocated", "relocation_id", id, "booking_id", bookingID, "place_id", placeID, "admin_id", adminID)
+	return s.repo.GetRelocation(id)
+}
+
+func (s *relocationService) Cancel(adminID, id uint) (*models.BookingRelocation, error) {
+	var bookingID uint
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		rel, err := s.pending(tx, adminID, id)
+		if err != nil {
+			return err
+		}
+		bookingID = rel.BookingID
+
+		if _, err := s.bookings.UpdateBookingStatusWithBalanceTx(tx, rel.BookingID, models.BookingCancelled); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		rel.Status = models.RelocationCancelled
+		rel.ResolvedBy = &adminID
+		rel.ResolvedAt = &now
+		return s.repo.ResolveRelocation(tx, rel)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("queued booking cancelled", "relocation_id", id, "booking_id", bookingID, "admin_id", adminID)
+	return s.repo.GetRelocation(id)
+}
+
+// relocate пробует кандидатов по порядку, пока перенос не пройдет
+func (s *relocationService) relocate(b *models.Booking, source *models.Place) (*models.RelocationOutcome, error) {
+	candidates, err := s.repo.FindCandidates(source, b.StartTime, b.EndTime, relocationCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	lastErr := ErrNoEquivalentPlace
+	for _, candidate := range candidates {
+		outcome, err := s.bookings.Relocate(b.ID, candidate.ID)
+		if err == nil {
+			return outcome, nil
+		}
+		if !isRelocationFailure(err) {
+			return nil, err
+		}
+		s.logger.Warn("relocation candidate rejected", "booking_id", b.ID, "place_id", candidate.ID, "error", err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (s *relocationService) apply(rel *models.BookingRelocation, outcome *models.RelocationOutcome, adminID uint) {
+	now := time.Now()
+	rel.Status = models.RelocationDone
+	rel.ToPlaceID = &outcome.ToPlaceID
+	rel.NewPrice = outcome.NewPrice
+	rel.Difference = outcome.Difference
+	rel.Error = ""
+	rel.ResolvedBy = &adminID
+	rel.ResolvedAt = &now
+}
+
+func (s *relocationService) pending(tx *gorm.DB, adminID, id uint) (*models.BookingRelocation, error) {
+	rel, err := s.repo.LockRelocation(tx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRelocationNotFound
+		}
+		return nil, err
+	}
+	if err := s.locations.CheckPlace(adminID, rel.FromPlaceID); err != nil {
+		return nil, err
+	}
+	if rel.Status != models.RelocationPending {
+		return nil, ErrRelocationResolved
+	}
+	return rel, nil
+}
+
+// isRelocationFailure отделяет причины, по которым бронь уходит в очередь,
+// от ошибок базы, прерывающих весь перенос
+func isRelocationFailure(err error) bool {
+	return errors.Is(err, ErrNoEquivalentPlace) ||
+		errors.Is(err, ErrPlaceOccupied) ||
+		errors.Is(err, ErrPlaceUnderMaintenance) ||
+		errors.Is(err, ErrRelocationNotAllowed) ||
+		errors.Is(err, ErrInsufficientFunds) ||
+		errors.Is(err, ErrCreditLimitExceeded)
+}
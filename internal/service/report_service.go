@@ -0,0 +1,398 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+)
+
+// Часы работы коворкинга — те же, что проверяет BookingService.Create
+const (
+	reportOpenHour   = 9
+	reportCloseHour  = 18
+	maxReportBuckets = 400
+)
+
+var ErrReportRange = errors.New("неверный период отчета")
+
+type ReportService interface {
+	Revenue(filter models.FilterReport) (*models.RevenueReport, error)
+	Occupancy(filter models.FilterReport) (*models.OccupancyReport, error)
+}
+
+type reportService struct {
+	repo   repository.ReportRepository
+	logger *slog.Logger
+}
+
+func NewReportService(repo repository.ReportRepository, logger *slog.Logger) ReportService {
+	return &reportService{repo: repo, logger: logger}
+}
+
+func (s *reportService) Revenue(filter models.FilterReport) (*models.RevenueReport, error) {
+	q, err := reportQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.Revenue(q)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.RevenueReport{
+		From:    q.From,
+		To:      q.To,
+		Period:  q.Period,
+		GroupBy: q.GroupBy,
+		Items:   rows,
+		Total:   revenueTotals(rows),
+	}
+	if report.Items == nil {
+		report.Items = []models.RevenueRow{}
+	}
+
+	if filter.Compare {
+		prev := q
+		prev.From, prev.To = previousRange(q.From, q.To)
+		prevRows, err := s.repo.Revenue(prev)
+		if err != nil {
+			return nil, err
+		}
+
+		prevNet := make(map[string]int, len(prevRows))
+		prevIndex := bucketIndex(prev)
+		for _, row := range prevRows {
+			prevNet[compareKey(prevIndex, row.Period, row.PlaceID, row.PlaceType)] = row.Net
+		}
+
+		index := bucketIndex(q)
+		for i := range report.Items {
+			row := &report.Items[i]
+			net := prevNet[compareKey(index, row.Period, row.PlaceID, row.PlaceType)]
+			row.PreviousNet = &net
+			if net != 0 {
+				change := round2(float64(row.Net-net) / math.Abs(float64(net)) * 100)
+				row.NetChange = &change
+			}
+		}
+
+		totals := revenueTotals(prevRows)
+		report.Previous = &totals
+	}
+
+	return report, nil
+}
+
+func (s *reportService) Occupancy(filter models.FilterReport) (*models.OccupancyReport, error) {
+	q, err := reportQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.occupancy(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Compare {
+		prev := q
+		prev.From, prev.To = previousRange(q.From, q.To)
+		prevReport, err := s.occupancy(prev)
+		if err != nil {
+			return nil, err
+		}
+
+		prevRate := make(map[string]float64, len(prevReport.Items))
+		prevIndex := bucketIndex(prev)
+		for _, row := range prevReport.Items {
+			prevRate[compareKey(prevIndex, row.Period, row.PlaceID, row.PlaceType)] = row.Rate
+		}
+
+		index := bucketIndex(q)
+		for i := range report.Items {
+			row := &report.Items[i]
+			rate, ok := prevRate[compareKey(index, row.Period, row.PlaceID, row.PlaceType)]
+			if !ok {
+				continue
+			}
+			change := round2(row.Rate - rate)
+			row.PreviousRate = &rate
+			row.RateChange = &change
+		}
+
+		report.PreviousRate = &prevReport.Rate
+	}
+
+	return report, nil
+}
+
+// occupancy строит строки по каждому интервалу и каждой группе мест, включая
+// интервалы без броней: нулевая загрузка — тоже результат
+func (s *reportService) occupancy(q repository.ReportQuery) (*models.OccupancyReport, error) {
+	places, err := s.repo.ListReportPlaces(q)
+	if err != nil {
+		return nil, err
+	}
+	booked, err := s.repo.BookedHours(q)
+	if err != nil {
+		return nil, err
+	}
+
+	type group struct {
+		row   models.OccupancyRow
+		zones map[string]int // число мест группы по часовым поясам площадок
+	}
+	var groups []*group
+	byKey := make(map[string]*group)
+	addGroup := func(placeID *uint, name string, placeType models.PlaceType) *group {
+		key := groupKey(placeID, placeType)
+		if g, ok := byKey[key]; ok {
+			return g
+		}
+		g := &group{row: models.OccupancyRow{PlaceID: placeID, PlaceName: name, PlaceType: placeType}, zones: map[string]int{}}
+		byKey[key] = g
+		groups = append(groups, g)
+		return g
+	}
+
+	zones := make(map[string]*time.Location)
+	for _, p := range places {
+		if _, ok := zones[p.Timezone]; !ok {
+			zones[p.Timezone] = s.location(p.Timezone)
+		}
+
+		var g *group
+		switch q.GroupBy {
+		case models.ReportByPlace:
+			id := p.ID
+			g = addGroup(&id, p.Name, p.Type)
+		case models.ReportByType:
+			g = addGroup(nil, "", p.Type)
+		default:
+			g = addGroup(nil, "", "")
+		}
+		g.zones[p.Timezone]++
+	}
+
+	bookedByKey := make(map[string]models.OccupancyRow, len(booked))
+	for _, row := range booked {
+		// Брони по местам, которые с тех пор отключили, тоже попадают в отчет
+		addGroup(row.PlaceID, row.PlaceName, row.PlaceType)
+		bookedByKey[bucketKey(row.Period)+"|"+groupKey(row.PlaceID, row.PlaceType)] = row
+	}
+
+	report := &models.OccupancyReport{
+		From:    q.From,
+		To:      q.To,
+		Period:  q.Period,
+		GroupBy: q.GroupBy,
+		Items:   []models.OccupancyRow{},
+	}
+
+	for _, start := range reportBuckets(q) {
+		end := nextBucket(start, q.Period)
+		openPerPlace := make(map[string]float64, len(zones))
+		for tz, loc := range zones {
+			openPerPlace[tz] = openHours(maxTime(start, q.From), minTime(end, q.To), loc)
+		}
+
+		for _, g := range groups {
+			row := g.row
+			row.Period = start
+			for tz, n := range g.zones {
+				row.OpenHours += openPerPlace[tz] * float64(n)
+			}
+			if b, ok := bookedByKey[bucketKey(start)+"|"+groupKey(g.row.PlaceID, g.row.PlaceType)]; ok {
+				row.Bookings = b.Bookings
+				row.BookedHours = round2(b.BookedHours)
+			}
+			row.Rate = occupancyRate(row.BookedHours, row.OpenHours)
+
+			report.BookedHours += row.BookedHours
+			report.OpenHours += row.OpenHours
+			report.Items = append(report.Items, row)
+		}
+	}
+	report.BookedHours = round2(report.BookedHours)
+	report.Rate = occupancyRate(report.BookedHours, report.OpenHours)
+
+	return report, nil
+}
+
+func reportQuery(filter models.FilterReport) (repository.ReportQuery, error) {
+	q := repository.ReportQuery{
+		From:    filter.From,
+		To:      filter.To,
+		Period:  filter.Period,
+		GroupBy: filter.GroupBy,
+		PlaceID: filter.PlaceID,
+		Type:    filter.Type,
+
+		LocationID:  filter.LocationID,
+		LocationIDs: filter.LocationIDs,
+	}
+	if q.Period == "" {
+		q.Period = models.ReportMonth
+	}
+	if !q.To.After(q.From) {
+		return q, fmt.Errorf("%w: to должен быть позже from", ErrReportRange)
+	}
+	if len(reportBuckets(q)) > maxReportBuckets {
+		return q, fmt.Errorf("%w: слишком много интервалов, увеличьте period", ErrReportRange)
+	}
+	return q, nil
+}
+
+// previousRange возвращает предыдущий период той же длины. Если период — целые
+// месяцы, сдвиг тоже делается на месяцы, чтобы интервалы совпадали по границам.
+func previousRange(from, to time.Time) (time.Time, time.Time) {
+	if isMonthStart(from) && isMonthStart(to) {
+		months := (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+		return from.AddDate(0, -months, 0), from
+	}
+	return from.Add(-to.Sub(from)), from
+}
+
+func isMonthStart(t time.Time) bool {
+	return t.Day() == 1 && t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0
+}
+
+func truncateBucket(t time.Time, period models.ReportPeriod) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch period {
+	case models.ReportWeek:
+		// date_trunc('week') в Postgres начинает неделю с понедельника
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case models.ReportMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	default:
+		return day
+	}
+}
+
+func nextBucket(start time.Time, period models.ReportPeriod) time.Time {
+	switch period {
+	case models.ReportWeek:
+		return start.AddDate(0, 0, 7)
+	case models.ReportMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+func reportBuckets(q repository.ReportQuery) []time.Time {
+	var buckets []time.Time
+	for start := truncateBucket(q.From, q.Period); start.Before(q.To); start = nextBucket(start, q.Period) {
+		buckets = append(buckets, start)
+		if len(buckets) > maxReportBuckets {
+			break
+		}
+	}
+	return buckets
+}
+
+func bucketKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// bucketIndex нумерует интервалы периода, чтобы сравнивать i-й интервал
+// текущего периода с i-м интервалом предыдущего
+func bucketIndex(q repository.ReportQuery) map[string]int {
+	buckets := reportBuckets(q)
+	index := make(map[string]int, len(buckets))
+	for i, b := range buckets {
+		index[bucketKey(b)] = i
+	}
+	return index
+}
+
+func groupKey(placeID *uint, placeType models.PlaceType) string {
+	if placeID != nil {
+		return fmt.Sprintf("place:%d", *placeID)
+	}
+	return "type:" + string(placeType)
+}
+
+func compareKey(index map[string]int, period time.Time, placeID *uint, placeType models.PlaceType) string {
+	return fmt.Sprintf("%d|%s", index[bucketKey(period)], groupKey(placeID, placeType))
+}
+
+// location загружает часовой пояс площадки; пояса проверяются при сохранении
+// площадки, поэтому ошибка здесь означает испорченные данные
+func (s *reportService) location(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		s.logger.Warn("unknown location timezone in report", "timezone", name, "error", err)
+		return time.UTC
+	}
+	return loc
+}
+
+// openHours — часы работы одного места в интервале [from, to): будни с 9 до 18
+// по времени площадки. Границы интервала — местное время площадки без пояса,
+// как и интервалы отчета; в дни перехода на летнее время часы считаются по факту.
+func openHours(from, to time.Time, loc *time.Location) float64 {
+	from, to = inLocation(from, loc), inLocation(to, loc)
+
+	var total time.Duration
+	for day := truncateBucket(from, models.ReportDay); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+		open := maxTime(time.Date(day.Year(), day.Month(), day.Day(), reportOpenHour, 0, 0, 0, loc), from)
+		closeAt := minTime(time.Date(day.Year(), day.Month(), day.Day(), reportCloseHour, 0, 0, 0, loc), to)
+		if closeAt.After(open) {
+			total += closeAt.Sub(open)
+		}
+	}
+	return total.Hours()
+}
+
+// inLocation читает показания часов t как местное время loc
+func inLocation(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+func occupancyRate(booked, open float64) float64 {
+	if open <= 0 {
+		return 0
+	}
+	return round2(booked / open * 100)
+}
+
+func revenueTotals(rows []models.RevenueRow) models.RevenueTotals {
+	var t models.RevenueTotals
+	for _, row := range rows {
+		t.Bookings += row.Bookings
+		t.Gross += row.Gross
+		t.Refunds += row.Refunds
+		t.Net += row.Net
+	}
+	return t
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+)
+
+func reportDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPreviousRange(t *testing.T) {
+	tests := []struct {
+		name           string
+		from, to       time.Time
+		wantFrom, want time.Time
+	}{
+		{
+			name: "один месяц",
+			from: reportDate(2026, 3, 1), to: reportDate(2026, 4, 1),
+			wantFrom: reportDate(2026, 2, 1), want: reportDate(2026, 3, 1),
+		},
+		{
+			name: "квартал через границу года",
+			from: reportDate(2026, 1, 1), to: reportDate(2026, 4, 1),
+			wantFrom: reportDate(2025, 10, 1), want: reportDate(2026, 1, 1),
+		},
+		{
+			name: "неделя",
+			from: reportDate(2026, 3, 10), to: reportDate(2026, 3, 17),
+			wantFrom: reportDate(2026, 3, 3), want: reportDate(2026, 3, 10),
+		},
+		{
+			name: "от начала месяца до середины — по длине",
+			from: reportDate(2026, 3, 1), to: reportDate(2026, 3, 15),
+			wantFrom: reportDate(2026, 2, 15), want: reportDate(2026, 3, 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := previousRange(tt.from, tt.to)
+			if !from.Equal(tt.wantFrom) || !to.Equal(tt.want) {
+				t.Fatalf("previousRange = [%s, %s), ожидалось [%s, %s)",
+					from.Format(time.DateOnly), to.Format(time.DateOnly), tt.wantFrom.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestReportBuckets(t *testing.T) {
+	tests := []struct {
+		name   string
+		q      repository.ReportQuery
+		starts []string
+	}{
+		{
+			name:   "дни",
+			q:      repository.ReportQuery{From: reportDate(2026, 3, 9), To: reportDate(2026, 3, 12), Period: models.ReportDay},
+			starts: []string{"2026-03-09", "2026-03-10", "2026-03-11"},
+		},
+		{
+			name: "недели начинаются с понедельника",
+			// 2026-03-12 — четверг, 2026-03-29 — воскресенье
+			q:      repository.ReportQuery{From: reportDate(2026, 3, 12), To: reportDate(2026, 3, 29), Period: models.ReportWeek},
+			starts: []string{"2026-03-09", "2026-03-16", "2026-03-23"},
+		},
+		{
+			name:   "воскресенье относится к прошедшей неделе",
+			q:      repository.ReportQuery{From: reportDate(2026, 3, 15), To: reportDate(2026, 3, 16), Period: models.ReportWeek},
+			starts: []string{"2026-03-09"},
+		},
+		{
+			name:   "месяцы",
+			q:      repository.ReportQuery{From: reportDate(2026, 1, 15), To: reportDate(2026, 3, 2), Period: models.ReportMonth},
+			starts: []string{"2026-01-01", "2026-02-01", "2026-03-01"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buckets := reportBuckets(tt.q)
+			if len(buckets) != len(tt.starts) {
+				t.Fatalf("интервалов %d, ожидалось %d", len(buckets), len(tt.starts))
+			}
+			for i, b := range buckets {
+				if bucketKey(b) != tt.starts[i] {
+					t.Fatalf("интервал %d начинается %s, ожидалось %s", i, bucketKey(b), tt.starts[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCompareKey(t *testing.T) {
+	// i-й интервал текущего периода сравнивается с i-м интервалом предыдущего
+	q := repository.ReportQuery{From: reportDate(2026, 3, 1), To: reportDate(2026, 4, 1), Period: models.ReportWeek}
+	prev := q
+	prev.From, prev.To = previousRange(q.From, q.To)
+
+	placeID := uint(3)
+	cur := compareKey(bucketIndex(q), reportDate(2026, 3, 2), &placeID, models.PlaceWorkspace)
+	old := compareKey(bucketIndex(prev), reportDate(2026, 2, 2), &placeID, models.PlaceWorkspace)
+	if cur != old {
+		t.Fatalf("ключи вторых недель не совпали: %s и %s", cur, old)
+	}
+	if other := compareKey(bucketIndex(q), reportDate(2026, 3, 2), nil, models.PlaceWorkspace); other == cur {
+		t.Fatalf("группа по типу совпала с группой по месту: %s", other)
+	}
+}
+
+func TestOpenHours(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		loc      *time.Location
+		want     float64
+	}{
+		{name: "будний день", from: reportDate(2026, 3, 9), to: reportDate(2026, 3, 10), loc: moscow, want: 9},
+		{name: "выходной", from: reportDate(2026, 3, 14), to: reportDate(2026, 3, 15), loc: moscow, want: 0},
+		{name: "неделя", from: reportDate(2026, 3, 9), to: reportDate(2026, 3, 16), loc: moscow, want: 45},
+		{name: "интервал с середины дня", from: reportDate(2026, 3, 9).Add(12 * time.Hour), to: reportDate(2026, 3, 10), loc: moscow, want: 6},
+		{name: "интервал до середины дня", from: reportDate(2026, 3, 9), to: reportDate(2026, 3, 9).Add(10*time.Hour + 30*time.Minute), loc: moscow, want: 1.5},
+		// 2026-03-09 в Нью-Йорке — первый будний день после перехода на летнее время
+		{name: "местные часы другой площадки", from: reportDate(2026, 3, 9), to: reportDate(2026, 3, 10), loc: newYork, want: 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := openHours(tt.from, tt.to, tt.loc); got != tt.want {
+				t.Fatalf("openHours = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
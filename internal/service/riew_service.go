@@ -2,6 +2,8 @@ package service
 
 import (
 	"errors"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/IslamCHup/coworking-manager-project/internal/models"
@@ -9,19 +11,50 @@ import (
 	"gorm.io/gorm"
 )
 var ErrReviewNotFound = errors.New("review not found") 
+var ErrRejectionReasonRequired = errors.New("укажите причину отклонения")
+var (
+	ErrReviewExists    = errors.New("вы уже оставили отзыв об этом месте, его можно отредактировать")
+	ErrNoCompletedStay = errors.New("оставить отзыв можно только после завершенной брони этого места")
+	ErrOwnReview       = errors.New("нельзя голосовать за свой отзыв или жаловаться на него")
+	ErrAlreadyReported = errors.New("вы уже пожаловались на этот отзыв")
+)
 type ReviewService interface {
+	// CreateReview принимает отзыв только после завершенной брони места; если автор
+	// уже писал об этом месте, вместе с ErrReviewExists возвращается его отзыв
 	CreateReview(req *models.Review) (*models.Review, error)
 	GetReviewId(id uint) (*models.Review, error)
 	UpdateReview(id uint, req models.UpdateReviewDTO) (*models.Review, error)
 	DeleteReview(id uint)error
+
+	// ListPlaceReviews — одобренные отзывы места вместе с его рейтингом
+	ListPlaceReviews(placeID uint, filter *models.FilterPlaceReviews) ([]models.Review, int64, *models.PlaceRating, error)
+	// ListUserReviews — отзывы автора с их статусом модерации
+	ListUserReviews(userID uint) ([]models.Review, error)
+
+	// Модерация: администратор видит и модерирует отзывы только своих площадок
+	ListModerationQueue(adminID uint, filter *models.FilterReviews) ([]models.Review, int64, error)
+	Approve(adminID, id uint) (*models.Review, error)
+	Reject(adminID, id uint, reason string) (*models.Review, error)
+	// Reply публикует ответ администрации; пустой текст удаляет ответ
+	Reply(adminID, id uint, text string) (*models.Review, error)
+	ListReports(adminID, id uint) ([]models.ReviewReport, error)
+
+	// Голосовать и жаловаться можно только на опубликованные чужие отзывы
+	MarkHelpful(userID, id uint) (*models.Review, error)
+	UnmarkHelpful(userID, id uint) (*models.Review, error)
+	Report(userID, id uint, reason string) (*models.Review, error)
 }
 type reviewService struct {
-	db     *gorm.DB
-	review repository.ReviewRepository
+	db        *gorm.DB
+	review    repository.ReviewRepository
+	locations LocationService
+	// после стольких открытых жалоб отзыв снимается с публикации до модерации
+	reportThreshold int
+	logger          *slog.Logger
 }
 
-func NewReviewService(db *gorm.DB, review repository.ReviewRepository) ReviewService {
-	return &reviewService{db: db, review: review}
+func NewReviewService(db *gorm.DB, review repository.ReviewRepository, locations LocationService, reportThreshold int, logger *slog.Logger) ReviewService {
+	return &reviewService{db: db, review: review, locations: locations, reportThreshold: max(reportThreshold, 1), logger: logger}
 }
 func (s *reviewService) CreateReview(req *models.Review) (*models.Review, error) {
 	if req.Rating < 1 || req.Rating > 5 {
@@ -42,14 +75,42 @@ func (s *reviewService) CreateReview(req *models.Review) (*models.Review, error)
 	if req.PlaceID == 0 {
 		return nil, errors.New("место не найдено")
 	}
+
+	existing, err := s.review.GetByUserAndPlace(req.UserID, req.PlaceID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, ErrReviewExists
+	}
+
+	// отзыв может оставить только тот, кто действительно был на месте
+	booking, err := s.review.FindCompletedBooking(req.UserID, req.PlaceID, req.BookingID, time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoCompletedStay
+		}
+		return nil, err
+	}
+
 	review := &models.Review{
-		UserID:    req.UserID,
-		PlaceID:   req.PlaceID,
-		Rating:    req.Rating,
-		Text:      req.Text,
-		CreatedAt: time.Now(),
+		UserID:       req.UserID,
+		PlaceID:      req.PlaceID,
+		Rating:       req.Rating,
+		Text:         req.Text,
+		Status:       models.ReviewPending,
+		BookingID:    &booking.ID,
+		VerifiedStay: true,
+		CreatedAt:    time.Now(),
+	}
+	err = s.review.CreateReview(review)
+	if errors.Is(err, repository.ErrReviewExists) {
+		existing, err := s.review.GetByUserAndPlace(req.UserID, req.PlaceID)
+		if err != nil {
+			return nil, err
+		}
+		return existing, ErrReviewExists
 	}
-	err := s.review.CreateReview(review)
 	if err != nil {
 		return nil, err
 	}
@@ -89,6 +150,13 @@ func (s *reviewService) UpdateReview(id uint, req models.UpdateReviewDTO) (*mode
 		review.Rating = req.Rating
 	}
 
+	// измененный отзыв снова проходит модерацию и до этого не учитывается в рейтинге
+	review.Status = models.ReviewPending
+	review.IsApproved = false
+	review.RejectionReason = ""
+	review.AdminID = nil
+	review.ModeratedAt = nil
+
 	err = s.review.UpdateReview(review)
 	if err != nil {
 		return nil, err
@@ -112,4 +180,158 @@ func (s *reviewService) DeleteReview(id uint) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+func (s *reviewService) ListPlaceReviews(placeID uint, filter *models.FilterPlaceReviews) ([]models.Review, int64, *models.PlaceRating, error) {
+	if err := s.db.Select("id").First(&models.Place{}, placeID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, nil, ErrPlaceNotFound
+		}
+		return nil, 0, nil, err
+	}
+
+	reviews, total, err := s.review.ListPlaceReviews(placeID, filter)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	rating, err := s.review.GetPlaceRating(placeID)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return reviews, total, rating, nil
+}
+
+func (s *reviewService) ListUserReviews(userID uint) ([]models.Review, error) {
+	return s.review.ListUserReviews(userID)
+}
+
+func (s *reviewService) ListModerationQueue(adminID uint, filter *models.FilterReviews) ([]models.Review, int64, error) {
+	scope, err := s.locations.Scope(adminID)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter.LocationIDs = scope
+	return s.review.ListReviews(filter)
+}
+
+func (s *reviewService) Approve(adminID, id uint) (*models.Review, error) {
+	return s.moderate(adminID, id, models.ReviewApproved, "")
+}
+
+func (s *reviewService) Reject(adminID, id uint, reason string) (*models.Review, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrRejectionReasonRequired
+	}
+	return s.moderate(adminID, id, models.ReviewRejected, reason)
+}
+
+func (s *reviewService) moderate(adminID, id uint, status models.ReviewStatus, reason string) (*models.Review, error) {
+	review, err := s.review.GetReview(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, err
+	}
+	if err := s.locations.CheckPlace(adminID, review.PlaceID); err != nil {
+		return nil, err
+	}
+
+	review, err = s.review.Moderate(id, adminID, status, reason)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, err
+	}
+
+	s.logger.Info("review moderated", "review_id", id, "admin_id", adminID, "status", status)
+	return review, nil
+}
+
+func (s *reviewService) Reply(adminID, id uint, text string) (*models.Review, error) {
+	review, err := s.review.GetReview(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, err
+	}
+	if err := s.locations.CheckPlace(adminID, review.PlaceID); err != nil {
+		return nil, err
+	}
+
+	review, err = s.review.SetReply(id, adminID, strings.TrimSpace(text))
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("review reply updated", "review_id", id, "admin_id", adminID, "removed", review.Reply == "")
+	return review, nil
+}
+
+func (s *reviewService) ListReports(adminID, id uint) ([]models.ReviewReport, error) {
+	review, err := s.review.GetReview(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, err
+	}
+	if err := s.locations.CheckPlace(adminID, review.PlaceID); err != nil {
+		return nil, err
+	}
+	return s.review.ListReports(id)
+}
+
+func (s *reviewService) MarkHelpful(userID, id uint) (*models.Review, error) {
+	if _, err := s.published(userID, id); err != nil {
+		return nil, err
+	}
+	return s.review.AddVote(id, userID)
+}
+
+func (s *reviewService) UnmarkHelpful(userID, id uint) (*models.Review, error) {
+	if _, err := s.published(userID, id); err != nil {
+		return nil, err
+	}
+	return s.review.RemoveVote(id, userID)
+}
+
+func (s *reviewService) Report(userID, id uint, reason string) (*models.Review, error) {
+	if _, err := s.published(userID, id); err != nil {
+		return nil, err
+	}
+
+	report := &models.ReviewReport{ReviewID: id, UserID: userID, Reason: strings.TrimSpace(reason)}
+	review, requeued, err := s.review.AddReport(report, s.reportThreshold)
+	if err != nil {
+		if errors.Is(err, repository.ErrReportExists) {
+			return nil, ErrAlreadyReported
+		}
+		return nil, err
+	}
+
+	if requeued {
+		s.logger.Warn("review returned to moderation after reports", "review_id", id, "reports", review.ReportCount)
+	}
+	return review, nil
+}
+
+// published возвращает одобренный отзыв, если он не принадлежит пользователю
+func (s *reviewService) published(userID, id uint) (*models.Review, error) {
+	review, err := s.review.GetReview(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, err
+	}
+	if review.Status != models.ReviewApproved {
+		return nil, ErrReviewNotFound
+	}
+	if review.UserID == userID {
+		return nil, ErrOwnReview
+	}
+	return review, nil
+}
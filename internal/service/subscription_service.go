@@ -0,0 +1,510 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+	"gorm.io/gorm"
+)
+
+var ErrNoSubscription = errors.New("нет активного абонемента")
+
+type SubscriptionService interface {
+	ListPlans() ([]models.Plan, error)
+	GetSubscription(userID uint) (*models.Subscription, error)
+	Subscribe(userID, planID uint) (*models.Subscription, error)
+	ChangePlan(userID, planID uint) (*models.Subscription, error)
+	Cancel(userID uint) error
+
+	// Draw и Return вызываются внутри транзакции оплаты/возврата брони
+	Draw(tx *gorm.DB, booking *models.Booking) error
+	Return(tx *gorm.DB, booking *models.Booking) error
+
+	RenewDue(ctx context.Context) error
+	RunRenewals(ctx context.Context, interval time.Duration)
+
+	CreatePlan(req models.PlanDTO) (*models.Plan, error)
+	ListAllPlans() ([]models.Plan, error)
+	UpdatePlan(id uint, req models.PlanDTO) (*models.Plan, error)
+	DeletePlan(id uint) error
+}
+
+type subscriptionService struct {
+	repo   repository.SubscriptionRepository
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewSubscriptionService(repo repository.SubscriptionRepository, db *gorm.DB, logger *slog.Logger) SubscriptionService {
+	return &subscriptionService{repo: repo, db: db, logger: logger}
+}
+
+func (s *subscriptionService) ListPlans() ([]models.Plan, error) {
+	return s.repo.ListPlans(true)
+}
+
+func (s *subscriptionService) GetSubscription(userID uint) (*models.Subscription, error) {
+	sub, err := s.repo.GetActiveSubscription(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoSubscription
+		}
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Subscribe оформляет абонемент и сразу списывает оплату первого периода
+func (s *subscriptionService) Subscribe(userID, planID uint) (*models.Subscription, error) {
+	plan, err := s.repo.GetPlan(planID)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.IsActive {
+		return nil, errors.New("план недоступен для подключения")
+	}
+
+	var sub *models.Subscription
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := s.repo.LockActiveSubscription(tx, userID); err == nil {
+			return errors.New("у вас уже есть активный абонемент")
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := s.adjustBalance(tx, userID, -plan.Price, "subscription_charge"); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		sub = &models.Subscription{
+			UserID:      userID,
+			PlanID:      plan.ID,
+			Status:      models.SubscriptionActive,
+			PeriodStart: now,
+			PeriodEnd:   now.AddDate(0, 0, plan.PeriodDays),
+			AutoRenew:   true,
+		}
+		if err := tx.Create(sub).Error; err != nil {
+			return err
+		}
+
+		return s.openPeriod(tx, sub, plan, nil)
+	})
+	if err != nil {
+		s.logger.Warn("Subscribe failed", "user_id", userID, "plan_id", planID, "error", err)
+		return nil, err
+	}
+
+	s.logger.Info("subscription created", "user_id", userID, "plan_id", planID, "subscription_id", sub.ID)
+	return s.GetSubscription(userID)
+}
+
+// ChangePlan переводит абонемент на другой план до конца текущего периода.
+// Неиспользованная доля старого плана возвращается на баланс, доля нового
+// списывается, а остатки часов пересчитываются пропорционально.
+func (s *subscriptionService) ChangePlan(userID, planID uint) (*models.Subscription, error) {
+	newPlan, err := s.repo.GetPlan(planID)
+	if err != nil {
+		return nil, err
+	}
+	if !newPlan.IsActive {
+		return nil, errors.New("план недоступен для подключения")
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		sub, err := s.repo.LockActiveSubscription(tx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNoSubscription
+			}
+			return err
+		}
+		if sub.PlanID == newPlan.ID {
+			return errors.New("этот план уже подключен")
+		}
+
+		var oldPlan models.Plan
+		if err := tx.Unscoped().First(&oldPlan, sub.PlanID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		fraction := remainingFraction(sub.PeriodStart, sub.PeriodEnd, now)
+		credit := int(math.Round(float64(oldPlan.Price) * fraction))
+		cost := int(math.Round(float64(newPlan.Price) * fraction))
+		if err := s.adjustBalance(tx, userID, credit-cost, "subscription_proration"); err != nil {
+			return err
+		}
+
+		buckets, err := s.repo.ListBuckets(tx, sub.ID, sub.PeriodStart)
+		if err != nil {
+			return err
+		}
+		byType := make(map[models.PlaceType]*models.HourBucket, len(buckets))
+		for i := range buckets {
+			byType[buckets[i].PlaceType] = &buckets[i]
+		}
+
+		// Перенесенные часы оплачены в прошлых периодах и остаются у пользователя
+		newHours := make(map[models.PlaceType]int, len(newPlan.Allowances))
+		for _, a := range newPlan.Allowances {
+			newHours[a.PlaceType] = int(math.Round(float64(a.Hours) * fraction))
+		}
+		for placeType, bucket := range byType {
+			keep := min(bucket.RolledOver, bucket.Remaining())
+			bucket.Total = bucket.Used + keep + newHours[placeType]
+			if err := tx.Save(bucket).Error; err != nil {
+				return err
+			}
+		}
+		for placeType, hours := range newHours {
+			if _, ok := byType[placeType]; ok {
+				continue
+			}
+			bucket := &models.HourBucket{
+				SubscriptionID: sub.ID,
+				PlaceType:      placeType,
+				PeriodStart:    sub.PeriodStart,
+				PeriodEnd:      sub.PeriodEnd,
+				Total:          hours,
+			}
+			if err := tx.Create(bucket).Error; err != nil {
+				return err
+			}
+		}
+
+		s.logger.Info("subscription plan changed",
+			"subscription_id", sub.ID,
+			"old_plan_id", oldPlan.ID,
+			"new_plan_id", newPlan.ID,
+			"credit", credit,
+			"cost", cost)
+
+		return tx.Model(sub).Update("plan_id", newPlan.ID).Error
+	})
+	if err != nil {
+		s.logger.Warn("ChangePlan failed", "user_id", userID, "plan_id", planID, "error", err)
+		return nil, err
+	}
+
+	return s.GetSubscription(userID)
+}
+
+// Cancel отключает автопродление: часы доступны до конца оплаченного периода
+func (s *subscriptionService) Cancel(userID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		sub, err := s.repo.LockActiveSubscription(tx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNoSubscription
+			}
+			return err
+		}
+		s.logger.Info("subscription auto-renew disabled", "subscription_id", sub.ID, "user_id", userID)
+		return tx.Model(sub).Update("auto_renew", false).Error
+	})
+}
+
+// Draw покрывает часы брони из абонемента, а остаток стоимости
+// пропорционально относит на баланс. Заполняет IncludedHours, HourBucketID и ChargedAmount.
+func (s *subscriptionService) Draw(tx *gorm.DB, booking *models.Booking) error {
+	booking.IncludedHours = 0
+	booking.HourBucketID = nil
+	booking.ChargedAmount = booking.TotalPrice
+
+	if booking.Place == nil {
+		return errors.New("место брони не загружено")
+	}
+
+	bucket, err := s.repo.LockBucketFor(tx, booking.UserID, booking.Place.Type, booking.StartTime)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	included := min(bucket.Remaining(), bookingHours(booking.StartTime, booking.EndTime))
+	if included <= 0 {
+		return nil
+	}
+
+	bucket.Used += included
+	if err := tx.Model(bucket).Update("used", bucket.Used).Error; err != nil {
+		s.logger.Error("failed to draw hours from bucket", "bucket_id", bucket.ID, "error", err)
+		return err
+	}
+
+	booking.IncludedHours = included
+	booking.HourBucketID = &bucket.ID
+	booking.ChargedAmount = chargedAmount(booking.TotalPrice, included, booking.StartTime, booking.EndTime)
+
+	s.logger.Info("booking hours drawn from subscription",
+		"booking_id", booking.ID,
+		"bucket_id", bucket.ID,
+		"hours", included,
+		"charged", booking.ChargedAmount)
+	return nil
+}
+
+// Return возвращает часы в абонемент, если период, из которого они были
+// взяты, еще не закончился
+func (s *subscriptionService) Return(tx *gorm.DB, booking *models.Booking) error {
+	if booking.HourBucketID == nil || booking.IncludedHours == 0 {
+		return nil
+	}
+
+	bucket, err := s.repo.LockBucket(tx, *booking.HourBucketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !bucket.PeriodEnd.After(time.Now()) {
+		s.logger.Info("bucket period closed, hours not returned", "booking_id", booking.ID, "bucket_id", bucket.ID)
+		return nil
+	}
+
+	bucket.Used = max(bucket.Used-booking.IncludedHours, 0)
+	if err := tx.Model(bucket).Update("used", bucket.Used).Error; err != nil {
+		s.logger.Error("failed to return hours to bucket", "bucket_id", bucket.ID, "error", err)
+		return err
+	}
+
+	s.logger.Info("booking hours returned to subscription", "booking_id", booking.ID, "bucket_id", bucket.ID, "hours", booking.IncludedHours)
+	return nil
+}
+
+// RenewDue продлевает абонементы с закончившимся периодом. Если автопродление
+// выключено, план снят или на балансе не хватает денег — абонемент истекает.
+func (s *subscriptionService) RenewDue(ctx context.Context) error {
+	subs, err := s.repo.ListDueRenewals(time.Now(), 100)
+	if err != nil {
+		return err
+	}
+
+	for _, due := range subs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.renew(due.ID); err != nil {
+			s.logger.Error("subscription renewal failed", "subscription_id", due.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+func (s *subscriptionService) renew(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		sub, err := s.repo.LockSubscription(tx, id)
+		if err != nil {
+			return err
+		}
+		if sub.Status != models.SubscriptionActive || sub.PeriodEnd.After(time.Now()) {
+			return nil
+		}
+
+		expire := func(reason string) error {
+			s.logger.Info("subscription expired", "subscription_id", sub.ID, "user_id", sub.UserID, "reason", reason)
+			return tx.Model(sub).Update("status", models.SubscriptionExpired).Error
+		}
+
+		if !sub.AutoRenew {
+			return expire("cancelled")
+		}
+
+		var plan models.Plan
+		if err := tx.Preload("Allowances").First(&plan, sub.PlanID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return expire("plan_deleted")
+			}
+			return err
+		}
+		if !plan.IsActive {
+			return expire("plan_inactive")
+		}
+
+		if err := s.adjustBalance(tx, sub.UserID, -plan.Price, "subscription_charge"); err != nil {
+			if errors.Is(err, ErrInsufficientFunds) || errors.Is(err, ErrCreditLimitExceeded) {
+				return expire("insufficient_funds")
+			}
+			return err
+		}
+
+		prev, err := s.repo.ListBuckets(tx, sub.ID, sub.PeriodStart)
+		if err != nil {
+			return err
+		}
+
+		sub.PeriodStart = sub.PeriodEnd
+		sub.PeriodEnd = sub.PeriodStart.AddDate(0, 0, plan.PeriodDays)
+		if err := tx.Model(sub).Updates(map[string]any{
+			"period_start": sub.PeriodStart,
+			"period_end":   sub.PeriodEnd,
+		}).Error; err != nil {
+			return err
+		}
+
+		s.logger.Info("subscription renewed", "subscription_id", sub.ID, "user_id", sub.UserID, "period_end", sub.PeriodEnd)
+		return s.openPeriod(tx, sub, &plan, prev)
+	})
+}
+
+// RunRenewals периодически продлевает абонементы до отмены контекста
+func (s *subscriptionService) RunRenewals(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RenewDue(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("RenewDue failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// openPeriod создает остатки часов на текущий период абонемента. Если план
+// разрешает перенос, неиспользованные часы прошлого периода добавляются,
+// но не больше нормы плана за один период.
+func (s *subscriptionService) openPeriod(tx *gorm.DB, sub *models.Subscription, plan *models.Plan, prev []models.HourBucket) error {
+	for _, a := range plan.Allowances {
+		rolled := 0
+		if plan.AllowRollover {
+			for _, b := range prev {
+				if b.PlaceType == a.PlaceType {
+					rolled = min(b.Remaining(), a.Hours)
+				}
+			}
+		}
+
+		bucket := &models.HourBucket{
+			SubscriptionID: sub.ID,
+			PlaceType:      a.PlaceType,
+			PeriodStart:    sub.PeriodStart,
+			PeriodEnd:      sub.PeriodEnd,
+			Total:          a.Hours + rolled,
+			RolledOver:     rolled,
+		}
+		if err := tx.Create(bucket).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// adjustBalance проводит оплату абонемента через журнал. Отрицательная
+// сумма — списание (fee), положительная — возврат при пересчете.
+func (s *subscriptionService) adjustBalance(tx *gorm.DB, userID uint, amount int, reason string) error {
+	entryType := models.LedgerFee
+	if amount > 0 {
+		entryType = models.LedgerRefund
+	}
+
+	if _, err := repository.PostLedger(tx, models.LedgerPosting{
+		UserID: userID,
+		Type:   entryType,
+		Amount: amount,
+		Reason: reason,
+	}); err != nil {
+		if !errors.Is(err, ErrInsufficientFunds) {
+			s.logger.Error("failed to change balance", "user_id", userID, "amount", amount, "error", err)
+		}
+		return err
+	}
+	return nil
+}
+
+func remainingFraction(start, end, now time.Time) float64 {
+	total := end.Sub(start)
+	if total <= 0 || !now.Before(end) {
+		return 0
+	}
+	if now.Before(start) {
+		return 1
+	}
+	return float64(end.Sub(now)) / float64(total)
+}
+
+func (s *subscriptionService) CreatePlan(req models.PlanDTO) (*models.Plan, error) {
+	plan := &models.Plan{IsActive: true}
+	applyPlanDTO(plan, req)
+	if err := s.repo.CreatePlan(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (s *subscriptionService) ListAllPlans() ([]models.Plan, error) {
+	return s.repo.ListPlans(false)
+}
+
+// UpdatePlan меняет план для следующих периодов: уже открытые остатки часов не пересчитываются
+func (s *subscriptionService) UpdatePlan(id uint, req models.PlanDTO) (*models.Plan, error) {
+	plan, err := s.repo.GetPlan(id)
+	if err != nil {
+		return nil, err
+	}
+	applyPlanDTO(plan, req)
+	if err := s.repo.UpdatePlan(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (s *subscriptionService) DeletePlan(id uint) error {
+	return s.repo.DeletePlan(id)
+}
+
+func applyPlanDTO(plan *models.Plan, req models.PlanDTO) {
+	plan.Name = req.Name
+	plan.Description = req.Description
+	plan.Price = req.Price
+	plan.PeriodDays = req.PeriodDays
+	if plan.PeriodDays == 0 {
+		plan.PeriodDays = 30
+	}
+	plan.AllowRollover = req.AllowRollover
+	if req.IsActive != nil {
+		plan.IsActive = *req.IsActive
+	}
+
+	plan.Allowances = plan.Allowances[:0]
+	for _, a := range req.Allowances {
+		plan.Allowances = append(plan.Allowances, models.PlanAllowance{PlaceType: a.PlaceType, Hours: a.Hours})
+	}
+}
+
+// bookingHours — длительность брони в часах абонемента, неполный час считается целым
+func bookingHours(start, end time.Time) int {
+	return int(math.Ceil(end.Sub(start).Hours()))
+}
+
+// chargedAmount — часть стоимости брони, которая списывается с баланса, если
+// includedHours покрыты абонементом: их доля вычитается пропорционально
+func chargedAmount(total, includedHours int, start, end time.Time) int {
+	if includedHours == 0 {
+		return total
+	}
+	return total - total*includedHours/bookingHours(start, end)
+}
+
+// paidAmount — сколько списано с баланса за оплаченную бронь и вернется при
+// отмене. Брони, оплаченные до появления абонементов, не хранят ChargedAmount.
+func paidAmount(b *models.Booking) int {
+	if b.ChargedAmount == 0 && b.IncludedHours == 0 {
+		return b.TotalPrice
+	}
+	return b.ChargedAmount
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+)
+
+func TestBookingHours(t *testing.T) {
+	start := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		end  time.Time
+		want int
+	}{
+		{name: "целые часы", end: start.Add(3 * time.Hour), want: 3},
+		{name: "неполный час считается целым", end: start.Add(90 * time.Minute), want: 2},
+		{name: "меньше часа", end: start.Add(time.Minute), want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bookingHours(start, tt.end); got != tt.want {
+				t.Fatalf("bookingHours = %d, ожидалось %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChargedAmount(t *testing.T) {
+	start := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		total    int
+		included int
+		end      time.Time
+		want     int
+	}{
+		{name: "без абонемента", total: 150000, included: 0, end: start.Add(3 * time.Hour), want: 150000},
+		{name: "часть часов по абонементу", total: 150000, included: 1, end: start.Add(3 * time.Hour), want: 100000},
+		{name: "все часы по абонементу", total: 150000, included: 3, end: start.Add(3 * time.Hour), want: 0},
+		{name: "копейки остаются за списанием", total: 100000, included: 1, end: start.Add(3 * time.Hour), want: 66667},
+		{name: "неполный час", total: 75000, included: 1, end: start.Add(90 * time.Minute), want: 37500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chargedAmount(tt.total, tt.included, start, tt.end); got != tt.want {
+				t.Fatalf("chargedAmount = %d, ожидалось %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaidAmount(t *testing.T) {
+	tests := []struct {
+		name    string
+		booking models.Booking
+		want    int
+	}{
+		{
+			name:    "бронь до абонементов возвращается целиком",
+			booking: models.Booking{TotalPrice: 150000},
+			want:    150000,
+		},
+		{
+			name:    "возвращается только списанное с баланса",
+			booking: models.Booking{TotalPrice: 150000, ChargedAmount: 100000, IncludedHours: 1},
+			want:    100000,
+		},
+		{
+			name:    "полностью по абонементу — возвращать нечего",
+			booking: models.Booking{TotalPrice: 150000, ChargedAmount: 0, IncludedHours: 3},
+			want:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := paidAmount(&tt.booking); got != tt.want {
+				t.Fatalf("paidAmount = %d, ожидалось %d", got, tt.want)
+			}
+		})
+	}
+}
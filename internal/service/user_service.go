@@ -12,7 +12,7 @@ type UserService interface {
 	UpdateUser(userID uint, req models.UserUpdateDTO) error
 	DeleteUser(userID uint) error
 	GetAllUsers() ([]models.UserResponseDTO, error)
-	UpdateUserBalance(userID uint, amount int) error
+	UpdateBillingSettings(userID uint, req models.BillingSettingsDTO) error
 }
 
 type userService struct {
@@ -49,12 +49,14 @@ func (s *userService) GetUserByID(userID uint) (*models.UserResponseDTO, error)
 	}
 
 	return &models.UserResponseDTO{
-		ID:        user.ID,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Email:     user.Email,
-		Balance:   user.Balance,
-		Bookings:  bookings,
+		ID:          user.ID,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		Email:       user.Email,
+		Balance:     user.Balance,
+		Postpaid:    user.Postpaid,
+		CreditLimit: user.CreditLimit,
+		Bookings:    bookings,
 	}, nil
 }
 
@@ -113,32 +115,19 @@ func (s *userService) GetAllUsers() ([]models.UserResponseDTO, error) {
 	result := make([]models.UserResponseDTO, 0, len(users))
 	for _, u := range users {
 		result = append(result, models.UserResponseDTO{
-			ID:        u.ID,
-			FirstName: u.FirstName,
-			LastName:  u.LastName,
-			Email:     u.Email,
-			Balance:   u.Balance,
+			ID:          u.ID,
+			FirstName:   u.FirstName,
+			LastName:    u.LastName,
+			Email:       u.Email,
+			Balance:     u.Balance,
+			Postpaid:    u.Postpaid,
+			CreditLimit: u.CreditLimit,
 		})
 	}
 
 	return result, nil
 }
 
-func (s *userService) UpdateUserBalance(userID uint, amount int) error {
-	if err := s.repo.UpdateUserBalance(userID, amount); err != nil {
-		s.logger.Error(
-			"UpdateUserBalance failed",
-			"user_id", userID,
-			"amount", amount,
-			"error", err,
-		)
-		return err
-	}
-
-	s.logger.Info(
-		"UpdateUserBalance success",
-		"user_id", userID,
-		"amount", amount,
-	)
-	return nil
+func (s *userService) UpdateBillingSettings(userID uint, req models.BillingSettingsDTO) error {
+	return s.repo.UpdateBillingSettings(userID, req)
 }
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/repository"
+)
+
+type WebhookService interface {
+	HandleEvent(ctx context.Context, ev *models.OutboxEvent) error
+
+	CreateSubscription(req models.WebhookSubscriptionDTO) (*models.WebhookSubscription, error)
+	ListSubscriptions() ([]models.WebhookSubscription, error)
+	UpdateSubscription(id uint, req models.WebhookSubscriptionDTO) (*models.WebhookSubscription, error)
+	DeleteSubscription(id uint) error
+
+	ListDeliveries(subscriptionID uint, filter *models.FilterWebhookDelivery) ([]models.WebhookDelivery, error)
+	Redeliver(deliveryID uint) error
+
+	ProcessDue(ctx context.Context) error
+	RunWorker(ctx context.Context, interval time.Duration)
+}
+
+// WebhookEnvelope — тело запроса, которое получает подписчик
+type WebhookEnvelope struct {
+	ID        string           `json:"id"`
+	Type      models.EventType `json:"type"`
+	CreatedAt time.Time        `json:"created_at"`
+	Data      json.RawMessage  `json:"data"`
+}
+
+// ErrUnknownWebhookEvent — в подписке указан тип, которого нет в models.EventTypes
+var ErrUnknownWebhookEvent = errors.New("неизвестный тип события")
+
+const (
+	webhookBatchSize   = 50
+	webhookLease       = time.Minute
+	webhookBaseBackoff = 10 * time.Second
+	webhookMaxBackoff  = time.Hour
+)
+
+type webhookService struct {
+	repo        repository.WebhookRepository
+	client      *http.Client
+	maxAttempts int
+	logger      *slog.Logger
+}
+
+func NewWebhookService(repo repository.WebhookRepository, maxAttempts int, logger *slog.Logger) WebhookService {
+	if maxAttempts <= 0 {
+		maxAttempts = 8
+	}
+	return &webhookService{
+		repo:        repo,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		maxAttempts: maxAttempts,
+		logger:      logger,
+	}
+}
+
+func (s *webhookService) CreateSubscription(req models.WebhookSubscriptionDTO) (*models.WebhookSubscription, error) {
+	if err := validateWebhookEvents(req.Events); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = randomHex(32); err != nil {
+			return nil, err
+		}
+	}
+
+	sub := &models.WebhookSubscription{
+		URL:         req.URL,
+		Secret:      secret,
+		Events:      strings.Join(req.Events, ","),
+		Description: req.Description,
+		IsActive:    true,
+	}
+	if req.IsActive != nil {
+		sub.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.CreateSubscription(sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (s *webhookService) ListSubscriptions() ([]models.WebhookSubscription, error) {
+	return s.repo.ListSubscriptions()
+}
+
+func (s *webhookService) UpdateSubscription(id uint, req models.WebhookSubscriptionDTO) (*models.WebhookSubscription, error) {
+	if err := validateWebhookEvents(req.Events); err != nil {
+		return nil, err
+	}
+
+	sub, err := s.repo.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.URL = req.URL
+	sub.Events = strings.Join(req.Events, ",")
+	sub.Description = req.Description
+	if req.Secret != "" {
+		sub.Secret = req.Secret
+	}
+	if req.IsActive != nil {
+		sub.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.UpdateSubscription(sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (s *webhookService) DeleteSubscription(id uint) error {
+	return s.repo.DeleteSubscription(id)
+}
+
+func (s *webhookService) ListDeliveries(subscriptionID uint, filter *models.FilterWebhookDelivery) ([]models.WebhookDelivery, error) {
+	if _, err := s.repo.GetSubscription(subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(subscriptionID, filter)
+}
+
+// Redeliver ставит доставку в очередь заново, в том числе из dead-letter
+func (s *webhookService) Redeliver(deliveryID uint) error {
+	delivery, err := s.repo.GetDelivery(deliveryID)
+	if err != nil {
+		return err
+	}
+
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	delivery.LastError = ""
+
+	if err := s.repo.UpdateDelivery(delivery); err != nil {
+		return err
+	}
+	s.logger.Info("webhook redelivery scheduled", "delivery_id", deliveryID)
+	return nil
+}
+
+// HandleEvent получает событие из outbox и ставит доставки в очередь.
+// Запрос пользователя это не задерживает: отправляет доставки воркер.
+// ID события берется из outbox, поэтому повторная обработка не создает дублей.
+func (s *webhookService) HandleEvent(ctx context.Context, ev *models.OutboxEvent) error {
+	subs, err := s.repo.ListActiveSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	var matched []models.WebhookSubscription
+	for _, sub := range subs {
+		if slices.Contains(strings.Split(sub.Events, ","), string(ev.EventType)) {
+			matched = append(matched, sub)
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+
+	eventID := strconv.FormatUint(uint64(ev.ID), 10)
+	body, err := json.Marshal(WebhookEnvelope{
+		ID:        eventID,
+		Type:      ev.EventType,
+		CreatedAt: ev.CreatedAt.UTC(),
+		Data:      json.RawMessage(ev.Payload),
+	})
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(matched))
+	for _, sub := range matched {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        eventID,
+			EventType:      ev.EventType,
+			Payload:        string(body),
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  time.Now(),
+		})
+	}
+
+	return s.repo.CreateDeliveries(deliveries)
+}
+
+func (s *webhookService) ProcessDue(ctx context.Context) error {
+	deliveries, err := s.repo.ClaimDueDeliveries(webhookBatchSize, webhookLease)
+	if err != nil {
+		return err
+	}
+
+	for i := range deliveries {
+		s.attempt(ctx, &deliveries[i])
+	}
+	return nil
+}
+
+// RunWorker отправляет накопившиеся доставки до отмены контекста
+func (s *webhookService) RunWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.ProcessDue(ctx); err != nil {
+			s.logger.Error("webhook ProcessDue failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *webhookService) attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	sub, err := s.repo.GetSubscription(delivery.SubscriptionID)
+	if err != nil {
+		// подписку удалили — дальше пытаться бессмысленно
+		delivery.Status = models.WebhookDeliveryDead
+		delivery.LastError = "подписка не найдена"
+		_ = s.repo.UpdateDelivery(delivery)
+		return
+	}
+
+	delivery.Attempts++
+	status, err := s.send(ctx, sub, delivery)
+	delivery.ResponseStatus = status
+
+	if err == nil {
+		now := time.Now()
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		s.logger.Info("webhook delivered", "delivery_id", delivery.ID, "subscription_id", sub.ID, "status", status)
+	} else {
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= s.maxAttempts {
+			delivery.Status = models.WebhookDeliveryDead
+			s.logger.Warn("webhook moved to dead-letter", "delivery_id", delivery.ID, "attempts", delivery.Attempts, "error", err)
+		} else {
+			delivery.NextAttemptAt = time.Now().Add(webhookBackoff(delivery.Attempts))
+			s.logger.Warn("webhook delivery failed, retry scheduled",
+				"delivery_id", delivery.ID,
+				"attempts", delivery.Attempts,
+				"next_attempt_at", delivery.NextAttemptAt,
+				"error", err)
+		}
+	}
+
+	if err := s.repo.UpdateDelivery(delivery); err != nil {
+		s.logger.Error("failed to save webhook delivery", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+func (s *webhookService) send(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", string(delivery.EventType))
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhook(sub.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("подписчик ответил статусом %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhook считает HMAC-SHA256 от "timestamp.body". Подписчик проверяет
+// подпись тем же секретом и отбрасывает запросы со старым timestamp.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff << (attempts - 1)
+	if backoff <= 0 || backoff > webhookMaxBackoff {
+		return webhookMaxBackoff
+	}
+	return backoff
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.New("не удалось сгенерировать случайное значение")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// validateWebhookEvents сверяет типы событий подписки со списком models.EventTypes,
+// чтобы новые типы не приходилось дописывать еще и в теги валидации
+func validateWebhookEvents(events []string) error {
+	for _, ev := range events {
+		if !slices.Contains(models.EventTypes, models.EventType(ev)) {
+			return fmt.Errorf("%w: %s", ErrUnknownWebhookEvent, ev)
+		}
+	}
+	return nil
+}
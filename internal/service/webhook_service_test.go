@@ -0,0 +1,35 @@
+package service
+
+import "testing"
+
+func TestSignWebhook(t *testing.T) {
+	const (
+		secret    = "whsec_test"
+		timestamp = "1767225600"
+		body      = `{"event":"booking.created"}`
+		// HMAC-SHA256(secret, timestamp + "." + body), посчитан независимо
+		want = "572882dc7ea9499178d82378f06931474dd00a69729b6ed55cea230f7e5fd6f6"
+	)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      string
+		match     bool
+	}{
+		{name: "та же подпись", secret: secret, timestamp: timestamp, body: body, match: true},
+		{name: "другой секрет", secret: "whsec_other", timestamp: timestamp, body: body},
+		{name: "другой timestamp", secret: secret, timestamp: "1767225601", body: body},
+		{name: "измененное тело", secret: secret, timestamp: timestamp, body: `{"event":"booking.deleted"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SignWebhook(tt.secret, tt.timestamp, []byte(tt.body))
+			if (got == want) != tt.match {
+				t.Fatalf("SignWebhook = %s, совпадение с эталоном ожидалось: %v", got, tt.match)
+			}
+		})
+	}
+}
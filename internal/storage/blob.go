@@ -0,0 +1,17 @@
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrBlobNotFound = errors.New("файл не найден")
+
+// BlobStore хранит файлы по ключу вида "places/12/3f9c.jpg". Сейчас есть
+// только локальная реализация; S3-совместимое хранилище подключается
+// реализацией того же интерфейса.
+type BlobStore interface {
+	Put(key string, r io.Reader) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSignature = errors.New("ссылка недействительна или устарела")
+
+// URLSigner выдает временные ссылки на файлы хранилища: подпись покрывает ключ
+// и срок действия, поэтому ссылку нельзя переиспользовать для другого файла
+type URLSigner struct {
+	baseURL string
+	secret  []byte
+	ttl     time.Duration
+}
+
+func NewURLSigner(baseURL, secret string, ttl time.Duration) *URLSigner {
+	return &URLSigner{baseURL: strings.TrimRight(baseURL, "/"), secret: []byte(secret), ttl: ttl}
+}
+
+func (s *URLSigner) Sign(key string, now time.Time) (string, time.Time) {
+	expires := now.Add(s.ttl).Truncate(time.Second)
+	exp := strconv.FormatInt(expires.Unix(), 10)
+
+	q := url.Values{}
+	q.Set("expires", exp)
+	q.Set("sig", s.signature(key, exp))
+	return fmt.Sprintf("%s/media/%s?%s", s.baseURL, key, q.Encode()), expires
+}
+
+func (s *URLSigner) Verify(key, expires, sig string, now time.Time) error {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > exp {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(sig), []byte(s.signature(key, expires))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func (s *URLSigner) signature(key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key))
+	mac.Write([]byte("."))
+	mac.Write([]byte(expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestURLSigner(t *testing.T) {
+	signer := NewURLSigner("https://cdn.example.com/", "media_secret", 10*time.Minute)
+	now := time.Date(2026, 3, 9, 12, 0, 0, 500, time.UTC)
+	key := "places/7/photo.jpg"
+
+	link, expires := signer.Sign(key, now)
+	if !expires.Equal(now.Add(10 * time.Minute).Truncate(time.Second)) {
+		t.Fatalf("срок действия %v", expires)
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("ссылка не разбирается: %v", err)
+	}
+	if u.Host != "cdn.example.com" || u.Path != "/media/"+key {
+		t.Fatalf("ссылка %s", link)
+	}
+	exp, sig := u.Query().Get("expires"), u.Query().Get("sig")
+
+	tests := []struct {
+		name    string
+		key     string
+		expires string
+		sig     string
+		now     time.Time
+		ok      bool
+	}{
+		{name: "свежая ссылка", key: key, expires: exp, sig: sig, now: now, ok: true},
+		{name: "в последнюю секунду срока", key: key, expires: exp, sig: sig, now: expires, ok: true},
+		{name: "срок истек", key: key, expires: exp, sig: sig, now: expires.Add(time.Second)},
+		{name: "другой файл", key: "places/8/photo.jpg", expires: exp, sig: sig, now: now},
+		{name: "продленный срок", key: key, expires: "9999999999", sig: sig, now: now},
+		{name: "срок не число", key: key, expires: "завтра", sig: sig, now: now},
+		{name: "подпись в другом регистре", key: key, expires: exp, sig: strings.ToUpper(sig), now: now},
+		{name: "пустая подпись", key: key, expires: exp, sig: "", now: now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := signer.Verify(tt.key, tt.expires, tt.sig, tt.now)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("неожиданная ошибка: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidSignature) {
+				t.Fatalf("ошибка %v, ожидалась ErrInvalidSignature", err)
+			}
+		})
+	}
+
+	other := NewURLSigner("https://cdn.example.com", "other_secret", 10*time.Minute)
+	if err := other.Verify(key, exp, sig, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("подпись чужим секретом принята: %v", err)
+	}
+}
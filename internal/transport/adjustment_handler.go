@@ -0,0 +1,170 @@
+package transport
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/service"
+)
+
+type AdjustmentHandler struct {
+	service service.AdjustmentService
+	logger  *slog.Logger
+}
+
+func NewAdjustmentHandler(service service.AdjustmentService, logger *slog.Logger) *AdjustmentHandler {
+	return &AdjustmentHandler{service: service, logger: logger}
+}
+
+func (h *AdjustmentHandler) RegisterAdminRoutes(admin *gin.RouterGroup) {
+	admin.PATCH("/users/:id/balance", h.AdjustUser)
+	admin.PATCH("/organizations/:id/balance", h.AdjustOrganization)
+
+	admin.GET("/balance-adjustments", h.List)
+	admin.GET("/balance-adjustments/report", h.Report)
+	admin.POST("/balance-adjustments/:id/approve", h.Approve)
+	admin.POST("/balance-adjustments/:id/reject", h.Reject)
+}
+
+func (h *AdjustmentHandler) AdjustUser(c *gin.Context) {
+	h.adjust(c, h.service.AdjustUser, "пользователь не найден")
+}
+
+func (h *AdjustmentHandler) AdjustOrganization(c *gin.Context) {
+	h.adjust(c, h.service.AdjustOrganization, "организация не найдена")
+}
+
+func (h *AdjustmentHandler) adjust(
+	c *gin.Context,
+	apply func(adminID, targetID uint, req models.BalanceAdjustmentDTO) (*models.BalanceAdjustment, error),
+	notFound string,
+) {
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	var req models.BalanceAdjustmentDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adminID := c.GetUint("admin_id")
+	adj, err := apply(adminID, uint(targetID), req)
+	if err != nil {
+		h.logger.Warn("balance adjustment failed", "target_id", targetID, "admin_id", adminID, "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 202 — корректировка принята, но ждет подтверждения другим администратором
+	if adj.Status == models.AdjustmentPending {
+		c.JSON(http.StatusAccepted, adj)
+		return
+	}
+	c.JSON(http.StatusOK, adj)
+}
+
+func (h *AdjustmentHandler) List(c *gin.Context) {
+	var filter models.FilterAdjustments
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, total, err := h.service.List(&filter)
+	if err != nil {
+		h.logger.Error("ListAdjustments failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить корректировки"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items, "total": total})
+}
+
+// Report по умолчанию строится за текущий месяц; to не включается в период
+func (h *AdjustmentHandler) Report(c *gin.Context) {
+	var query struct {
+		From *time.Time `form:"from" time_format:"2006-01-02"`
+		To   *time.Time `form:"to" time_format:"2006-01-02"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := from.AddDate(0, 1, 0)
+	if query.From != nil {
+		from = *query.From
+	}
+	if query.To != nil {
+		to = *query.To
+	}
+
+	rows, err := h.service.Report(from, to)
+	if err != nil {
+		h.logger.Warn("AdjustmentReport failed", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "items": rows})
+}
+
+func (h *AdjustmentHandler) Approve(c *gin.Context) {
+	h.review(c, h.service.Approve)
+}
+
+func (h *AdjustmentHandler) Reject(c *gin.Context) {
+	h.review(c, h.service.Reject)
+}
+
+func (h *AdjustmentHandler) review(c *gin.Context, decide func(adminID, id uint, comment string) (*models.BalanceAdjustment, error)) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID корректировки"})
+		return
+	}
+
+	var req models.ReviewAdjustmentDTO
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	adminID := c.GetUint("admin_id")
+	adj, err := decide(adminID, uint(id), req.Comment)
+	if err != nil {
+		h.logger.Warn("adjustment review failed", "adjustment_id", id, "admin_id", adminID, "error", err)
+		switch {
+		case errors.Is(err, service.ErrAdjustmentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrSelfApproval):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrAdjustmentProcessed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось обработать корректировку"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, adj)
+}
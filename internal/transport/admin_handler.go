@@ -15,24 +15,29 @@ import (
 )
 
 type AdminHandler struct {
-	userService    service.UserService
-	bookingService service.BookingService
-	logger         *slog.Logger
+	userService     service.UserService
+	bookingService  service.BookingService
+	locationService service.LocationService
+	logger          *slog.Logger
 }
 
 func NewAdminHandler(
 	userService service.UserService,
 	bookingService service.BookingService,
+	locationService service.LocationService,
 	logger *slog.Logger,
 ) *AdminHandler {
 	return &AdminHandler{
-		userService:    userService,
-		bookingService: bookingService,
-		logger:         logger,
+		userService:     userService,
+		bookingService:  bookingService,
+		locationService: locationService,
+		logger:          logger,
 	}
 }
 
-func (h *AdminHandler) RegisterRoutes(r *gin.Engine, adminService service.AdminService) {
+// RegisterRoutes возвращает группу /admin, чтобы остальные админские хендлеры
+// регистрировались под тем же BasicAuth
+func (h *AdminHandler) RegisterRoutes(r *gin.Engine, adminService service.AdminService) *gin.RouterGroup {
 	admin := r.Group("/admin", middleware.AdminBasicAuthMiddleware(adminService, h.logger))
 
 	admin.GET("/login", h.Login)
@@ -40,12 +45,14 @@ func (h *AdminHandler) RegisterRoutes(r *gin.Engine, adminService service.AdminS
 	admin.GET("/users", h.GetAllUsers)
 	admin.PUT("/users/:id", h.UpdateUser)
 	admin.DELETE("/users/:id", h.DeleteUser)
-	admin.PATCH("/users/:id/balance", h.UpdateUserBalance)
+	admin.PATCH("/users/:id/billing", h.UpdateUserBilling)
 
 	admin.PUT("/bookings/:id", h.UpdateBooking)
 	admin.DELETE("/bookings/:id", h.DeleteBooking)
 
 	admin.PUT("/status/booking/:id", h.AdminUpdateBookingStatus)
+
+	return admin
 }
 
 func (h *AdminHandler) Login(c *gin.Context) {
@@ -141,6 +148,16 @@ func (h *AdminHandler) UpdateBooking(c *gin.Context) {
 		return
 	}
 
+	if !h.checkBookingScope(c, uint(bookingID)) {
+		return
+	}
+	if req.PlaceID != nil {
+		if err := h.locationService.CheckPlace(c.GetUint("admin_id"), *req.PlaceID); err != nil {
+			h.writeScopeError(c, err)
+			return
+		}
+	}
+
 	if err := h.bookingService.UpdateBook(uint(bookingID), &req); err != nil {
 		h.logger.Error("UpdateBooking failed", "booking_id", bookingID, "error", err)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -164,6 +181,10 @@ func (h *AdminHandler) DeleteBooking(c *gin.Context) {
 		return
 	}
 
+	if !h.checkBookingScope(c, uint(bookingID)) {
+		return
+	}
+
 	if err := h.bookingService.DeleteBooking(uint(bookingID)); err != nil {
 		h.logger.Error("DeleteBooking failed", "booking_id", bookingID, "error", err)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -218,16 +239,20 @@ func (h *AdminHandler) AdminUpdateBookingStatus(c *gin.Context) {
 		return
 	}
 
+	if !h.checkBookingScope(c, uint(bookingID)) {
+		return
+	}
+
 	// Получаем информацию о букинге для детального сообщения об ошибке
 	bookingInfo, _ := h.bookingService.GetBookingById(uint(bookingID))
 
-	if err := h.bookingService.UpdateBookingStatusWithBalance(uint(bookingID), bookingStatus); err != nil {
+	if _, err := h.bookingService.UpdateBookingStatusWithBalance(uint(bookingID), bookingStatus); err != nil {
 		h.logger.Error("AdminUpdateBookingStatus failed", "booking_id", bookingID, "error", err)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "бронирование не найдено"})
 			return
 		}
-		if err.Error() == "insufficient balance" {
+		if errors.Is(err, service.ErrInsufficientFunds) {
 			// Формируем детальное сообщение об ошибке
 			errorResponse := gin.H{
 				"error":   "недостаточно средств",
@@ -250,6 +275,10 @@ func (h *AdminHandler) AdminUpdateBookingStatus(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, errorResponse)
 			return
 		}
+		if errors.Is(err, service.ErrSpendingLimit) || errors.Is(err, service.ErrCreditLimitExceeded) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось обновить статус бронирования"})
 		return
 	}
@@ -258,32 +287,55 @@ func (h *AdminHandler) AdminUpdateBookingStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "статус бронирования успешно обновлен"})
 }
 
-func (h *AdminHandler) UpdateUserBalance(c *gin.Context) {
+// UpdateUserBilling переключает пользователя между предоплатой и постоплатой с кредитным лимитом
+func (h *AdminHandler) UpdateUserBilling(c *gin.Context) {
 	idParam := c.Param("id")
-	userID64, err := strconv.ParseUint(idParam, 10, 32)
+	userID, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
 		h.logger.Warn("invalid user id", "id", idParam, "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID пользователя"})
 		return
 	}
 
-	var req models.UpdateBalanceDTO
+	var req models.BillingSettingsDTO
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warn("UpdateUserBalance invalid body", "error", err)
+		h.logger.Warn("UpdateUserBilling invalid body", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if req.Amount == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "amount не может быть 0"})
+	if err := h.userService.UpdateBillingSettings(uint(userID), req); err != nil {
+		h.logger.Error("UpdateUserBilling failed", "user_id", userID, "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "пользователь не найден"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось обновить режим оплаты"})
 		return
 	}
 
-	if err := h.userService.UpdateUserBalance(uint(userID64), req.Amount); err != nil {
-		h.logger.Error("UpdateUserBalance failed", "user_id", userID64, "amount", req.Amount, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось изменить баланс"})
-		return
+	c.JSON(http.StatusOK, gin.H{"message": "режим оплаты обновлен"})
+}
+
+// checkBookingScope пропускает администратора только к броням мест на его площадках
+func (h *AdminHandler) checkBookingScope(c *gin.Context, bookingID uint) bool {
+	if err := h.locationService.CheckBooking(c.GetUint("admin_id"), bookingID); err != nil {
+		h.writeScopeError(c, err)
+		return false
 	}
+	return true
+}
 
-	c.JSON(http.StatusOK, gin.H{"message": "баланс обновлен"})
+func (h *AdminHandler) writeScopeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "бронирование не найдено"})
+	case errors.Is(err, service.ErrPlaceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "место не найдено"})
+	case errors.Is(err, service.ErrLocationForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("admin scope check failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось проверить доступ"})
+	}
 }
@@ -0,0 +1,96 @@
+package transport
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/realtime"
+	"github.com/IslamCHup/coworking-manager-project/internal/service"
+)
+
+const sseHeartbeat = 15 * time.Second
+
+type AvailabilityHandler struct {
+	service service.AvailabilityService
+	logger  *slog.Logger
+}
+
+func NewAvailabilityHandler(service service.AvailabilityService, logger *slog.Logger) *AvailabilityHandler {
+	return &AvailabilityHandler{service: service, logger: logger}
+}
+
+func (h *AvailabilityHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/places/stream", h.Stream)
+}
+
+// Stream отдает изменения занятости мест по SSE. Поддерживает фильтр
+// по place_id и type, возобновление по заголовку Last-Event-ID и heartbeat-комментарии.
+func (h *AvailabilityHandler) Stream(c *gin.Context) {
+	var filter models.FilterOccupancy
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, cancel := h.service.Subscribe(filter)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// подписка оформлена до чтения истории, поэтому событие между ними не потеряется.
+	// Живые события, пришедшие во время чтения, уже могут быть в истории:
+	// все, что по порядку id не новее последнего отправленного, отбрасывается.
+	lastSent := ""
+	if lastID := c.GetHeader("Last-Event-ID"); lastID != "" {
+		missed, err := h.service.Since(c.Request.Context(), lastID, filter)
+		if err != nil {
+			h.logger.Warn("occupancy resume failed", "last_event_id", lastID, "error", err)
+		}
+		for _, ev := range missed {
+			renderOccupancy(c, ev)
+			lastSent = ev.ID
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case ev, ok := <-events:
+			if !ok {
+				return false
+			}
+			if ev.ID != "" && lastSent != "" && !realtime.IDAfter(ev.ID, lastSent) {
+				return true
+			}
+			renderOccupancy(c, ev)
+			lastSent = ev.ID
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": heartbeat\n\n")
+			return err == nil
+		}
+	})
+}
+
+func renderOccupancy(c *gin.Context, ev models.OccupancyEvent) {
+	c.Render(-1, sse.Event{
+		Id:    ev.ID,
+		Event: string(ev.Kind),
+		Data:  ev,
+	})
+}
@@ -1,11 +1,13 @@
 package transport
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"github.com/IslamCHup/coworking-manager-project/internal/middleware"
 	"github.com/IslamCHup/coworking-manager-project/internal/models"
@@ -37,6 +39,7 @@ func (h *BookingHandler) RegisterRoutes(r *gin.Engine) {
 		protected.DELETE("/:id", h.DeleteBooking)
 		protected.PATCH("/:id", h.Update)
 		protected.PATCH("/status/:id", h.UpdateStatus)
+		protected.PATCH("/check-in/:id", h.CheckIn)
 	}
 }
 
@@ -104,16 +107,20 @@ func (h *BookingHandler) ListBooking(c *gin.Context) {
 		return
 	}
 	filter := models.FilterBooking{
-		Status:    q.Status,
-		Preload:   q.Preload,
-		PriceMin:  q.PriceMin,
-		PriceMax:  q.PriceMax,
-		StartTime: q.StartTime,
-		EndTime:   q.EndTime,
-		Limit:     q.Limit,
-		Offset:    q.Offset,
-		SortBy:    q.SortBy,
-		Order:     q.Order,
+		PlaceID:    q.PlaceID,
+		LocationID: q.LocationID,
+		FloorID:    q.FloorID,
+		ZoneID:     q.ZoneID,
+		Status:     q.Status,
+		Preload:    q.Preload,
+		PriceMin:   q.PriceMin,
+		PriceMax:   q.PriceMax,
+		StartTime:  q.StartTime,
+		EndTime:    q.EndTime,
+		Limit:      q.Limit,
+		Offset:     q.Offset,
+		SortBy:     q.SortBy,
+		Order:      q.Order,
 	}
 	booking, err := h.service.ListBooking(&filter)
 
@@ -168,3 +175,26 @@ func (h *BookingHandler) UpdateStatus(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "обновлено"})
 }
+
+func (h *BookingHandler) CheckIn(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID бронирования"})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+
+	if err := h.service.CheckIn(userID, uint(id)); err != nil {
+		h.logger.Warn("CheckIn failed", "booking_id", id, "user_id", userID, "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "бронирование не найдено"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("CheckIn success", "booking_id", id, "user_id", userID)
+	c.JSON(http.StatusOK, gin.H{"message": "вы отметились"})
+}
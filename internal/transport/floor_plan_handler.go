@@ -0,0 +1,179 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/floorplan"
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/service"
+)
+
+type FloorPlanHandler struct {
+	service service.FloorPlanService
+	logger  *slog.Logger
+}
+
+func NewFloorPlanHandler(service service.FloorPlanService, logger *slog.Logger) *FloorPlanHandler {
+	return &FloorPlanHandler{service: service, logger: logger}
+}
+
+func (h *FloorPlanHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/floors/:id/map", h.Map)
+	r.GET("/floors/:id/plan/background", h.Background)
+}
+
+func (h *FloorPlanHandler) RegisterAdminRoutes(admin *gin.RouterGroup) {
+	admin.PUT("/floors/:id/plan", h.UploadPlan)
+	admin.PUT("/places/:id/position", h.SetPosition)
+	admin.DELETE("/places/:id/position", h.ClearPosition)
+}
+
+// Map отдает занятость мест этажа: JSON для клиентов, рисующих план сами, или готовый SVG
+func (h *FloorPlanHandler) Map(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID этажа")
+	if !ok {
+		return
+	}
+
+	var q models.FilterFloorMap
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	floorMap, err := h.service.FloorMap(id, q)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	if q.Format != "svg" {
+		c.JSON(http.StatusOK, floorMap)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := floorplan.RenderSVG(&buf, floorMap); err != nil {
+		h.logger.Error("render floor svg failed", "floor_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось построить план"})
+		return
+	}
+	c.Data(http.StatusOK, "image/svg+xml; charset=utf-8", buf.Bytes())
+}
+
+func (h *FloorPlanHandler) Background(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID этажа")
+	if !ok {
+		return
+	}
+
+	plan, err := h.service.GetBackground(id)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	// Подложку загружает администратор площадки, и SVG может содержать скрипты.
+	// CSP с sandbox не дает им выполниться, если файл открыть по прямой ссылке.
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Data(http.StatusOK, plan.ContentType, plan.Image)
+}
+
+// UploadPlan принимает multipart-форму: файл image и, для SVG, размеры width и height
+func (h *FloorPlanHandler) UploadPlan(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID этажа")
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "файл плана не передан"})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "не удалось прочитать файл"})
+		return
+	}
+	defer f.Close()
+
+	// читаем на байт больше лимита, чтобы сервис мог отклонить слишком большой файл
+	data, err := io.ReadAll(io.LimitReader(f, 5<<20+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "не удалось прочитать файл"})
+		return
+	}
+
+	width, _ := strconv.Atoi(c.PostForm("width"))
+	height, _ := strconv.Atoi(c.PostForm("height"))
+
+	plan, err := h.service.UploadPlan(c.GetUint("admin_id"), id, data, width, height)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+func (h *FloorPlanHandler) SetPosition(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID места")
+	if !ok {
+		return
+	}
+
+	var req models.PlacePositionDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	place, err := h.service.SetPlacePosition(c.GetUint("admin_id"), id, req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, place)
+}
+
+func (h *FloorPlanHandler) ClearPosition(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID места")
+	if !ok {
+		return
+	}
+
+	if err := h.service.ClearPlacePosition(c.GetUint("admin_id"), id); err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "место убрано с плана"})
+}
+
+func (h *FloorPlanHandler) writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrFloorNotFound),
+		errors.Is(err, service.ErrFloorPlanNotFound),
+		errors.Is(err, service.ErrPlaceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrLocationForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidFloorPlan),
+		errors.Is(err, service.ErrInvalidPosition),
+		errors.Is(err, service.ErrInvalidMapRange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPlaceWithoutFloor):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("floor plan request failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось выполнить запрос"})
+	}
+}
@@ -0,0 +1,313 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/service"
+)
+
+type InvoiceHandler struct {
+	service service.InvoiceService
+	orgs    service.OrganizationService
+	logger  *slog.Logger
+}
+
+func NewInvoiceHandler(service service.InvoiceService, orgs service.OrganizationService, logger *slog.Logger) *InvoiceHandler {
+	return &InvoiceHandler{service: service, orgs: orgs, logger: logger}
+}
+
+func (h *InvoiceHandler) RegisterUserRoutes(users *gin.RouterGroup) {
+	users.GET("/me/invoices", h.ListMyInvoices)
+	users.GET("/me/invoices/:id", h.GetMyInvoice)
+	users.GET("/me/invoices/:id/html", h.myDocument(service.InvoiceHTML))
+	users.GET("/me/invoices/:id/pdf", h.myDocument(service.InvoicePDF))
+}
+
+// RegisterOrganizationRoutes — счета организации, доступны ее владельцам
+func (h *InvoiceHandler) RegisterOrganizationRoutes(protected *gin.RouterGroup) {
+	protected.GET("/organizations/me/invoices", h.ListOrganizationInvoices)
+	protected.GET("/organizations/me/invoices/:id", h.GetOrganizationInvoice)
+	protected.GET("/organizations/me/invoices/:id/html", h.organizationDocument(service.InvoiceHTML))
+	protected.GET("/organizations/me/invoices/:id/pdf", h.organizationDocument(service.InvoicePDF))
+}
+
+func (h *InvoiceHandler) RegisterAdminRoutes(admin *gin.RouterGroup) {
+	admin.GET("/invoices", h.ListInvoices)
+	admin.POST("/invoices/generate", h.Generate)
+	admin.GET("/invoices/:id", h.GetInvoice)
+	admin.GET("/invoices/:id/html", h.adminDocument(service.InvoiceHTML))
+	admin.GET("/invoices/:id/pdf", h.adminDocument(service.InvoicePDF))
+	admin.POST("/invoices/:id/issue", h.Issue)
+	admin.POST("/invoices/:id/pay", h.MarkPaid)
+	admin.POST("/invoices/:id/void", h.Void)
+}
+
+func (h *InvoiceHandler) ListMyInvoices(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var filter models.FilterInvoice
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invoices, total, err := h.service.ListForUser(userID, &filter)
+	if err != nil {
+		h.logger.Error("ListMyInvoices failed", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить счета"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": invoices, "total": total})
+}
+
+func (h *InvoiceHandler) GetMyInvoice(c *gin.Context) {
+	inv, ok := h.loadMine(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, inv)
+}
+
+func (h *InvoiceHandler) myDocument(format service.InvoiceFormat) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		inv, ok := h.loadMine(c)
+		if !ok {
+			return
+		}
+		h.writeDocument(c, inv, format)
+	}
+}
+
+func (h *InvoiceHandler) loadMine(c *gin.Context) (*models.Invoice, bool) {
+	userID := c.MustGet("user_id").(uint)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID счета"})
+		return nil, false
+	}
+
+	inv, err := h.service.GetForUser(userID, uint(id))
+	if err != nil {
+		h.writeError(c, err)
+		return nil, false
+	}
+	return inv, true
+}
+
+func (h *InvoiceHandler) ListOrganizationInvoices(c *gin.Context) {
+	orgID, ok := h.managedOrganization(c)
+	if !ok {
+		return
+	}
+
+	var filter models.FilterInvoice
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invoices, total, err := h.service.ListForOrganization(orgID, &filter)
+	if err != nil {
+		h.logger.Error("ListOrganizationInvoices failed", "organization_id", orgID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить счета"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": invoices, "total": total})
+}
+
+func (h *InvoiceHandler) GetOrganizationInvoice(c *gin.Context) {
+	inv, ok := h.loadOrganization(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, inv)
+}
+
+func (h *InvoiceHandler) organizationDocument(format service.InvoiceFormat) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		inv, ok := h.loadOrganization(c)
+		if !ok {
+			return
+		}
+		h.writeDocument(c, inv, format)
+	}
+}
+
+func (h *InvoiceHandler) loadOrganization(c *gin.Context) (*models.Invoice, bool) {
+	orgID, ok := h.managedOrganization(c)
+	if !ok {
+		return nil, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID счета"})
+		return nil, false
+	}
+
+	inv, err := h.service.GetForOrganization(orgID, uint(id))
+	if err != nil {
+		h.writeError(c, err)
+		return nil, false
+	}
+	return inv, true
+}
+
+func (h *InvoiceHandler) managedOrganization(c *gin.Context) (uint, bool) {
+	userID := c.MustGet("user_id").(uint)
+
+	orgID, err := h.orgs.ManagedOrganization(userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotOrgMember):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrOrgForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("managed organization lookup failed", "user_id", userID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить организацию"})
+		}
+		return 0, false
+	}
+	return orgID, true
+}
+
+func (h *InvoiceHandler) ListInvoices(c *gin.Context) {
+	var filter models.FilterInvoice
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invoices, total, err := h.service.List(&filter)
+	if err != nil {
+		h.logger.Error("ListInvoices failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить счета"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": invoices, "total": total})
+}
+
+func (h *InvoiceHandler) Generate(c *gin.Context) {
+	var req models.GenerateInvoicesDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invoices, err := h.service.Generate(req.Year, req.Month, req.UserID, req.OrganizationID)
+	if err != nil {
+		h.logger.Error("Generate invoices failed", "year", req.Year, "month", req.Month, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"items": invoices, "created": len(invoices)})
+}
+
+func (h *InvoiceHandler) GetInvoice(c *gin.Context) {
+	inv, ok := h.loadAny(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, inv)
+}
+
+func (h *InvoiceHandler) adminDocument(format service.InvoiceFormat) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		inv, ok := h.loadAny(c)
+		if !ok {
+			return
+		}
+		h.writeDocument(c, inv, format)
+	}
+}
+
+func (h *InvoiceHandler) loadAny(c *gin.Context) (*models.Invoice, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID счета"})
+		return nil, false
+	}
+
+	inv, err := h.service.Get(uint(id))
+	if err != nil {
+		h.writeError(c, err)
+		return nil, false
+	}
+	return inv, true
+}
+
+func (h *InvoiceHandler) Issue(c *gin.Context) {
+	h.transition(c, func(id uint) (*models.Invoice, error) { return h.service.Issue(id) })
+}
+
+func (h *InvoiceHandler) MarkPaid(c *gin.Context) {
+	h.transition(c, func(id uint) (*models.Invoice, error) { return h.service.MarkPaid(id) })
+}
+
+func (h *InvoiceHandler) Void(c *gin.Context) {
+	var req models.VoidInvoiceDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.transition(c, func(id uint) (*models.Invoice, error) { return h.service.Void(id, req.Reason) })
+}
+
+func (h *InvoiceHandler) transition(c *gin.Context, apply func(id uint) (*models.Invoice, error)) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID счета"})
+		return
+	}
+
+	inv, err := apply(uint(id))
+	if err != nil {
+		h.logger.Warn("invoice transition failed", "invoice_id", id, "path", c.FullPath(), "error", err)
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, inv)
+}
+
+func (h *InvoiceHandler) writeDocument(c *gin.Context, inv *models.Invoice, format service.InvoiceFormat) {
+	data, err := h.service.Render(inv, format)
+	if err != nil {
+		h.logger.Error("invoice render failed", "invoice_id", inv.ID, "format", format, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось сформировать документ"})
+		return
+	}
+
+	name := fmt.Sprintf("invoice-%d", inv.ID)
+	if inv.Number != nil {
+		name = *inv.Number
+	}
+
+	contentType := "text/html; charset=utf-8"
+	if format == service.InvoicePDF {
+		contentType = "application/pdf"
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, name))
+	}
+	c.Data(http.StatusOK, contentType, data)
+}
+
+func (h *InvoiceHandler) writeError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvoiceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,43 @@
+package transport
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/service"
+)
+
+type LedgerHandler struct {
+	service service.LedgerService
+	logger  *slog.Logger
+}
+
+func NewLedgerHandler(service service.LedgerService, logger *slog.Logger) *LedgerHandler {
+	return &LedgerHandler{service: service, logger: logger}
+}
+
+func (h *LedgerHandler) RegisterRoutes(users *gin.RouterGroup) {
+	users.GET("/me/transactions", h.ListTransactions)
+}
+
+func (h *LedgerHandler) ListTransactions(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var filter models.FilterTransactions
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.service.ListUserTransactions(userID, filter)
+	if err != nil {
+		h.logger.Error("ListTransactions failed", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить операции"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,281 @@
+package transport
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/service"
+)
+
+type LocationHandler struct {
+	service service.LocationService
+	logger  *slog.Logger
+}
+
+func NewLocationHandler(service service.LocationService, logger *slog.Logger) *LocationHandler {
+	return &LocationHandler{service: service, logger: logger}
+}
+
+func (h *LocationHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/locations", h.List)
+	r.GET("/locations/:id", h.Get)
+}
+
+func (h *LocationHandler) RegisterAdminRoutes(admin *gin.RouterGroup) {
+	admin.GET("/locations", h.ListManaged)
+	admin.POST("/locations", h.CreateLocation)
+	admin.PATCH("/locations/:id", h.UpdateLocation)
+	admin.DELETE("/locations/:id", h.DeleteLocation)
+
+	admin.POST("/locations/:id/floors", h.CreateFloor)
+	admin.PATCH("/floors/:id", h.UpdateFloor)
+	admin.DELETE("/floors/:id", h.DeleteFloor)
+
+	admin.POST("/floors/:id/zones", h.CreateZone)
+	admin.PATCH("/zones/:id", h.UpdateZone)
+	admin.DELETE("/zones/:id", h.DeleteZone)
+
+	admin.PUT("/admins/:id/locations", h.SetAdminLocations)
+}
+
+func (h *LocationHandler) List(c *gin.Context) {
+	locations, err := h.service.ListLocations()
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, locations)
+}
+
+func (h *LocationHandler) Get(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID площадки")
+	if !ok {
+		return
+	}
+
+	loc, err := h.service.GetLocation(id)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, loc)
+}
+
+func (h *LocationHandler) ListManaged(c *gin.Context) {
+	locations, err := h.service.ListManagedLocations(c.GetUint("admin_id"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, locations)
+}
+
+func (h *LocationHandler) CreateLocation(c *gin.Context) {
+	var req models.LocationDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loc, err := h.service.CreateLocation(c.GetUint("admin_id"), req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, loc)
+}
+
+func (h *LocationHandler) UpdateLocation(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID площадки")
+	if !ok {
+		return
+	}
+
+	var req models.LocationUpdateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loc, err := h.service.UpdateLocation(c.GetUint("admin_id"), id, req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, loc)
+}
+
+func (h *LocationHandler) DeleteLocation(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID площадки")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteLocation(c.GetUint("admin_id"), id); err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "площадка удалена"})
+}
+
+func (h *LocationHandler) CreateFloor(c *gin.Context) {
+	locationID, ok := parseID(c, "неверный ID площадки")
+	if !ok {
+		return
+	}
+
+	var req models.FloorDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	floor, err := h.service.CreateFloor(c.GetUint("admin_id"), locationID, req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, floor)
+}
+
+func (h *LocationHandler) UpdateFloor(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID этажа")
+	if !ok {
+		return
+	}
+
+	var req models.FloorUpdateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	floor, err := h.service.UpdateFloor(c.GetUint("admin_id"), id, req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, floor)
+}
+
+func (h *LocationHandler) DeleteFloor(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID этажа")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteFloor(c.GetUint("admin_id"), id); err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "этаж удален"})
+}
+
+func (h *LocationHandler) CreateZone(c *gin.Context) {
+	floorID, ok := parseID(c, "неверный ID этажа")
+	if !ok {
+		return
+	}
+
+	var req models.ZoneDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	zone, err := h.service.CreateZone(c.GetUint("admin_id"), floorID, req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, zone)
+}
+
+func (h *LocationHandler) UpdateZone(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID зоны")
+	if !ok {
+		return
+	}
+
+	var req models.ZoneUpdateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	zone, err := h.service.UpdateZone(c.GetUint("admin_id"), id, req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, zone)
+}
+
+func (h *LocationHandler) DeleteZone(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID зоны")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteZone(c.GetUint("admin_id"), id); err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "зона удалена"})
+}
+
+// SetAdminLocations закрепляет площадки за администратором; доступ ко всем
+// площадкам дает только unrestricted=true
+func (h *LocationHandler) SetAdminLocations(c *gin.Context) {
+	targetID, ok := parseID(c, "неверный ID администратора")
+	if !ok {
+		return
+	}
+
+	var req models.AdminLocationsDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids, err := h.service.SetAdminLocations(c.GetUint("admin_id"), targetID, req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"admin_id": targetID, "unrestricted": req.Unrestricted, "location_ids": ids})
+}
+
+func (h *LocationHandler) writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrLocationNotFound),
+		errors.Is(err, service.ErrAdminNotFound),
+		errors.Is(err, service.ErrFloorNotFound),
+		errors.Is(err, service.ErrZoneNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrLocationForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrLocationInUse),
+		errors.Is(err, service.ErrLocationHasAdmins):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidTimezone):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("location request failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось выполнить запрос"})
+	}
+}
+
+func parseID(c *gin.Context, msg string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return 0, false
+	}
+	return uint(id), true
+}
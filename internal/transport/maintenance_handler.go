@@ -0,0 +1,168 @@
+package transport
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/service"
+)
+
+type MaintenanceHandler struct {
+	service service.MaintenanceService
+	logger  *slog.Logger
+}
+
+func NewMaintenanceHandler(service service.MaintenanceService, logger *slog.Logger) *MaintenanceHandler {
+	return &MaintenanceHandler{service: service, logger: logger}
+}
+
+func (h *MaintenanceHandler) RegisterAdminRoutes(admin *gin.RouterGroup) {
+	admin.GET("/maintenance", h.List)
+	admin.POST("/maintenance", h.Create)
+	admin.GET("/maintenance/:id", h.Get)
+	admin.PATCH("/maintenance/:id", h.Update)
+	admin.DELETE("/maintenance/:id", h.Delete)
+	admin.GET("/maintenance/:id/bookings", h.AffectedBookings)
+	admin.POST("/maintenance/:id/bookings/cancel", h.CancelBookings)
+}
+
+func (h *MaintenanceHandler) List(c *gin.Context) {
+	var q models.FilterMaintenance
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	windows, total, err := h.service.List(c.GetUint("admin_id"), &q)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": windows, "total": total})
+}
+
+func (h *MaintenanceHandler) Create(c *gin.Context) {
+	var req models.MaintenanceWindowDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	window, affected, err := h.service.Create(c.GetUint("admin_id"), req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"window": window, "affected_bookings": affected})
+}
+
+func (h *MaintenanceHandler) Get(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID окна обслуживания")
+	if !ok {
+		return
+	}
+
+	window, err := h.service.Get(c.GetUint("admin_id"), id)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, window)
+}
+
+func (h *MaintenanceHandler) Update(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID окна обслуживания")
+	if !ok {
+		return
+	}
+
+	var req models.MaintenanceWindowUpdateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	window, affected, err := h.service.Update(c.GetUint("admin_id"), id, req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"window": window, "affected_bookings": affected})
+}
+
+func (h *MaintenanceHandler) Delete(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID окна обслуживания")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.GetUint("admin_id"), id); err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "окно обслуживания удалено"})
+}
+
+// AffectedBookings — брони, которые нужно перенести на другое место или отменить
+func (h *MaintenanceHandler) AffectedBookings(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID окна обслуживания")
+	if !ok {
+		return
+	}
+
+	bookings, err := h.service.AffectedBookings(c.GetUint("admin_id"), id)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": bookings, "total": len(bookings)})
+}
+
+func (h *MaintenanceHandler) CancelBookings(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID окна обслуживания")
+	if !ok {
+		return
+	}
+
+	var req models.MaintenanceBookingsDTO
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := h.service.CancelBookings(c.GetUint("admin_id"), id, req.BookingIDs)
+	if errors.Is(err, service.ErrMaintenanceNotSettled) && result != nil {
+		// Часть броней уже отменена и деньги по ним возвращены — отдаем итог вместе с ошибкой
+		h.logger.Warn("maintenance bookings partially cancelled", "window_id", id, "failed", len(result.FailedBookings))
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "result": result})
+		return
+	}
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *MaintenanceHandler) writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrMaintenanceNotFound),
+		errors.Is(err, service.ErrPlaceNotFound),
+		errors.Is(err, service.ErrZoneNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrLocationForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidMaintenance),
+		errors.Is(err, service.ErrBookingNotInMaintenance):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("maintenance request failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,58 @@
+package transport
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/service"
+)
+
+type NotificationHandler struct {
+	service service.NotificationService
+	logger  *slog.Logger
+}
+
+func NewNotificationHandler(service service.NotificationService, logger *slog.Logger) *NotificationHandler {
+	return &NotificationHandler{service: service, logger: logger}
+}
+
+func (h *NotificationHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/me/notifications", h.GetPreferences)
+	r.PUT("/me/notifications", h.UpdatePreference)
+}
+
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	prefs, err := h.service.GetPreferences(userID)
+	if err != nil {
+		h.logger.Error("GetPreferences failed", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить настройки уведомлений"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+func (h *NotificationHandler) UpdatePreference(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var req models.NotificationPreferenceDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("UpdatePreference invalid body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdatePreference(userID, req); err != nil {
+		h.logger.Error("UpdatePreference failed", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось сохранить настройки уведомлений"})
+		return
+	}
+
+	h.logger.Info("UpdatePreference success", "user_id", userID, "channel", req.Channel)
+	c.JSON(http.StatusOK, gin.H{"message": "настройки уведомлений обновлены"})
+}
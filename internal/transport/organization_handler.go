@@ -0,0 +1,261 @@
+package transport
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/service"
+)
+
+type OrganizationHandler struct {
+	service service.OrganizationService
+	logger  *slog.Logger
+}
+
+func NewOrganizationHandler(service service.OrganizationService, logger *slog.Logger) *OrganizationHandler {
+	return &OrganizationHandler{service: service, logger: logger}
+}
+
+func (h *OrganizationHandler) RegisterRoutes(protected *gin.RouterGroup) {
+	orgs := protected.Group("/organizations")
+	orgs.POST("", h.Create)
+	orgs.GET("/me", h.GetMine)
+	orgs.PATCH("/me", h.Update)
+
+	orgs.GET("/me/members", h.ListMembers)
+	orgs.PATCH("/me/members/:user_id", h.UpdateMember)
+	orgs.DELETE("/me/members/:user_id", h.RemoveMember)
+
+	orgs.POST("/me/invitations", h.Invite)
+	orgs.GET("/me/invitations", h.ListInvitations)
+	orgs.POST("/invitations/:token/accept", h.AcceptInvitation)
+
+	orgs.GET("/me/bookings", h.ListMemberBookings)
+}
+
+func (h *OrganizationHandler) RegisterAdminRoutes(admin *gin.RouterGroup) {
+	admin.GET("/organizations", h.ListOrganizations)
+	admin.PATCH("/organizations/:id/billing", h.UpdateBillingSettings)
+}
+
+func (h *OrganizationHandler) Create(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var req models.OrganizationDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.service.Create(userID, req)
+	if err != nil {
+		h.logger.Warn("CreateOrganization failed", "user_id", userID, "error", err)
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, org)
+}
+
+func (h *OrganizationHandler) GetMine(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	org, err := h.service.GetMine(userID)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+func (h *OrganizationHandler) Update(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var req models.OrganizationUpdateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.service.Update(userID, req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+func (h *OrganizationHandler) ListMembers(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	members, err := h.service.ListMembers(userID)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+func (h *OrganizationHandler) UpdateMember(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	memberID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID пользователя"})
+		return
+	}
+
+	var req models.MemberUpdateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.service.UpdateMember(userID, uint(memberID), req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	memberID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID пользователя"})
+		return
+	}
+
+	if err := h.service.RemoveMember(userID, uint(memberID)); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *OrganizationHandler) Invite(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var req models.InvitationDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	inv, err := h.service.Invite(userID, req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, inv)
+}
+
+func (h *OrganizationHandler) ListInvitations(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	invs, err := h.service.ListInvitations(userID)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, invs)
+}
+
+func (h *OrganizationHandler) AcceptInvitation(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	member, err := h.service.AcceptInvitation(userID, c.Param("token"))
+	if err != nil {
+		h.logger.Warn("AcceptInvitation failed", "user_id", userID, "error", err)
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+func (h *OrganizationHandler) ListMemberBookings(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var filter models.FilterOrgBookings
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bookings, err := h.service.ListMemberBookings(userID, filter)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, bookings)
+}
+
+func (h *OrganizationHandler) ListOrganizations(c *gin.Context) {
+	orgs, err := h.service.ListOrganizations()
+	if err != nil {
+		h.logger.Error("ListOrganizations failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить организации"})
+		return
+	}
+	c.JSON(http.StatusOK, orgs)
+}
+
+func (h *OrganizationHandler) UpdateBillingSettings(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID организации"})
+		return
+	}
+
+	var req models.BillingSettingsDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.service.UpdateBillingSettings(uint(id), req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "организация не найдена"})
+			return
+		}
+		h.logger.Error("UpdateBillingSettings failed", "organization_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось обновить режим оплаты"})
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+func (h *OrganizationHandler) writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "участник не найден"})
+	case errors.Is(err, service.ErrNotOrgMember):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrOrgForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvitationInvalid):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("organization request failed", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
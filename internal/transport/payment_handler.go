@@ -0,0 +1,163 @@
+package transport
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/payment"
+	"github.com/IslamCHup/coworking-manager-project/internal/service"
+)
+
+// maxPaymentWebhookBody ограничивает тело вебхука провайдера
+const maxPaymentWebhookBody = 1 << 20
+
+type PaymentHandler struct {
+	service service.PaymentService
+	logger  *slog.Logger
+}
+
+func NewPaymentHandler(service service.PaymentService, logger *slog.Logger) *PaymentHandler {
+	return &PaymentHandler{service: service, logger: logger}
+}
+
+func (h *PaymentHandler) RegisterRoutes(r *gin.Engine) {
+	r.POST("/payments/webhook", h.Webhook)
+}
+
+func (h *PaymentHandler) RegisterUserRoutes(users *gin.RouterGroup) {
+	users.POST("/me/topups", h.CreateTopup)
+	users.GET("/me/topups", h.ListTopups)
+	users.GET("/me/topups/:id", h.GetTopup)
+}
+
+func (h *PaymentHandler) RegisterAdminRoutes(admin *gin.RouterGroup) {
+	admin.POST("/payments/:id/refund", h.Refund)
+}
+
+// CreateTopup создает намерение пополнения. Клиент передает заголовок
+// Idempotency-Key, чтобы повтор запроса не создал второй платеж.
+func (h *PaymentHandler) CreateTopup(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var req models.TopupDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	intent, err := h.service.CreateTopup(c.Request.Context(), userID, req, c.GetHeader("Idempotency-Key"))
+	if err != nil {
+		if errors.Is(err, service.ErrPaymentsDisabled) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("CreateTopup failed", "user_id", userID, "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, intent)
+}
+
+func (h *PaymentHandler) ListTopups(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var filter models.FilterTransactions
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	intents, total, err := h.service.ListTopups(userID, filter)
+	if err != nil {
+		h.logger.Error("ListTopups failed", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить пополнения"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": intents, "total": total})
+}
+
+func (h *PaymentHandler) GetTopup(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID платежа"})
+		return
+	}
+
+	intent, err := h.service.GetTopup(userID, uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrPaymentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("GetTopup failed", "user_id", userID, "intent_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить платеж"})
+		return
+	}
+
+	c.JSON(http.StatusOK, intent)
+}
+
+// Webhook принимает уведомления провайдера. Ответ не 2xx заставит провайдера повторить доставку.
+func (h *PaymentHandler) Webhook(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPaymentWebhookBody))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "не удалось прочитать тело запроса"})
+		return
+	}
+
+	if err := h.service.HandleWebhook(c.Request.Context(), c.Request.Header, body); err != nil {
+		switch {
+		case errors.Is(err, payment.ErrInvalidSignature):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrPaymentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrPaymentsDisabled):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("payment webhook failed", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось обработать уведомление"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+func (h *PaymentHandler) Refund(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID платежа"})
+		return
+	}
+
+	intent, err := h.service.Refund(c.Request.Context(), c.GetUint("admin_id"), uint(id))
+	if err != nil {
+		h.logger.Warn("Refund failed", "intent_id", id, "error", err)
+		if errors.Is(err, service.ErrPaymentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrPaymentsDisabled) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrRefundNotConfirmed) {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, intent)
+}
@@ -1,6 +1,7 @@
 package transport
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -13,11 +14,12 @@ import (
 
 type PlaceHandler struct {
 	service service.PlaceService
+	media   service.PlaceMediaService
 	logger  *slog.Logger
 }
 
-func NewPlaceHandler(s service.PlaceService, logger *slog.Logger) *PlaceHandler {
-	return &PlaceHandler{service: s, logger: logger}
+func NewPlaceHandler(s service.PlaceService, media service.PlaceMediaService, logger *slog.Logger) *PlaceHandler {
+	return &PlaceHandler{service: s, media: media, logger: logger}
 }
 
 func (h *PlaceHandler) RegisterRoutes(r *gin.Engine) {
@@ -25,6 +27,7 @@ func (h *PlaceHandler) RegisterRoutes(r *gin.Engine) {
 	{
 		places.GET("/", h.ListPlaces)
 		places.GET("/free", h.ListFreePlaces)
+		places.GET("/search", h.Search)
 		places.GET(":id", h.GetByID)
 	}
 }
@@ -61,6 +64,13 @@ func (h *PlaceHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	place.Gallery, err = h.media.Gallery(place.ID)
+	if err != nil {
+		h.logger.Error("place gallery failed", "error", err, "id", id64)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось загрузить фотографии места"})
+		return
+	}
+
 	c.JSON(http.StatusOK, place)
 }
 
@@ -79,4 +89,169 @@ func (h *PlaceHandler) ListFreePlaces(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, places)
-}
\ No newline at end of file
+}
+
+// Search — полнотекстовый поиск с учетом опечаток; фильтры по цене и
+// свободному интервалу можно комбинировать с запросом
+func (h *PlaceHandler) Search(c *gin.Context) {
+	var q models.FilterPlaceSearch
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hits, total, err := h.service.Search(&q)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPlaceSearch) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("SearchPlaces failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось выполнить поиск"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": hits, "total": total})
+}
+
+func (h *PlaceHandler) RegisterAdminRoutes(admin *gin.RouterGroup) {
+	admin.POST("/places", h.Create)
+	admin.PATCH("/places/:id", h.Update)
+	admin.POST("/places/:id/deactivate", h.Deactivate)
+	admin.DELETE("/places/:id", h.Delete)
+}
+
+func (h *PlaceHandler) Create(c *gin.Context) {
+	var req models.PlaceCreateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	place, err := h.service.Create(c.GetUint("admin_id"), req)
+	if err != nil {
+		h.writeError(c, 0, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, place)
+}
+
+// Update с is_active=false и параметром cascade=true отменяет будущие брони места,
+// а с relocate=true переносит их на равноценные места
+func (h *PlaceHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID места"})
+		return
+	}
+
+	var req models.PlaceUpdateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	place, result, err := h.service.Update(c.GetUint("admin_id"), uint(id), req, removalMode(c))
+	if err != nil && !errors.Is(err, service.ErrBookingsNotSettled) {
+		h.writeError(c, uint(id), err)
+		return
+	}
+
+	if result != nil {
+		resp := gin.H{"place": place, "cancelled_bookings": result.CancelledBookings, "refunded": result.Refunded}
+		if result.RelocatedBookings != nil {
+			resp["relocated_bookings"] = result.RelocatedBookings
+			resp["queued_bookings"] = result.QueuedBookings
+		}
+		if err != nil {
+			resp["failed_bookings"] = result.FailedBookings
+			resp["error"] = err.Error()
+			c.JSON(http.StatusConflict, resp)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"place": place})
+}
+
+func (h *PlaceHandler) Deactivate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID места"})
+		return
+	}
+
+	result, err := h.service.Deactivate(c.GetUint("admin_id"), uint(id), removalMode(c))
+	if err != nil {
+		h.writeRemovalError(c, uint(id), result, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *PlaceHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID места"})
+		return
+	}
+
+	result, err := h.service.Delete(c.GetUint("admin_id"), uint(id), removalMode(c))
+	if err != nil {
+		h.writeRemovalError(c, uint(id), result, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *PlaceHandler) writeError(c *gin.Context, id uint, err error) {
+	switch {
+	case errors.Is(err, service.ErrPlaceNotFound),
+		errors.Is(err, service.ErrLocationNotFound),
+		errors.Is(err, service.ErrFloorNotFound),
+		errors.Is(err, service.ErrZoneNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrLocationForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidPlacement):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPlaceHasFutureBookings):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   err.Error(),
+			"details": "отмените брони или повторите запрос с cascade=true, чтобы отменить их с возвратом денег, или с relocate=true, чтобы перенести их на другие места",
+		})
+	default:
+		h.logger.Error("admin place request failed", "place_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// writeRemovalError при частичной отмене броней отдает вместе с ошибкой
+// итог: что отменено, что нет. Место к этому моменту уже отключено.
+func (h *PlaceHandler) writeRemovalError(c *gin.Context, id uint, result *models.PlaceRemovalResult, err error) {
+	if !errors.Is(err, service.ErrBookingsNotSettled) || result == nil {
+		h.writeError(c, id, err)
+		return
+	}
+	h.logger.Warn("place bookings partially cancelled", "place_id", id, "failed", len(result.FailedBookings))
+	c.JSON(http.StatusConflict, gin.H{
+		"error":   err.Error(),
+		"details": "место отключено; повторите запрос с cascade=true, чтобы отменить оставшиеся брони",
+		"result":  result,
+	})
+}
+
+// removalMode читает из запроса, что делать с будущими бронями места
+func removalMode(c *gin.Context) models.PlaceRemovalMode {
+	switch {
+	case c.Query("relocate") == "true":
+		return models.RemovalRelocate
+	case c.Query("cascade") == "true":
+		return models.RemovalCascade
+	}
+	return models.RemovalRefuse
+}
@@ -0,0 +1,185 @@
+package transport
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/service"
+)
+
+type PlaceMediaHandler struct {
+	service service.PlaceMediaService
+	logger  *slog.Logger
+}
+
+func NewPlaceMediaHandler(service service.PlaceMediaService, logger *slog.Logger) *PlaceMediaHandler {
+	return &PlaceMediaHandler{service: service, logger: logger}
+}
+
+func (h *PlaceMediaHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/media/*key", h.Serve)
+}
+
+func (h *PlaceMediaHandler) RegisterAdminRoutes(admin *gin.RouterGroup) {
+	admin.GET("/places/:id/images", h.List)
+	admin.POST("/places/:id/images", h.Upload)
+	admin.PUT("/places/:id/images/order", h.Reorder)
+	admin.PATCH("/places/:id/images/:image_id", h.Update)
+	admin.DELETE("/places/:id/images/:image_id", h.Delete)
+}
+
+// Serve отдает файл по подписанной ссылке из галереи
+func (h *PlaceMediaHandler) Serve(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	rc, contentType, err := h.service.Open(key, c.Query("expires"), c.Query("sig"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	defer rc.Close()
+
+	// ключи уникальны и не переиспользуются, поэтому файл можно кешировать до конца жизни ссылки
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.DataFromReader(http.StatusOK, -1, contentType, rc, nil)
+}
+
+func (h *PlaceMediaHandler) List(c *gin.Context) {
+	placeID, ok := parseID(c, "неверный ID места")
+	if !ok {
+		return
+	}
+
+	gallery, err := h.service.Gallery(placeID)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": gallery, "total": len(gallery)})
+}
+
+// Upload принимает multipart-форму с файлом image и необязательной подписью caption
+func (h *PlaceMediaHandler) Upload(c *gin.Context) {
+	placeID, ok := parseID(c, "неверный ID места")
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "файл фотографии не передан"})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "не удалось прочитать файл"})
+		return
+	}
+	defer f.Close()
+
+	// читаем на байт больше лимита, чтобы сервис мог отклонить слишком большой файл
+	data, err := io.ReadAll(io.LimitReader(f, service.MaxPlaceImageSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "не удалось прочитать файл"})
+		return
+	}
+
+	image, err := h.service.Upload(c.GetUint("admin_id"), placeID, data, c.PostForm("caption"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, image)
+}
+
+func (h *PlaceMediaHandler) Update(c *gin.Context) {
+	placeID, imageID, ok := h.imageIDs(c)
+	if !ok {
+		return
+	}
+
+	var req models.PlaceImageUpdateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	image, err := h.service.UpdateImage(c.GetUint("admin_id"), placeID, imageID, req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, image)
+}
+
+func (h *PlaceMediaHandler) Reorder(c *gin.Context) {
+	placeID, ok := parseID(c, "неверный ID места")
+	if !ok {
+		return
+	}
+
+	var req models.PlaceImageOrderDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	gallery, err := h.service.Reorder(c.GetUint("admin_id"), placeID, req.ImageIDs)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": gallery, "total": len(gallery)})
+}
+
+func (h *PlaceMediaHandler) Delete(c *gin.Context) {
+	placeID, imageID, ok := h.imageIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteImage(c.GetUint("admin_id"), placeID, imageID); err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "фотография удалена"})
+}
+
+func (h *PlaceMediaHandler) imageIDs(c *gin.Context) (uint, uint, bool) {
+	placeID, ok := parseID(c, "неверный ID места")
+	if !ok {
+		return 0, 0, false
+	}
+	imageID, err := strconv.ParseUint(c.Param("image_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID фотографии"})
+		return 0, 0, false
+	}
+	return placeID, uint(imageID), true
+}
+
+func (h *PlaceMediaHandler) writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPlaceNotFound),
+		errors.Is(err, service.ErrPlaceImageNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrLocationForbidden),
+		errors.Is(err, service.ErrInvalidMediaLink):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidPlaceImage),
+		errors.Is(err, service.ErrInvalidImageOrder):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTooManyPlaceImages):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("place media request failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось выполнить запрос"})
+	}
+}
@@ -0,0 +1,138 @@
+package transport
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/service"
+)
+
+type PricingHandler struct {
+	service service.PricingService
+	logger  *slog.Logger
+}
+
+func NewPricingHandler(service service.PricingService, logger *slog.Logger) *PricingHandler {
+	return &PricingHandler{service: service, logger: logger}
+}
+
+func (h *PricingHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/places/:id/quote", h.Quote)
+}
+
+func (h *PricingHandler) RegisterAdminRoutes(admin *gin.RouterGroup) {
+	admin.GET("/price-rules", h.ListRules)
+	admin.POST("/price-rules", h.CreateRule)
+	admin.PUT("/price-rules/:id", h.UpdateRule)
+	admin.DELETE("/price-rules/:id", h.DeleteRule)
+}
+
+// Quote считает стоимость без создания брони
+func (h *PricingHandler) Quote(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID места"})
+		return
+	}
+
+	var req models.QuoteRequestDTO
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quote, err := h.service.Quote(uint(id), req)
+	if err != nil {
+		h.logger.Warn("Quote failed", "place_id", id, "error", err)
+		if errors.Is(err, service.ErrPlaceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "место не найдено"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+func (h *PricingHandler) ListRules(c *gin.Context) {
+	rules, err := h.service.ListRules()
+	if err != nil {
+		h.logger.Error("ListRules failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить правила цен"})
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+func (h *PricingHandler) CreateRule(c *gin.Context) {
+	var req models.PriceRuleDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("CreateRule invalid body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.service.CreateRule(req)
+	if err != nil {
+		h.logger.Warn("CreateRule failed", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+func (h *PricingHandler) UpdateRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID правила"})
+		return
+	}
+
+	var req models.PriceRuleDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("UpdateRule invalid body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.service.UpdateRule(uint(id), req)
+	if err != nil {
+		h.logger.Warn("UpdateRule failed", "rule_id", id, "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "правило не найдено"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *PricingHandler) DeleteRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID правила"})
+		return
+	}
+
+	if err := h.service.DeleteRule(uint(id)); err != nil {
+		h.logger.Error("DeleteRule failed", "rule_id", id, "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "правило не найдено"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось удалить правило"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "правило удалено"})
+}
@@ -0,0 +1,106 @@
+package transport
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/service"
+)
+
+type PromoHandler struct {
+	service service.PromoService
+	logger  *slog.Logger
+}
+
+func NewPromoHandler(service service.PromoService, logger *slog.Logger) *PromoHandler {
+	return &PromoHandler{service: service, logger: logger}
+}
+
+func (h *PromoHandler) RegisterAdminRoutes(admin *gin.RouterGroup) {
+	admin.GET("/promo-codes", h.ListPromos)
+	admin.POST("/promo-codes", h.CreatePromo)
+	admin.PUT("/promo-codes/:id", h.UpdatePromo)
+	admin.DELETE("/promo-codes/:id", h.DeletePromo)
+}
+
+func (h *PromoHandler) ListPromos(c *gin.Context) {
+	promos, err := h.service.ListPromos()
+	if err != nil {
+		h.logger.Error("ListPromos failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить промокоды"})
+		return
+	}
+	c.JSON(http.StatusOK, promos)
+}
+
+func (h *PromoHandler) CreatePromo(c *gin.Context) {
+	var req models.PromoCodeDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("CreatePromo invalid body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promo, err := h.service.CreatePromo(req)
+	if err != nil {
+		h.logger.Warn("CreatePromo failed", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, promo)
+}
+
+func (h *PromoHandler) UpdatePromo(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID промокода"})
+		return
+	}
+
+	var req models.PromoCodeDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("UpdatePromo invalid body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promo, err := h.service.UpdatePromo(uint(id), req)
+	if err != nil {
+		h.logger.Warn("UpdatePromo failed", "promo_id", id, "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "промокод не найден"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, promo)
+}
+
+func (h *PromoHandler) DeletePromo(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID промокода"})
+		return
+	}
+
+	if err := h.service.DeletePromo(uint(id)); err != nil {
+		h.logger.Error("DeletePromo failed", "promo_id", id, "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "промокод не найден"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось удалить промокод"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "промокод удален"})
+}
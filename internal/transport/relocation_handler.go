@@ -0,0 +1,127 @@
+package transport
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/service"
+)
+
+type RelocationHandler struct {
+	service service.RelocationService
+	logger  *slog.Logger
+}
+
+func NewRelocationHandler(service service.RelocationService, logger *slog.Logger) *RelocationHandler {
+	return &RelocationHandler{service: service, logger: logger}
+}
+
+func (h *RelocationHandler) RegisterAdminRoutes(admin *gin.RouterGroup) {
+	admin.GET("/relocations", h.List)
+	admin.POST("/relocations/:id/assign", h.Assign)
+	admin.POST("/relocations/:id/cancel", h.Cancel)
+	admin.POST("/maintenance/:id/bookings/relocate", h.RelocateMaintenance)
+}
+
+// List — очередь переносов; по умолчанию в ответ попадают все статусы
+func (h *RelocationHandler) List(c *gin.Context) {
+	var q models.FilterRelocation
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, total, err := h.service.ListQueue(c.GetUint("admin_id"), &q)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "total": total})
+}
+
+func (h *RelocationHandler) Assign(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID переноса")
+	if !ok {
+		return
+	}
+
+	var req models.RelocationAssignDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	relocation, err := h.service.Assign(c.GetUint("admin_id"), id, req.PlaceID)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, relocation)
+}
+
+func (h *RelocationHandler) Cancel(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID переноса")
+	if !ok {
+		return
+	}
+
+	relocation, err := h.service.Cancel(c.GetUint("admin_id"), id)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, relocation)
+}
+
+// RelocateMaintenance переносит брони, попавшие в окно обслуживания;
+// без тела запроса — все затронутые брони
+func (h *RelocationHandler) RelocateMaintenance(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID окна обслуживания")
+	if !ok {
+		return
+	}
+
+	var req models.MaintenanceBookingsDTO
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := h.service.RelocateMaintenance(c.GetUint("admin_id"), id, req.BookingIDs)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *RelocationHandler) writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrRelocationNotFound),
+		errors.Is(err, service.ErrMaintenanceNotFound),
+		errors.Is(err, service.ErrPlaceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrLocationForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInsufficientFunds),
+		errors.Is(err, service.ErrCreditLimitExceeded):
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrRelocationResolved),
+		errors.Is(err, service.ErrPlaceOccupied),
+		errors.Is(err, service.ErrPlaceUnderMaintenance),
+		errors.Is(err, service.ErrNoEquivalentPlace):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrRelocationNotAllowed),
+		errors.Is(err, service.ErrBookingNotInMaintenance):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("relocation request failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось выполнить запрос"})
+	}
+}
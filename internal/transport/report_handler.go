@@ -0,0 +1,190 @@
+package transport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/service"
+)
+
+type ReportHandler struct {
+	service   service.ReportService
+	locations service.LocationService
+	logger    *slog.Logger
+}
+
+func NewReportHandler(service service.ReportService, locations service.LocationService, logger *slog.Logger) *ReportHandler {
+	return &ReportHandler{service: service, locations: locations, logger: logger}
+}
+
+func (h *ReportHandler) RegisterAdminRoutes(admin *gin.RouterGroup) {
+	admin.GET("/reports/revenue", h.Revenue)
+	admin.GET("/reports/occupancy", h.Occupancy)
+}
+
+func (h *ReportHandler) Revenue(c *gin.Context) {
+	var filter models.FilterReport
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.scope(c, &filter) {
+		return
+	}
+
+	report, err := h.service.Revenue(filter)
+	if err != nil {
+		h.writeError(c, "RevenueReport", err)
+		return
+	}
+
+	if filter.Format != "csv" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	header := []string{"period", "place_id", "place_name", "place_type", "bookings", "gross", "refunds", "net"}
+	if filter.Compare {
+		header = append(header, "previous_net", "net_change")
+	}
+	records := [][]string{header}
+	for _, row := range report.Items {
+		record := []string{
+			row.Period.Format("2006-01-02"),
+			optionalUint(row.PlaceID),
+			row.PlaceName,
+			string(row.PlaceType),
+			strconv.FormatInt(row.Bookings, 10),
+			strconv.Itoa(row.Gross),
+			strconv.Itoa(row.Refunds),
+			strconv.Itoa(row.Net),
+		}
+		if filter.Compare {
+			record = append(record, optionalInt(row.PreviousNet), optionalFloat(row.NetChange))
+		}
+		records = append(records, record)
+	}
+
+	h.writeCSV(c, "revenue", report.From, report.To, records)
+}
+
+func (h *ReportHandler) Occupancy(c *gin.Context) {
+	var filter models.FilterReport
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.scope(c, &filter) {
+		return
+	}
+
+	report, err := h.service.Occupancy(filter)
+	if err != nil {
+		h.writeError(c, "OccupancyReport", err)
+		return
+	}
+
+	if filter.Format != "csv" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	header := []string{"period", "place_id", "place_name", "place_type", "bookings", "booked_hours", "open_hours", "rate"}
+	if filter.Compare {
+		header = append(header, "previous_rate", "rate_change")
+	}
+	records := [][]string{header}
+	for _, row := range report.Items {
+		record := []string{
+			row.Period.Format("2006-01-02"),
+			optionalUint(row.PlaceID),
+			row.PlaceName,
+			string(row.PlaceType),
+			strconv.FormatInt(row.Bookings, 10),
+			strconv.FormatFloat(row.BookedHours, 'f', 2, 64),
+			strconv.FormatFloat(row.OpenHours, 'f', 2, 64),
+			strconv.FormatFloat(row.Rate, 'f', 2, 64),
+		}
+		if filter.Compare {
+			record = append(record, optionalFloat(row.PreviousRate), optionalFloat(row.RateChange))
+		}
+		records = append(records, record)
+	}
+
+	h.writeCSV(c, "occupancy", report.From, report.To, records)
+}
+
+// scope ограничивает отчет площадками администратора
+func (h *ReportHandler) scope(c *gin.Context, filter *models.FilterReport) bool {
+	adminID := c.GetUint("admin_id")
+	if filter.LocationID != nil {
+		if err := h.locations.CheckLocation(adminID, *filter.LocationID); err != nil {
+			h.writeError(c, "ReportScope", err)
+			return false
+		}
+	}
+
+	scope, err := h.locations.Scope(adminID)
+	if err != nil {
+		h.writeError(c, "ReportScope", err)
+		return false
+	}
+	filter.LocationIDs = scope
+	return true
+}
+
+func (h *ReportHandler) writeCSV(c *gin.Context, name string, from, to time.Time, records [][]string) {
+	filename := fmt.Sprintf("%s_%s_%s.csv", name, from.Format("20060102"), to.Format("20060102"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	if err := w.WriteAll(records); err != nil {
+		h.logger.Error("report csv write failed", "report", name, "error", err)
+	}
+}
+
+func (h *ReportHandler) writeError(c *gin.Context, op string, err error) {
+	if errors.Is(err, service.ErrReportRange) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrLocationForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	h.logger.Error(op+" failed", "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось построить отчет"})
+}
+
+func optionalUint(v *uint) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*v), 10)
+}
+
+func optionalInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+func optionalFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', 2, 64)
+}
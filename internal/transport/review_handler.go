@@ -1,6 +1,7 @@
 package transport
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -19,14 +20,34 @@ func NewReviewHandler(review service.ReviewService, logger *slog.Logger) *Review
 	return &ReviewHandler{review: review, logger: logger}
 }
 
-func (h *ReviewHandler) RegisterRoutesReview(r *gin.Engine) {
-	review := r.Group("/review")
-	{
-		review.POST("/", h.CreateReview)
-		review.GET("/:id", h.GetByID)
-		review.PUT("/:id", h.UpdateReview)
-		review.DELETE("/:id", h.DeleteReview)
-	}
+// RegisterRoutes — публичные маршруты: показываются только одобренные отзывы,
+// автор видит свой отзыв в любом статусе
+func (h *ReviewHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/reviews/:id", h.GetByID)
+	r.GET("/places/:id/reviews", h.ListPlaceReviews)
+}
+
+// RegisterProtectedRoutes ожидает группу /reviews с обязательной авторизацией
+func (h *ReviewHandler) RegisterProtectedRoutes(reviews *gin.RouterGroup) {
+	reviews.POST("/", h.CreateReview)
+	reviews.PUT("/:id", h.UpdateReview)
+	reviews.DELETE("/:id", h.DeleteReview)
+	reviews.POST("/:id/helpful", h.MarkHelpful)
+	reviews.DELETE("/:id/helpful", h.UnmarkHelpful)
+	reviews.POST("/:id/report", h.Report)
+}
+
+func (h *ReviewHandler) RegisterUserRoutes(users *gin.RouterGroup) {
+	users.GET("/me/reviews", h.ListMine)
+}
+
+func (h *ReviewHandler) RegisterAdminRoutes(admin *gin.RouterGroup) {
+	admin.GET("/reviews", h.ModerationQueue)
+	admin.POST("/reviews/:id/approve", h.Approve)
+	admin.POST("/reviews/:id/reject", h.Reject)
+	admin.PUT("/reviews/:id/reply", h.Reply)
+	admin.DELETE("/reviews/:id/reply", h.DeleteReply)
+	admin.GET("/reviews/:id/reports", h.ListReports)
 }
 
 func (h *ReviewHandler) CreateReview(c *gin.Context) {
@@ -64,6 +85,14 @@ func (h *ReviewHandler) CreateReview(c *gin.Context) {
 	req.UserID = userIDUint
 
 	createdReview, err := h.review.CreateReview(&req)
+	if errors.Is(err, service.ErrReviewExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "review_id": createdReview.ID})
+		return
+	}
+	if errors.Is(err, service.ErrNoCompletedStay) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		h.logger.Error("failed to create review", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -92,6 +121,12 @@ func (h *ReviewHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	// маршрут публичный: неодобренные отзывы автор видит в /users/me/reviews
+	if review.Status != models.ReviewApproved {
+		c.JSON(http.StatusNotFound, gin.H{"error": "отзыв не найден"})
+		return
+	}
+
 	c.JSON(http.StatusOK, review)
 }
 
@@ -198,4 +233,208 @@ func (h *ReviewHandler) DeleteReview(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "отзыв успешно удален",
 	})
-}
\ No newline at end of file
+}
+
+// ListPlaceReviews — публичный список одобренных отзывов места с рейтингом
+func (h *ReviewHandler) ListPlaceReviews(c *gin.Context) {
+	placeID, ok := parseID(c, "неверный ID места")
+	if !ok {
+		return
+	}
+
+	var q models.FilterPlaceReviews
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reviews, total, rating, err := h.review.ListPlaceReviews(placeID, &q)
+	if err != nil {
+		if errors.Is(err, service.ErrPlaceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "место не найдено"})
+			return
+		}
+		h.logger.Error("failed to list place reviews", "place_id", placeID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить отзывы"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": reviews, "total": total, "rating": rating})
+}
+
+// ListMine — отзывы текущего пользователя со статусом модерации и причиной отказа
+func (h *ReviewHandler) ListMine(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	reviews, err := h.review.ListUserReviews(userID)
+	if err != nil {
+		h.logger.Error("failed to list user reviews", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить отзывы"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": reviews, "total": len(reviews)})
+}
+
+// ModerationQueue — по умолчанию ожидающие модерации отзывы, старые первыми
+func (h *ReviewHandler) ModerationQueue(c *gin.Context) {
+	var q models.FilterReviews
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reviews, total, err := h.review.ListModerationQueue(c.GetUint("admin_id"), &q)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": reviews, "total": total})
+}
+
+func (h *ReviewHandler) Approve(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID отзыва")
+	if !ok {
+		return
+	}
+
+	review, err := h.review.Approve(c.GetUint("admin_id"), id)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
+
+func (h *ReviewHandler) Reject(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID отзыва")
+	if !ok {
+		return
+	}
+
+	var req models.ReviewRejectDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	review, err := h.review.Reject(c.GetUint("admin_id"), id, req.Reason)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
+
+// Reply публикует или заменяет ответ администрации под отзывом
+func (h *ReviewHandler) Reply(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID отзыва")
+	if !ok {
+		return
+	}
+
+	var req models.ReviewReplyDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	review, err := h.review.Reply(c.GetUint("admin_id"), id, req.Text)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
+
+func (h *ReviewHandler) DeleteReply(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID отзыва")
+	if !ok {
+		return
+	}
+
+	review, err := h.review.Reply(c.GetUint("admin_id"), id, "")
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
+
+func (h *ReviewHandler) ListReports(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID отзыва")
+	if !ok {
+		return
+	}
+
+	reports, err := h.review.ListReports(c.GetUint("admin_id"), id)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": reports, "total": len(reports)})
+}
+
+func (h *ReviewHandler) MarkHelpful(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID отзыва")
+	if !ok {
+		return
+	}
+
+	review, err := h.review.MarkHelpful(c.MustGet("user_id").(uint), id)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"review_id": review.ID, "helpful_count": review.HelpfulCount})
+}
+
+func (h *ReviewHandler) UnmarkHelpful(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID отзыва")
+	if !ok {
+		return
+	}
+
+	review, err := h.review.UnmarkHelpful(c.MustGet("user_id").(uint), id)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"review_id": review.ID, "helpful_count": review.HelpfulCount})
+}
+
+// Report принимает жалобу; автору жалобы не сообщается, снят ли отзыв с публикации
+func (h *ReviewHandler) Report(c *gin.Context) {
+	id, ok := parseID(c, "неверный ID отзыва")
+	if !ok {
+		return
+	}
+
+	var req models.ReviewReportDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.review.Report(c.MustGet("user_id").(uint), id, req.Reason); err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "жалоба отправлена на рассмотрение"})
+}
+
+func (h *ReviewHandler) writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrReviewNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "отзыв не найден"})
+	case errors.Is(err, service.ErrLocationForbidden),
+		errors.Is(err, service.ErrOwnReview):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAlreadyReported):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrRejectionReasonRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("review request failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось выполнить запрос"})
+	}
+}
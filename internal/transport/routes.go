@@ -18,12 +18,43 @@ func RegisterRoutes(
 	authService service.AuthService,
 	refreshService service.RefreshService,
 	reviewService service.ReviewService,
+	notificationService service.NotificationService,
+	webhookService service.WebhookService,
+	availabilityService service.AvailabilityService,
+	pricingService service.PricingService,
+	promoService service.PromoService,
+	subscriptionService service.SubscriptionService,
+	ledgerService service.LedgerService,
+	paymentService service.PaymentService,
+	invoiceService service.InvoiceService,
+	organizationService service.OrganizationService,
+	adjustmentService service.AdjustmentService,
+	reportService service.ReportService,
+	locationService service.LocationService,
+	floorPlanService service.FloorPlanService,
+	placeMediaService service.PlaceMediaService,
+	maintenanceService service.MaintenanceService,
+	relocationService service.RelocationService,
 ) {
 	bookingHandler := NewBookingHandler(bookingService, logger)
 	bookingHandler.RegisterRoutes(router)
 
-	placeHandler := NewPlaceHandler(placeService, logger)
+	placeHandler := NewPlaceHandler(placeService, placeMediaService, logger)
 	placeHandler.RegisterRoutes(router)
+	placeMediaHandler := NewPlaceMediaHandler(placeMediaService, logger)
+	placeMediaHandler.RegisterRoutes(router)
+	locationHandler := NewLocationHandler(locationService, logger)
+	locationHandler.RegisterRoutes(router)
+	floorPlanHandler := NewFloorPlanHandler(floorPlanService, logger)
+	floorPlanHandler.RegisterRoutes(router)
+	availabilityHandler := NewAvailabilityHandler(availabilityService, logger)
+	availabilityHandler.RegisterRoutes(router)
+	pricingHandler := NewPricingHandler(pricingService, logger)
+	pricingHandler.RegisterRoutes(router)
+	subscriptionHandler := NewSubscriptionHandler(subscriptionService, logger)
+	subscriptionHandler.RegisterRoutes(router)
+	paymentHandler := NewPaymentHandler(paymentService, logger)
+	paymentHandler.RegisterRoutes(router)
 
 	authHandler := NewAuthHandler(authService, refreshService, logger)
 	authHandler.RegisterRoutes(router)
@@ -31,17 +62,54 @@ func RegisterRoutes(
 	refreshHandler.RegisterRoutes(router)
 	userHandler := NewUserHandler(userService, logger)
 
-	adminHandler := NewAdminHandler(userService, bookingService, logger)
-	adminHandler.RegisterRoutes(router, adminService)
+	adminHandler := NewAdminHandler(userService, bookingService, locationService, logger)
+	admin := adminHandler.RegisterRoutes(router, adminService)
+
+	webhookHandler := NewWebhookHandler(webhookService, logger)
+	webhookHandler.RegisterRoutes(admin)
+	pricingHandler.RegisterAdminRoutes(admin)
+	placeHandler.RegisterAdminRoutes(admin)
+	locationHandler.RegisterAdminRoutes(admin)
+	floorPlanHandler.RegisterAdminRoutes(admin)
+	placeMediaHandler.RegisterAdminRoutes(admin)
+	maintenanceHandler := NewMaintenanceHandler(maintenanceService, logger)
+	maintenanceHandler.RegisterAdminRoutes(admin)
+	relocationHandler := NewRelocationHandler(relocationService, logger)
+	relocationHandler.RegisterAdminRoutes(admin)
+	promoHandler := NewPromoHandler(promoService, logger)
+	promoHandler.RegisterAdminRoutes(admin)
+	subscriptionHandler.RegisterAdminRoutes(admin)
+	paymentHandler.RegisterAdminRoutes(admin)
+	invoiceHandler := NewInvoiceHandler(invoiceService, organizationService, logger)
+	invoiceHandler.RegisterAdminRoutes(admin)
+	organizationHandler := NewOrganizationHandler(organizationService, logger)
+	organizationHandler.RegisterAdminRoutes(admin)
+	adjustmentHandler := NewAdjustmentHandler(adjustmentService, logger)
+	adjustmentHandler.RegisterAdminRoutes(admin)
+	reportHandler := NewReportHandler(reportService, locationService, logger)
+	reportHandler.RegisterAdminRoutes(admin)
 
 	reviewHandler := NewReviewHandler(reviewService, logger)
+	reviewHandler.RegisterRoutes(router)
+	reviewHandler.RegisterAdminRoutes(admin)
+	notificationHandler := NewNotificationHandler(notificationService, logger)
+	ledgerHandler := NewLedgerHandler(ledgerService, logger)
 
 	protected := router.Group("/")
 	protected.Use(middleware.RequireAuthMiddleware())
 
 	users := protected.Group("/users")
 	userHandler.RegisterRoutes(users)
+	notificationHandler.RegisterRoutes(users)
+	subscriptionHandler.RegisterUserRoutes(users)
+	ledgerHandler.RegisterRoutes(users)
+	paymentHandler.RegisterUserRoutes(users)
+	invoiceHandler.RegisterUserRoutes(users)
+	reviewHandler.RegisterUserRoutes(users)
+
+	organizationHandler.RegisterRoutes(protected)
+	invoiceHandler.RegisterOrganizationRoutes(protected)
 
 	reviews := protected.Group("/reviews")
-	reviews.POST("/", reviewHandler.CreateReview)
+	reviewHandler.RegisterProtectedRoutes(reviews)
 }
@@ -0,0 +1,206 @@
+package transport
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/service"
+)
+
+type SubscriptionHandler struct {
+	service service.SubscriptionService
+	logger  *slog.Logger
+}
+
+func NewSubscriptionHandler(service service.SubscriptionService, logger *slog.Logger) *SubscriptionHandler {
+	return &SubscriptionHandler{service: service, logger: logger}
+}
+
+func (h *SubscriptionHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/plans", h.ListPlans)
+}
+
+func (h *SubscriptionHandler) RegisterUserRoutes(users *gin.RouterGroup) {
+	users.GET("/me/subscription", h.GetSubscription)
+	users.POST("/me/subscription", h.Subscribe)
+	users.PUT("/me/subscription", h.ChangePlan)
+	users.DELETE("/me/subscription", h.Cancel)
+}
+
+func (h *SubscriptionHandler) RegisterAdminRoutes(admin *gin.RouterGroup) {
+	admin.GET("/plans", h.ListAllPlans)
+	admin.POST("/plans", h.CreatePlan)
+	admin.PUT("/plans/:id", h.UpdatePlan)
+	admin.DELETE("/plans/:id", h.DeletePlan)
+}
+
+func (h *SubscriptionHandler) ListPlans(c *gin.Context) {
+	plans, err := h.service.ListPlans()
+	if err != nil {
+		h.logger.Error("ListPlans failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить планы"})
+		return
+	}
+	c.JSON(http.StatusOK, plans)
+}
+
+func (h *SubscriptionHandler) GetSubscription(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	sub, err := h.service.GetSubscription(userID)
+	if err != nil {
+		if errors.Is(err, service.ErrNoSubscription) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("GetSubscription failed", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить абонемент"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+func (h *SubscriptionHandler) Subscribe(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var req models.SubscribeDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub, err := h.service.Subscribe(userID, req.PlanID)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	h.logger.Info("Subscribe success", "user_id", userID, "plan_id", req.PlanID)
+	c.JSON(http.StatusCreated, sub)
+}
+
+func (h *SubscriptionHandler) ChangePlan(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var req models.SubscribeDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub, err := h.service.ChangePlan(userID, req.PlanID)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	h.logger.Info("ChangePlan success", "user_id", userID, "plan_id", req.PlanID)
+	c.JSON(http.StatusOK, sub)
+}
+
+func (h *SubscriptionHandler) Cancel(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	if err := h.service.Cancel(userID); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "автопродление отключено, абонемент действует до конца периода"})
+}
+
+func (h *SubscriptionHandler) writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "план не найден"})
+	case errors.Is(err, service.ErrNoSubscription):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInsufficientFunds):
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *SubscriptionHandler) ListAllPlans(c *gin.Context) {
+	plans, err := h.service.ListAllPlans()
+	if err != nil {
+		h.logger.Error("ListAllPlans failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить планы"})
+		return
+	}
+	c.JSON(http.StatusOK, plans)
+}
+
+func (h *SubscriptionHandler) CreatePlan(c *gin.Context) {
+	var req models.PlanDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("CreatePlan invalid body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.service.CreatePlan(req)
+	if err != nil {
+		h.logger.Error("CreatePlan failed", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+func (h *SubscriptionHandler) UpdatePlan(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID плана"})
+		return
+	}
+
+	var req models.PlanDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("UpdatePlan invalid body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.service.UpdatePlan(uint(id), req)
+	if err != nil {
+		h.logger.Warn("UpdatePlan failed", "plan_id", id, "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "план не найден"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+func (h *SubscriptionHandler) DeletePlan(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID плана"})
+		return
+	}
+
+	if err := h.service.DeletePlan(uint(id)); err != nil {
+		h.logger.Error("DeletePlan failed", "plan_id", id, "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "план не найден"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось удалить план"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "план удален"})
+}
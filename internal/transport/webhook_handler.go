@@ -0,0 +1,168 @@
+package transport
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/IslamCHup/coworking-manager-project/internal/models"
+	"github.com/IslamCHup/coworking-manager-project/internal/service"
+)
+
+type WebhookHandler struct {
+	service service.WebhookService
+	logger  *slog.Logger
+}
+
+func NewWebhookHandler(service service.WebhookService, logger *slog.Logger) *WebhookHandler {
+	return &WebhookHandler{service: service, logger: logger}
+}
+
+// RegisterRoutes вешает маршруты на группу /admin
+func (h *WebhookHandler) RegisterRoutes(admin *gin.RouterGroup) {
+	admin.GET("/webhooks", h.ListSubscriptions)
+	admin.POST("/webhooks", h.CreateSubscription)
+	admin.PUT("/webhooks/:id", h.UpdateSubscription)
+	admin.DELETE("/webhooks/:id", h.DeleteSubscription)
+	admin.GET("/webhooks/:id/deliveries", h.ListDeliveries)
+	admin.POST("/webhooks/deliveries/:id/redeliver", h.Redeliver)
+}
+
+func (h *WebhookHandler) ListSubscriptions(c *gin.Context) {
+	subs, err := h.service.ListSubscriptions()
+	if err != nil {
+		h.logger.Error("ListSubscriptions failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить подписки"})
+		return
+	}
+	c.JSON(http.StatusOK, subs)
+}
+
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	var req models.WebhookSubscriptionDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("CreateSubscription invalid body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub, err := h.service.CreateSubscription(req)
+	if errors.Is(err, service.ErrUnknownWebhookEvent) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("CreateSubscription failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось создать подписку"})
+		return
+	}
+
+	// секрет показываем только один раз — при создании
+	c.JSON(http.StatusCreated, gin.H{
+		"subscription": sub,
+		"secret":       sub.Secret,
+	})
+}
+
+func (h *WebhookHandler) UpdateSubscription(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID подписки"})
+		return
+	}
+
+	var req models.WebhookSubscriptionDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("UpdateSubscription invalid body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub, err := h.service.UpdateSubscription(uint(id), req)
+	if errors.Is(err, service.ErrUnknownWebhookEvent) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("UpdateSubscription failed", "subscription_id", id, "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "подписка не найдена"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось обновить подписку"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID подписки"})
+		return
+	}
+
+	if err := h.service.DeleteSubscription(uint(id)); err != nil {
+		h.logger.Error("DeleteSubscription failed", "subscription_id", id, "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "подписка не найдена"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось удалить подписку"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "подписка удалена"})
+}
+
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID подписки"})
+		return
+	}
+
+	var filter models.FilterWebhookDelivery
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deliveries, err := h.service.ListDeliveries(uint(id), &filter)
+	if err != nil {
+		h.logger.Error("ListDeliveries failed", "subscription_id", id, "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "подписка не найдена"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось получить журнал доставок"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID доставки"})
+		return
+	}
+
+	if err := h.service.Redeliver(uint(id)); err != nil {
+		h.logger.Error("Redeliver failed", "delivery_id", id, "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "доставка не найдена"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "не удалось поставить доставку в очередь"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "доставка поставлена в очередь"})
+}